@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
@@ -11,13 +13,29 @@ import (
 
 const authBaseUrl = "https://auth.globus.org/v2"
 
+type authBaseUrlKey struct{}
+
+// Returns a context that makes the auth helpers use another Globus Auth base url (the real one
+// is "https://auth.globus.org/v2"), e.g. a fake Globus Auth server (see globustest.AuthServer).
+func AuthContextWithBaseUrl(ctx context.Context, url string) context.Context {
+	return context.WithValue(ctx, authBaseUrlKey{}, strings.TrimSuffix(url, "/"))
+}
+
+// the Globus Auth base url to use for requests made with the given context
+func authURL(ctx context.Context) string {
+	if url, ok := ctx.Value(authBaseUrlKey{}).(string); ok && url != "" {
+		return url
+	}
+	return authBaseUrl
+}
+
 // Returns a two-legged (client credental) http client with oauth2 authentication.
 // The function can fail if the token acquisition check fails.
 func AuthCreateServiceClient(ctx context.Context, clientID string, clientSecret string, scopes []string) (client GlobusClient, err error) {
 	conf := clientcredentials.Config{
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
-		TokenURL:     authBaseUrl + "/oauth2/token",
+		TokenURL:     authURL(ctx) + "/oauth2/token",
 		Scopes:       scopes,
 	}
 
@@ -30,19 +48,66 @@ func AuthCreateServiceClient(ctx context.Context, clientID string, clientSecret
 	return GlobusClient{client: conf.Client(ctx)}, nil
 }
 
+// Returns an http client authenticated with the tokens obtained through a refresh token, e.g. one
+// printed by the getRefreshToken command of the CLI. The access token is refreshed automatically.
+// If Globus Auth rotates the refresh token, onRotate (optional) is called with the new one, which
+// has to be stored, as the old one becomes invalid.
+// The function can fail if the token acquisition check fails.
+func AuthCreateClientFromRefreshToken(ctx context.Context, clientID string, clientSecret string, refreshToken string, scopes []string, onRotate func(refreshToken string)) (client GlobusClient, err error) {
+	conf := AuthGenerateOauthClientConfig(ctx, clientID, clientSecret, "", scopes)
+	ts := &rotationNotifyingTokenSource{
+		source:       conf.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}),
+		refreshToken: refreshToken,
+		onRotate:     onRotate,
+	}
+
+	// token acquisition check
+	_, tokenError := ts.Token()
+	if tokenError != nil {
+		return GlobusClient{}, fmt.Errorf("error getting token from refresh token: %s", tokenError.Error())
+	}
+
+	return GlobusClient{client: oauth2.NewClient(ctx, ts)}, nil
+}
+
+// passes on the tokens of a token source and reports changes of the refresh token
+type rotationNotifyingTokenSource struct {
+	source       oauth2.TokenSource
+	onRotate     func(refreshToken string)
+	mu           sync.Mutex
+	refreshToken string
+}
+
+func (s *rotationNotifyingTokenSource) Token() (*oauth2.Token, error) {
+	tok, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tok.RefreshToken != "" && tok.RefreshToken != s.refreshToken {
+		s.refreshToken = tok.RefreshToken
+		if s.onRotate != nil {
+			s.onRotate(tok.RefreshToken)
+		}
+	}
+	return tok, nil
+}
+
 func HttpClientToGlobusClient(client *http.Client) GlobusClient {
 	return GlobusClient{client: client}
 }
 
 // This is a very basic function that returns an oauth2 config
-// with the token url hard-coded to the one provided by Globus.
+// with the urls of Globus Auth (or the ones set with AuthContextWithBaseUrl).
 func AuthGenerateOauthClientConfig(ctx context.Context, clientID string, clientSecret string, redirectURL string, scopes []string) (conf oauth2.Config) {
 	conf = oauth2.Config{
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
 		Endpoint: oauth2.Endpoint{
-			TokenURL: authBaseUrl + "/oauth2/token",
-			AuthURL:  "https://auth.globus.org/v2/oauth2/authorize",
+			TokenURL: authURL(ctx) + "/oauth2/token",
+			AuthURL:  authURL(ctx) + "/oauth2/authorize",
 		},
 		RedirectURL: redirectURL,
 		Scopes:      scopes,
@@ -50,3 +115,34 @@ func AuthGenerateOauthClientConfig(ctx context.Context, clientID string, clientS
 
 	return conf
 }
+
+// session requirements that can be added to an authorization request, e.g. to make
+// the user log in with a specific identity for the consent
+type ConsentSession struct {
+	RequiredIdentities   []string // session_required_identities
+	RequiredSingleDomain []string // session_required_single_domain
+	Message              string   // session_message, shown to the user on the login page
+}
+
+// Returns the authorization URL options that force a new login with the given session parameters.
+func AuthConsentOptions(session ConsentSession) []oauth2.AuthCodeOption {
+	opts := []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("prompt", "login")}
+	if len(session.RequiredIdentities) > 0 {
+		opts = append(opts, oauth2.SetAuthURLParam("session_required_identities", strings.Join(session.RequiredIdentities, ",")))
+	}
+	if len(session.RequiredSingleDomain) > 0 {
+		opts = append(opts, oauth2.SetAuthURLParam("session_required_single_domain", strings.Join(session.RequiredSingleDomain, ",")))
+	}
+	if session.Message != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("session_message", session.Message))
+	}
+	return opts
+}
+
+// Builds an authorization URL that requests exactly the scopes that are missing according to
+// a consent error. The remaining options (e.g. PKCE challenge, offline access) are passed as-is.
+func AuthGenerateConsentURL(conf oauth2.Config, state string, consent *ConsentRequiredError, session ConsentSession, opts ...oauth2.AuthCodeOption) string {
+	conf.Scopes = consent.RequiredScopes
+	opts = append(opts, AuthConsentOptions(session)...)
+	return conf.AuthCodeURL(state, opts...)
+}
@@ -0,0 +1,116 @@
+package globus_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+	"golang.org/x/oauth2"
+)
+
+const (
+	appClientId     = "0f2c9a4e-0000-4000-8000-0000000000a1"
+	appClientSecret = "app-secret"
+	redirectURL     = "http://localhost:8080/callback"
+
+	transferScope = "urn:globus:auth:scope:transfer.api.globus.org:all"
+	groupsScope   = "urn:globus:auth:scope:groups.api.globus.org:all"
+)
+
+// logs the user of the server in to the app client with the authorization code flow
+func loginUser(t *testing.T, ctx context.Context, srv *globustest.AuthServer, scopes ...string) (oauth2.Config, *oauth2.Token) {
+	t.Helper()
+	conf := globus.AuthGenerateOauthClientConfig(ctx, appClientId, appClientSecret, redirectURL, scopes)
+	code, err := srv.ApproveCode(conf.AuthCodeURL("state", oauth2.AccessTypeOffline))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := conf.Exchange(ctx, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conf, tok
+}
+
+func TestAuthExchangeAndRefresh(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	ctx := srv.Context(context.Background())
+
+	conf, tok := loginUser(t, ctx, srv, transferScope, groupsScope)
+	set, err := globus.AuthNewTokenSet(ctx, conf, tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{globus.ResourceServerGroups, globus.ResourceServerTransfer}
+	if servers := set.ResourceServers(); !slices.Equal(servers, want) {
+		t.Fatalf("got tokens for %v, want %v", servers, want)
+	}
+
+	issued, ok := srv.Token(tok.AccessToken)
+	if !ok || issued.IdentityId != srv.User.IdentityId || issued.ResourceServer != globus.ResourceServerTransfer {
+		t.Fatalf("exchanged token %+v doesn't belong to the user and Transfer", issued)
+	}
+	if tok.RefreshToken == "" {
+		t.Fatal("no refresh token despite offline access")
+	}
+
+	expired := *tok
+	expired.Expiry = time.Now().Add(-time.Hour)
+	refreshed, err := conf.TokenSource(ctx, &expired).Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.AccessToken == tok.AccessToken {
+		t.Error("the token wasn't refreshed")
+	}
+	if issued, ok := srv.Token(refreshed.AccessToken); !ok || !slices.Equal(issued.Scopes, []string{transferScope}) {
+		t.Errorf("refreshed token has scopes %v, want [%s]", issued.Scopes, transferScope)
+	}
+}
+
+func TestAuthRefreshTokenRotation(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	srv.RotateRefreshTokens = true
+	ctx := srv.Context(context.Background())
+
+	_, tok := loginUser(t, ctx, srv, transferScope)
+	var rotated string
+	_, err := globus.AuthCreateClientFromRefreshToken(ctx, appClientId, appClientSecret, tok.RefreshToken, []string{transferScope}, func(refreshToken string) {
+		rotated = refreshToken
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated == "" || rotated == tok.RefreshToken {
+		t.Errorf("got rotated refresh token '%s', want a new one", rotated)
+	}
+
+	// the old refresh token is invalid now
+	_, err = globus.AuthCreateClientFromRefreshToken(ctx, appClientId, appClientSecret, tok.RefreshToken, []string{transferScope}, nil)
+	if err == nil {
+		t.Error("the rotated refresh token can still be used")
+	}
+}
+
+func TestAuthCreateServiceClientConsentRequired(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	srv.RequireConsent(transferScope)
+	ctx := srv.Context(context.Background())
+
+	if _, err := globus.AuthCreateServiceClient(ctx, appClientId, appClientSecret, []string{transferScope}); err == nil {
+		t.Fatal("got a token without consent")
+	}
+	srv.GrantConsent(appClientId+"@clients.auth.globus.org", transferScope)
+	if _, err := globus.AuthCreateServiceClient(ctx, appClientId, appClientSecret, []string{transferScope}); err != nil {
+		t.Fatal(err)
+	}
+}
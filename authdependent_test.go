@@ -0,0 +1,66 @@
+package globus_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+)
+
+const (
+	serviceClientId     = "0f2c9a4e-0000-4000-8000-0000000000b2"
+	serviceClientSecret = "service-secret"
+)
+
+// the scope of the service, with Transfer as dependency
+var serviceScope = "https://auth.globus.org/scopes/" + serviceClientId + "/all[" + transferScope + "]"
+
+func TestAuthGetDependentTokens(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	srv.AddClient(serviceClientId, serviceClientSecret)
+	ctx := srv.Context(context.Background())
+
+	_, userToken := loginUser(t, ctx, srv, serviceScope)
+	set, err := globus.AuthGetDependentTokens(ctx, serviceClientId, serviceClientSecret, userToken.AccessToken, globus.DependentTokenOptions{Offline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if servers := set.ResourceServers(); !slices.Equal(servers, []string{globus.ResourceServerTransfer}) {
+		t.Fatalf("got dependent tokens for %v, want [%s]", servers, globus.ResourceServerTransfer)
+	}
+
+	source, err := set.TokenSource(globus.ResourceServerTransfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := source.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued, ok := srv.Token(tok.AccessToken)
+	if !ok || issued.ClientId != serviceClientId || issued.IdentityId != srv.User.IdentityId {
+		t.Errorf("dependent token %+v wasn't issued to the service on behalf of the user", issued)
+	}
+	if tok.RefreshToken == "" {
+		t.Error("no refresh token despite offline access")
+	}
+}
+
+func TestAuthGetDependentTokensWrongResourceServer(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	srv.AddClient(serviceClientId, serviceClientSecret)
+	ctx := srv.Context(context.Background())
+
+	// a Transfer token wasn't issued for the service, so it can't be exchanged by it
+	_, userToken := loginUser(t, ctx, srv, transferScope)
+	_, err := globus.AuthGetDependentTokens(ctx, serviceClientId, serviceClientSecret, userToken.AccessToken, globus.DependentTokenOptions{})
+	if err == nil {
+		t.Fatal("exchanged a token of another resource server")
+	}
+}
@@ -0,0 +1,191 @@
+package globus
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// the maximum number of ids or usernames Globus Auth resolves in one request
+const identitiesBatchSize = 100
+
+// A Globus identity, e.g. the owner of a task. Status is one of "unused", "used", "private" and
+// "closed"; the details of private identities are only visible to the identity itself.
+type Identity struct {
+	Id               string `json:"id"`
+	Username         string `json:"username"`
+	Name             string `json:"name"`
+	Email            string `json:"email"`
+	Organization     string `json:"organization"`
+	Status           string `json:"status"`
+	IdentityProvider string `json:"identity_provider"`
+}
+
+// Looks up identities by id or username (entries that look like UUIDs are ids, the others
+// usernames) in Globus Auth, in batches of up to 100. Unknown ones are left out of the result.
+// The token source must provide a token for Globus Auth itself with the view_identities scope.
+func AuthGetIdentities(ctx context.Context, src oauth2.TokenSource, idsOrUsernames ...string) ([]Identity, error) {
+	var ids, usernames []string
+	for _, value := range idsOrUsernames {
+		if isUUID(value) {
+			ids = append(ids, value)
+		} else {
+			usernames = append(usernames, value)
+		}
+	}
+
+	client := oauth2.NewClient(ctx, src)
+	var identities []Identity
+	for _, lookup := range []struct {
+		param  string
+		values []string
+	}{{"ids", ids}, {"usernames", usernames}} {
+		for start := 0; start < len(lookup.values); start += identitiesBatchSize {
+			end := min(start+identitiesBatchSize, len(lookup.values))
+			batch, err := getIdentities(ctx, client, lookup.param, lookup.values[start:end])
+			if err != nil {
+				return nil, err
+			}
+			identities = append(identities, batch...)
+		}
+	}
+	return identities, nil
+}
+
+func getIdentities(ctx context.Context, client *http.Client, param string, values []string) ([]Identity, error) {
+	query := url.Values{param: {strings.Join(values, ",")}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, authURL(ctx)+"/api/identities?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error getting identities: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			Message:    fmt.Sprintf("error getting identities: unknown http code %d, body: \"%s\"", resp.StatusCode, string(body)),
+		}
+	}
+
+	var result struct {
+		Identities []Identity `json:"identities"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("could not parse identities: %v", err)
+	}
+	return result.Identities, nil
+}
+
+// Resolves ids and usernames to identities through AuthGetIdentities and keeps the most recently
+// used ones in a cache of limited size, e.g. to show the owners of a long task list. Usernames are
+// matched case-insensitively, like Globus Auth does.
+type IdentityResolver struct {
+	src      oauth2.TokenSource
+	capacity int
+
+	mu      sync.Mutex
+	lru     *list.List               // of Identity, most recently used first
+	entries map[string]*list.Element // by identityKey of the id and of the username
+}
+
+// creates a resolver caching up to capacity identities
+func NewIdentityResolver(src oauth2.TokenSource, capacity int) *IdentityResolver {
+	return &IdentityResolver{
+		src:      src,
+		capacity: capacity,
+		lru:      list.New(),
+		entries:  map[string]*list.Element{},
+	}
+}
+
+// the cache key of an id or username, which Globus Auth doesn't distinguish by case
+func identityKey(idOrUsername string) string {
+	return strings.ToLower(idOrUsername)
+}
+
+// Returns the identities of the given ids and usernames, by the id or username they were
+// requested with. Only the ones missing from the cache are looked up, unknown ones are left out.
+func (r *IdentityResolver) Resolve(ctx context.Context, idsOrUsernames ...string) (map[string]Identity, error) {
+	// the values requested for each key, e.g. "Alice@example.org" and "alice@example.org"
+	requested := map[string][]string{}
+	var keys []string
+	for _, value := range idsOrUsernames {
+		key := identityKey(value)
+		if _, ok := requested[key]; !ok {
+			keys = append(keys, key)
+		}
+		requested[key] = append(requested[key], value)
+	}
+
+	found := map[string]Identity{}
+	var missing []string
+	r.mu.Lock()
+	for _, key := range keys {
+		if element, ok := r.entries[key]; ok {
+			r.lru.MoveToFront(element)
+			found[key] = element.Value.(Identity)
+		} else {
+			missing = append(missing, requested[key][0])
+		}
+	}
+	r.mu.Unlock()
+
+	if len(missing) > 0 {
+		fetched, err := AuthGetIdentities(ctx, r.src, missing...)
+		if err != nil {
+			return nil, err
+		}
+		r.mu.Lock()
+		for _, identity := range fetched {
+			r.add(identity)
+			found[identityKey(identity.Id)] = identity
+			found[identityKey(identity.Username)] = identity
+		}
+		r.mu.Unlock()
+	}
+
+	// only return what was asked for, the usernames of looked up ids weren't
+	identities := map[string]Identity{}
+	for key, values := range requested {
+		if identity, ok := found[key]; ok {
+			for _, value := range values {
+				identities[value] = identity
+			}
+		}
+	}
+	return identities, nil
+}
+
+func (r *IdentityResolver) add(identity Identity) {
+	if element, ok := r.entries[identityKey(identity.Id)]; ok {
+		r.lru.Remove(element)
+		delete(r.entries, identityKey(element.Value.(Identity).Username))
+	}
+	element := r.lru.PushFront(identity)
+	r.entries[identityKey(identity.Id)] = element
+	r.entries[identityKey(identity.Username)] = element
+
+	for r.lru.Len() > r.capacity {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.entries, identityKey(oldest.Value.(Identity).Id))
+		delete(r.entries, identityKey(oldest.Value.(Identity).Username))
+	}
+}
@@ -0,0 +1,148 @@
+package globus_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+	"golang.org/x/oauth2"
+)
+
+// counts the requests to the identities API
+type identitiesCounter struct {
+	transport http.RoundTripper
+	requests  atomic.Int32
+}
+
+func (c *identitiesCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, "/api/identities") {
+		c.requests.Add(1)
+	}
+	return c.transport.RoundTrip(req)
+}
+
+func testIdentity(i int) globus.Identity {
+	return globus.Identity{
+		Id:       fmt.Sprintf("3f9a7c21-0000-4000-8000-%012d", i),
+		Username: fmt.Sprintf("user%d@example.org", i),
+		Name:     fmt.Sprintf("User %d", i),
+		Status:   "used",
+	}
+}
+
+// starts an auth server knowing n identities besides the user, returns a context counting the
+// identities requests and a token source for Globus Auth
+func identitiesServer(t *testing.T, n int) (*globustest.AuthServer, context.Context, *identitiesCounter, oauth2.TokenSource) {
+	t.Helper()
+	srv := globustest.NewAuthServer()
+	t.Cleanup(srv.Close)
+	srv.AddClient(appClientId, appClientSecret)
+	for i := 0; i < n; i++ {
+		srv.AddIdentity(testIdentity(i))
+	}
+
+	counter := &identitiesCounter{transport: http.DefaultTransport}
+	ctx := context.WithValue(srv.Context(context.Background()), oauth2.HTTPClient, &http.Client{Transport: counter})
+	conf, tok := loginUser(t, ctx, srv, globus.ScopeAuthViewIdentities)
+	return srv, ctx, counter, conf.TokenSource(ctx, tok)
+}
+
+func TestAuthGetIdentities(t *testing.T) {
+	srv, ctx, counter, src := identitiesServer(t, 150)
+
+	var values []string
+	for i := 0; i < 150; i++ {
+		values = append(values, testIdentity(i).Id)
+	}
+	values = append(values, strings.ToUpper(srv.User.Username), "nobody@example.org")
+	identities, err := globus.AuthGetIdentities(ctx, src, values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identities) != 151 {
+		t.Errorf("got %d identities, want 151", len(identities))
+	}
+	if identities[0] != testIdentity(0) || identities[150].Id != srv.User.IdentityId {
+		t.Errorf("got identities %+v ... %+v, want the ones of the ids, then the user", identities[0], identities[150])
+	}
+	// two batches of ids and one of usernames
+	if requests := counter.requests.Load(); requests != 3 {
+		t.Errorf("got %d requests, want 3", requests)
+	}
+}
+
+func TestIdentityResolver(t *testing.T) {
+	_, ctx, counter, src := identitiesServer(t, 150)
+	resolver := globus.NewIdentityResolver(src, 200)
+
+	values := []string{testIdentity(0).Username, "USER0@example.org", "nobody@example.org"}
+	for i := 0; i < 150; i++ {
+		values = append(values, testIdentity(i).Id)
+	}
+	identities, err := resolver.Resolve(ctx, values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// both spellings of the username are in the result
+	if len(identities) != 152 {
+		t.Errorf("got %d identities, want 152", len(identities))
+	}
+	for _, value := range []string{testIdentity(0).Username, "USER0@example.org", testIdentity(0).Id} {
+		if identities[value] != testIdentity(0) {
+			t.Errorf("got %+v for '%s', want %+v", identities[value], value, testIdentity(0))
+		}
+	}
+	if _, ok := identities["nobody@example.org"]; ok {
+		t.Error("got an identity for an unknown username")
+	}
+	// two batches of ids and one of usernames
+	if requests := counter.requests.Load(); requests != 3 {
+		t.Errorf("got %d requests, want 3", requests)
+	}
+
+	// the usernames of looked up ids are cached too, in any case
+	identities, err = resolver.Resolve(ctx, testIdentity(1).Username, "User149@Example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identities[testIdentity(1).Username] != testIdentity(1) || identities["User149@Example.org"] != testIdentity(149) {
+		t.Errorf("got %v, want the identities 1 and 149", identities)
+	}
+	if requests := counter.requests.Load(); requests != 3 {
+		t.Errorf("got %d requests, want the identities from the cache", requests)
+	}
+}
+
+func TestIdentityResolverEviction(t *testing.T) {
+	_, ctx, counter, src := identitiesServer(t, 3)
+	resolver := globus.NewIdentityResolver(src, 2)
+
+	resolve := func(value string, wantRequests int32) {
+		t.Helper()
+		before := counter.requests.Load()
+		identities, err := resolver.Resolve(ctx, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(identities) != 1 {
+			t.Errorf("got %d identities for '%s', want 1", len(identities), value)
+		}
+		if requests := counter.requests.Load() - before; requests != wantRequests {
+			t.Errorf("resolving '%s' took %d requests, want %d", value, requests, wantRequests)
+		}
+	}
+
+	resolve(testIdentity(0).Id, 1)
+	resolve(testIdentity(1).Id, 1)
+	// identity 0 becomes the most recently used one, so identity 1 is evicted for identity 2
+	resolve(testIdentity(0).Username, 0)
+	resolve(testIdentity(2).Id, 1)
+	resolve(testIdentity(0).Id, 0)
+	resolve(testIdentity(2).Username, 0)
+	resolve(testIdentity(1).Id, 1)
+}
@@ -0,0 +1,309 @@
+package globus
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// the issuer of the id tokens of Globus Auth
+const authIssuer = "https://auth.globus.org"
+
+const (
+	// how long fetched signing keys are used before they're fetched again
+	jwksMaxAge = 24 * time.Hour
+	// minimum time between fetches caused by unknown key ids, so forged tokens can't flood Globus Auth
+	jwksMinRefetchInterval = time.Minute
+	// tolerated clock skew when checking the expiry and issue time
+	idTokenLeeway = time.Minute
+)
+
+// an identity linked to the one of the user, as in the identity set of an id token or userinfo
+type IdentitySetEntry struct {
+	Sub                         string `json:"sub"`
+	Name                        string `json:"name,omitempty"`
+	Username                    string `json:"username,omitempty"`
+	Email                       string `json:"email,omitempty"`
+	Organization                string `json:"organization,omitempty"`
+	IdentityProvider            string `json:"identity_provider,omitempty"`
+	IdentityProviderDisplayName string `json:"identity_provider_display_name,omitempty"`
+	LastAuthentication          int64  `json:"last_authentication,omitempty"`
+}
+
+// The identity claims of the logged in user, as returned by the userinfo endpoint. Which of them
+// are set depends on the granted scopes: "profile" for the names, "email" for the email.
+type UserInfo struct {
+	Sub                         string             `json:"sub"`
+	Name                        string             `json:"name,omitempty"`
+	Email                       string             `json:"email,omitempty"`
+	PreferredUsername           string             `json:"preferred_username,omitempty"`
+	Organization                string             `json:"organization,omitempty"`
+	IdentityProvider            string             `json:"identity_provider,omitempty"`
+	IdentityProviderDisplayName string             `json:"identity_provider_display_name,omitempty"`
+	IdentitySet                 []IdentitySetEntry `json:"identity_set,omitempty"`
+}
+
+// the claims of a verified id token
+type IDTokenClaims struct {
+	UserInfo
+	Iss      string   `json:"iss"`
+	Aud      audience `json:"aud"`
+	Exp      int64    `json:"exp"`
+	Iat      int64    `json:"iat"`
+	Nonce    string   `json:"nonce,omitempty"`
+	AtHash   string   `json:"at_hash,omitempty"`
+	AuthTime int64    `json:"auth_time,omitempty"`
+}
+
+// the "aud" claim, which is either a single string or a list
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// Verifies id tokens issued to a client: the signature against the signing keys of Globus Auth
+// (JWKS), the issuer, the audience and the expiry. The keys are cached and fetched again when
+// a token is signed with an unknown key, so a rotation of the keys is picked up.
+type IDTokenVerifier struct {
+	clientID string
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetched   time.Time
+	refetched time.Time // last fetch caused by an unknown key id
+}
+
+// creates a verifier for the id tokens of a client
+func NewIDTokenVerifier(clientID string) *IDTokenVerifier {
+	return &IDTokenVerifier{clientID: clientID}
+}
+
+// Verifies a raw id token and returns its claims. ctx is used if the signing keys have to be
+// fetched, it should always point to the same Globus Auth (see AuthContextWithBaseUrl).
+func (v *IDTokenVerifier) Verify(ctx context.Context, rawIDToken string) (*IDTokenClaims, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("the id token is not a JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid id token header: %v", err)
+	}
+	hash, ok := map[string]crypto.Hash{"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512}[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("unsupported id token algorithm '%s'", header.Alg)
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token signature: %v", err)
+	}
+	h := hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, hash, h.Sum(nil), signature); err != nil {
+		return nil, errors.New("the signature of the id token is invalid")
+	}
+
+	var claims IDTokenClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid id token claims: %v", err)
+	}
+	now := time.Now()
+	switch {
+	case claims.Iss != authIssuer:
+		return nil, fmt.Errorf("the id token was issued by '%s' instead of '%s'", claims.Iss, authIssuer)
+	case !claims.Aud.contains(v.clientID):
+		return nil, fmt.Errorf("the id token was issued for %v instead of client '%s'", []string(claims.Aud), v.clientID)
+	case now.After(time.Unix(claims.Exp, 0).Add(idTokenLeeway)):
+		return nil, errors.New("the id token is expired")
+	case now.Before(time.Unix(claims.Iat, 0).Add(-idTokenLeeway)):
+		return nil, errors.New("the id token was issued in the future")
+	}
+	return &claims, nil
+}
+
+// returns the signing key with the given id, fetching the keys if it's unknown or they're outdated
+func (v *IDTokenVerifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, known := v.keys[kid]
+	outdated := time.Since(v.fetched) > jwksMaxAge
+	if known && !outdated {
+		return key, nil
+	}
+	if outdated || time.Since(v.refetched) > jwksMinRefetchInterval {
+		keys, err := fetchJWKS(ctx)
+		if err != nil {
+			if known {
+				return key, nil // keep using the outdated key while Globus Auth can't be reached
+			}
+			return nil, err
+		}
+		v.keys, v.fetched = keys, time.Now()
+		if !outdated {
+			v.refetched = v.fetched
+		}
+	}
+	key, known = v.keys[kid]
+	if !known {
+		return nil, fmt.Errorf("the id token is signed with an unknown key '%s'", kid)
+	}
+	return key, nil
+}
+
+// fetches the RSA signing keys of Globus Auth, by key id
+func fetchJWKS(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	jwksURL := strings.TrimSuffix(authURL(ctx), "/v2") + "/jwk.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := authHTTPClient(ctx).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch the signing keys: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch the signing keys: unknown http code %d", resp.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("could not parse the signing keys: %v", err)
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			return nil, fmt.Errorf("invalid signing key '%s'", jwk.Kid)
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// verifiers by client and Globus Auth url, so the keys are cached across calls of AuthVerifyIDToken
+var (
+	idTokenVerifiersMu sync.Mutex
+	idTokenVerifiers   = map[string]*IDTokenVerifier{}
+)
+
+// Verifies the id token of a token response, which Globus Auth includes when the "openid" scope
+// is requested, and returns its claims. Fails if the response contains no id token.
+func AuthVerifyIDToken(ctx context.Context, clientID string, tok *oauth2.Token) (*IDTokenClaims, error) {
+	rawIDToken, ok := tok.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("the token response contains no id token, request the openid scope")
+	}
+
+	verifierKey := clientID + " " + authURL(ctx)
+	idTokenVerifiersMu.Lock()
+	verifier, ok := idTokenVerifiers[verifierKey]
+	if !ok {
+		verifier = NewIDTokenVerifier(clientID)
+		idTokenVerifiers[verifierKey] = verifier
+	}
+	idTokenVerifiersMu.Unlock()
+
+	return verifier.Verify(ctx, rawIDToken)
+}
+
+// Returns the identity of the logged in user from the userinfo endpoint, using a token for Globus
+// Auth itself (resource server ResourceServerAuth, obtained with the "openid" scope).
+func AuthGetUserInfo(ctx context.Context, src oauth2.TokenSource) (*UserInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, authURL(ctx)+"/oauth2/userinfo", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := oauth2.NewClient(ctx, src).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user info: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			Message:    fmt.Sprintf("error getting user info: unknown http code %d, body: \"%s\"", resp.StatusCode, string(body)),
+		}
+	}
+
+	var info UserInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("could not parse user info: %v", err)
+	}
+	return &info, nil
+}
@@ -0,0 +1,210 @@
+package globus_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+	"golang.org/x/oauth2"
+)
+
+// counts the requests for the signing keys
+type jwksCounter struct {
+	transport http.RoundTripper
+	fetches   atomic.Int32
+}
+
+func (c *jwksCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, "/jwk.json") {
+		c.fetches.Add(1)
+	}
+	return c.transport.RoundTrip(req)
+}
+
+func idTokenClaims(srv *globustest.AuthServer) globus.IDTokenClaims {
+	now := time.Now()
+	return globus.IDTokenClaims{
+		UserInfo: globus.UserInfo{Sub: srv.User.IdentityId},
+		Iss:      "https://auth.globus.org",
+		Aud:      []string{appClientId},
+		Exp:      now.Add(time.Hour).Unix(),
+		Iat:      now.Unix(),
+	}
+}
+
+func signIDToken(t *testing.T, srv *globustest.AuthServer, claims globus.IDTokenClaims) string {
+	t.Helper()
+	token, err := srv.SignIDToken(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// replaces the header of a JWT, keeping its payload and signature
+func withJWTHeader(t *testing.T, token string, header map[string]string) string {
+	t.Helper()
+	data, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	parts[0] = base64.RawURLEncoding.EncodeToString(data)
+	return strings.Join(parts, ".")
+}
+
+func jwtHeader(t *testing.T, token string) (header map[string]string) {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		t.Fatal(err)
+	}
+	return header
+}
+
+func TestAuthVerifyIDToken(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	ctx := srv.Context(context.Background())
+
+	_, tok := loginUser(t, ctx, srv, "openid", "profile", "email")
+	claims, err := globus.AuthVerifyIDToken(ctx, appClientId, tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Sub != srv.User.IdentityId || claims.PreferredUsername != srv.User.Username || claims.Email != srv.User.Email {
+		t.Errorf("got claims %+v, want the ones of %+v", claims, srv.User)
+	}
+
+	// the token was issued to the app client only
+	if _, err := globus.AuthVerifyIDToken(ctx, serviceClientId, tok); err == nil {
+		t.Error("the id token was accepted for another client")
+	}
+
+	_, tok = loginUser(t, ctx, srv, transferScope)
+	if _, err := globus.AuthVerifyIDToken(ctx, appClientId, tok); err == nil {
+		t.Error("a token response without id token was accepted")
+	}
+}
+
+func TestIDTokenVerifierInvalidTokens(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	ctx := srv.Context(context.Background())
+	verifier := globus.NewIDTokenVerifier(appClientId)
+
+	valid := signIDToken(t, srv, idTokenClaims(srv))
+	if _, err := verifier.Verify(ctx, valid); err != nil {
+		t.Fatalf("a valid id token was rejected: %v", err)
+	}
+
+	// the payload of another token with the signature of the valid one
+	other := idTokenClaims(srv)
+	other.Sub = "6c2e5f8b-0000-4000-8000-0000000000ff"
+	badSignature := strings.Split(signIDToken(t, srv, other), ".")
+	badSignature[2] = strings.Split(valid, ".")[2]
+
+	header := jwtHeader(t, valid)
+	unsigned := strings.Split(withJWTHeader(t, valid, map[string]string{"alg": "none", "typ": "JWT", "kid": header["kid"]}), ".")
+	unsigned[2] = ""
+
+	modified := func(modify func(claims *globus.IDTokenClaims)) string {
+		claims := idTokenClaims(srv)
+		modify(&claims)
+		return signIDToken(t, srv, claims)
+	}
+	tests := map[string]string{
+		"bad signature":   strings.Join(badSignature, "."),
+		"alg none":        strings.Join(unsigned, "."),
+		"alg HS256":       withJWTHeader(t, valid, map[string]string{"alg": "HS256", "typ": "JWT", "kid": header["kid"]}),
+		"wrong audience":  modified(func(c *globus.IDTokenClaims) { c.Aud = []string{serviceClientId} }),
+		"wrong issuer":    modified(func(c *globus.IDTokenClaims) { c.Iss = "https://auth.example.org" }),
+		"expired":         modified(func(c *globus.IDTokenClaims) { c.Exp = time.Now().Add(-time.Hour).Unix() }),
+		"issued in 1 day": modified(func(c *globus.IDTokenClaims) { c.Iat = time.Now().Add(24 * time.Hour).Unix() }),
+		"not a JWT":       "not-a-jwt",
+	}
+	for name, token := range tests {
+		if claims, err := verifier.Verify(ctx, token); err == nil {
+			t.Errorf("%s: the id token was accepted with claims %+v", name, claims)
+		} else {
+			t.Logf("%s: %v", name, err)
+		}
+	}
+
+	// a list of audiences and an expiry within the tolerated clock skew are fine
+	token := modified(func(c *globus.IDTokenClaims) {
+		c.Aud = []string{serviceClientId, appClientId}
+		c.Exp = time.Now().Add(-10 * time.Second).Unix()
+	})
+	if _, err := verifier.Verify(ctx, token); err != nil {
+		t.Errorf("a token for several audiences within the clock skew was rejected: %v", err)
+	}
+}
+
+func TestIDTokenVerifierKeyRotation(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	counter := &jwksCounter{transport: http.DefaultTransport}
+	ctx := context.WithValue(srv.Context(context.Background()), oauth2.HTTPClient, &http.Client{Transport: counter})
+	verifier := globus.NewIDTokenVerifier(appClientId)
+
+	for i := 0; i < 2; i++ {
+		if _, err := verifier.Verify(ctx, signIDToken(t, srv, idTokenClaims(srv))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fetches := counter.fetches.Load(); fetches != 1 {
+		t.Errorf("the keys were fetched %d times, want them cached", fetches)
+	}
+
+	// a token signed with the new, unknown key makes the verifier fetch the keys again
+	if err := srv.RotateSigningKey(); err != nil {
+		t.Fatal(err)
+	}
+	rotated := signIDToken(t, srv, idTokenClaims(srv))
+	if _, err := verifier.Verify(ctx, rotated); err != nil {
+		t.Fatalf("a token signed with the rotated key was rejected: %v", err)
+	}
+	if fetches := counter.fetches.Load(); fetches != 2 {
+		t.Errorf("the keys were fetched %d times, want 2", fetches)
+	}
+
+	// tokens of keys that don't exist don't cause fetches right after the last one
+	for _, kid := range []string{"forged-1", "forged-2"} {
+		forged := withJWTHeader(t, rotated, map[string]string{"alg": "RS512", "typ": "JWT", "kid": kid})
+		if _, err := verifier.Verify(ctx, forged); err == nil {
+			t.Errorf("a token signed with the unknown key %s was accepted", kid)
+		}
+	}
+	if fetches := counter.fetches.Load(); fetches != 2 {
+		t.Errorf("the keys were fetched %d times for unknown keys, want 2", fetches)
+	}
+}
+
+func TestIDTokenVerifierContext(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	verifier := globus.NewIDTokenVerifier(appClientId)
+	token := signIDToken(t, srv, idTokenClaims(srv))
+
+	// the context of a call is only used for that call's fetch
+	cancelled, cancel := context.WithCancel(srv.Context(context.Background()))
+	cancel()
+	if _, err := verifier.Verify(cancelled, token); err == nil {
+		t.Fatal("the keys were fetched with a cancelled context")
+	}
+	if _, err := verifier.Verify(srv.Context(context.Background()), token); err != nil {
+		t.Errorf("the keys weren't fetched after a cancelled call: %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package globus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The claims of a token, as returned by the introspection endpoint of Globus Auth. Inactive
+// (expired, revoked or unknown) tokens only have Active set to false.
+type TokenIntrospection struct {
+	Active      bool     `json:"active"`
+	Scope       string   `json:"scope,omitempty"`
+	ClientId    string   `json:"client_id,omitempty"`
+	Sub         string   `json:"sub,omitempty"`
+	Username    string   `json:"username,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Email       string   `json:"email,omitempty"`
+	Aud         []string `json:"aud,omitempty"`
+	Iss         string   `json:"iss,omitempty"`
+	Exp         int64    `json:"exp,omitempty"`
+	Iat         int64    `json:"iat,omitempty"`
+	Nbf         int64    `json:"nbf,omitempty"`
+	IdentitySet []string `json:"identity_set,omitempty"`
+}
+
+// the scopes of the token
+func (t *TokenIntrospection) Scopes() []string {
+	return strings.Fields(t.Scope)
+}
+
+// whether the token has all of the given scopes
+func (t *TokenIntrospection) HasScopes(scopes ...string) bool {
+	granted := map[string]bool{}
+	for _, scope := range t.Scopes() {
+		granted[scope] = true
+	}
+	for _, scope := range scopes {
+		if !granted[scope] {
+			return false
+		}
+	}
+	return true
+}
+
+// the expiry of the token
+func (t *TokenIntrospection) Expiry() time.Time {
+	return time.Unix(t.Exp, 0)
+}
+
+// Asks Globus Auth about a token received by a service, e.g. as bearer token of a request. The
+// client id and secret are the ones of the service (the resource server of the token); the
+// identity set, i.e. all identities linked to the user's identity, is included.
+func AuthIntrospectToken(ctx context.Context, clientID string, clientSecret string, token string) (*TokenIntrospection, error) {
+	form := url.Values{
+		"token":   {token},
+		"include": {"identity_set"},
+	}
+	body, err := authPostForm(ctx, clientID, clientSecret, "/oauth2/token/introspect", form)
+	if err != nil {
+		return nil, fmt.Errorf("error introspecting token: %v", err)
+	}
+
+	var introspection TokenIntrospection
+	if err := json.Unmarshal(body, &introspection); err != nil {
+		return nil, fmt.Errorf("could not parse introspection response: %v", err)
+	}
+	return &introspection, nil
+}
+
+type introspectionKey struct{}
+
+// returns the introspection of the request's token, set by the middleware of a TokenValidator
+func IntrospectionFromContext(ctx context.Context) (*TokenIntrospection, bool) {
+	introspection, ok := ctx.Value(introspectionKey{}).(*TokenIntrospection)
+	return introspection, ok
+}
+
+// Validates bearer tokens of incoming requests through introspection, e.g. for services that
+// accept Globus tokens from their clients. Only tokens issued for the service (the audience) are
+// accepted. Active introspection results are cached for CacheTTL (but never beyond the token's
+// expiry), so a revoked token may still be accepted for that long. Use NewTokenValidator to create one.
+type TokenValidator struct {
+	clientID     string
+	clientSecret string
+	// the context used for requests to Globus Auth, e.g. from AuthContextWithBaseUrl
+	ctx context.Context
+
+	// the resource server the tokens must be issued for (default: the client id of the service)
+	Audience string
+	// scopes the tokens must have, requests with tokens lacking one of them are rejected
+	RequiredScopes []string
+	// how long introspection results are cached, 0 disables the cache
+	CacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cachedIntrospection
+}
+
+type cachedIntrospection struct {
+	introspection *TokenIntrospection
+	expiry        time.Time
+}
+
+// creates a validator with the credentials of the service and a cache TTL of 5 minutes
+func NewTokenValidator(ctx context.Context, clientID string, clientSecret string, requiredScopes ...string) *TokenValidator {
+	return &TokenValidator{
+		clientID:       clientID,
+		clientSecret:   clientSecret,
+		ctx:            ctx,
+		Audience:       clientID,
+		RequiredScopes: requiredScopes,
+		CacheTTL:       5 * time.Minute,
+		cache:          map[[sha256.Size]byte]cachedIntrospection{},
+	}
+}
+
+// Returns the introspection of a token, from the cache if possible. Inactive tokens, tokens issued
+// for another audience and tokens without the required scopes result in an error.
+func (v *TokenValidator) Validate(ctx context.Context, token string) (*TokenIntrospection, error) {
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+
+	v.mu.Lock()
+	cached, ok := v.cache[key]
+	v.mu.Unlock()
+
+	introspection := cached.introspection
+	if !ok || now.After(cached.expiry) {
+		var err error
+		introspection, err = AuthIntrospectToken(mergedContext{ctx, v.ctx}, v.clientID, v.clientSecret, token)
+		if err != nil {
+			return nil, err
+		}
+		if introspection.Active && v.CacheTTL > 0 {
+			v.store(key, introspection, now)
+		}
+	}
+
+	if !introspection.Active || (introspection.Exp != 0 && now.After(introspection.Expiry())) {
+		return nil, &TokenValidationError{Status: http.StatusUnauthorized, Code: "invalid_token", Message: "the token is not active"}
+	}
+	if v.Audience != "" && !slices.Contains(introspection.Aud, v.Audience) {
+		return nil, &TokenValidationError{
+			Status:  http.StatusUnauthorized,
+			Code:    "invalid_token",
+			Message: fmt.Sprintf("the token was issued for %v instead of '%s'", introspection.Aud, v.Audience),
+		}
+	}
+	if !introspection.HasScopes(v.RequiredScopes...) {
+		return nil, &TokenValidationError{
+			Status:  http.StatusForbidden,
+			Code:    "insufficient_scope",
+			Message: fmt.Sprintf("the token lacks the required scopes %v", v.RequiredScopes),
+		}
+	}
+	return introspection, nil
+}
+
+func (v *TokenValidator) store(key [sha256.Size]byte, introspection *TokenIntrospection, now time.Time) {
+	expiry := now.Add(v.CacheTTL)
+	if introspection.Exp != 0 && introspection.Expiry().Before(expiry) {
+		expiry = introspection.Expiry()
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for k, cached := range v.cache {
+		if now.After(cached.expiry) {
+			delete(v.cache, k)
+		}
+	}
+	v.cache[key] = cachedIntrospection{introspection: introspection, expiry: expiry}
+}
+
+// Returns a middleware that rejects requests without a valid bearer token and passes the
+// introspection of the token on in the request context (see IntrospectionFromContext).
+func (v *TokenValidator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the auth scheme is case-insensitive (RFC 7235)
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			writeBearerError(w, &TokenValidationError{Status: http.StatusUnauthorized, Message: "a bearer token is required"}, v.RequiredScopes)
+			return
+		}
+
+		introspection, err := v.Validate(r.Context(), strings.TrimSpace(token))
+		if err != nil {
+			validationErr, ok := err.(*TokenValidationError)
+			if !ok {
+				http.Error(w, "could not validate the token", http.StatusBadGateway)
+				return
+			}
+			writeBearerError(w, validationErr, v.RequiredScopes)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), introspectionKey{}, introspection)))
+	})
+}
+
+// returned by TokenValidator.Validate for tokens that are rejected
+type TokenValidationError struct {
+	Status  int
+	Code    string // the bearer error code (RFC 6750), empty if no token was given
+	Message string
+}
+
+func (e *TokenValidationError) Error() string {
+	return e.Message
+}
+
+func writeBearerError(w http.ResponseWriter, err *TokenValidationError, requiredScopes []string) {
+	challenge := "Bearer"
+	if err.Code != "" {
+		challenge += fmt.Sprintf(` error="%s", error_description="%s"`, err.Code, err.Message)
+	}
+	if err.Code == "insufficient_scope" {
+		challenge += fmt.Sprintf(`, scope="%s"`, strings.Join(requiredScopes, " "))
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, err.Message, err.Status)
+}
+
+// a request context that falls back to the values of another context, e.g. the auth base url
+// of the validator's context
+type mergedContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c mergedContext) Value(key any) any {
+	if value := c.Context.Value(key); value != nil || c.values == nil {
+		return value
+	}
+	return c.values.Value(key)
+}
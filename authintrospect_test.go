@@ -0,0 +1,238 @@
+package globus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+)
+
+func TestAuthIntrospectToken(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	srv.AddClient(serviceClientId, serviceClientSecret)
+	ctx := srv.Context(context.Background())
+
+	_, userToken := loginUser(t, ctx, srv, serviceScope)
+	introspection, err := globus.AuthIntrospectToken(ctx, serviceClientId, serviceClientSecret, userToken.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !introspection.Active {
+		t.Fatal("the token is inactive")
+	}
+	if introspection.Sub != srv.User.IdentityId || introspection.Username != srv.User.Username {
+		t.Errorf("got identity %s (%s), want %s (%s)", introspection.Sub, introspection.Username, srv.User.IdentityId, srv.User.Username)
+	}
+	if introspection.ClientId != appClientId || !introspection.HasScopes(serviceScope) {
+		t.Errorf("got client %s with scopes %v, want %s with %s", introspection.ClientId, introspection.Scopes(), appClientId, serviceScope)
+	}
+	if len(introspection.IdentitySet) == 0 {
+		t.Error("the identity set is missing")
+	}
+
+	// other clients only see an inactive token
+	srv.AddClient("other", "other-secret")
+	introspection, err = globus.AuthIntrospectToken(ctx, "other", "other-secret", userToken.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if introspection.Active {
+		t.Error("the token is active for a client it wasn't issued for")
+	}
+
+	if _, err := globus.AuthIntrospectToken(ctx, serviceClientId, "wrong-secret", userToken.AccessToken); err == nil {
+		t.Error("introspected a token with a wrong client secret")
+	}
+}
+
+// a service behind the middleware of a validator, answering with the identity of the token
+func validatedServer(validator *globus.TokenValidator) *httptest.Server {
+	return httptest.NewServer(validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		introspection, ok := globus.IntrospectionFromContext(r.Context())
+		if !ok {
+			http.Error(w, "no introspection in the context", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, introspection.Sub)
+	})))
+}
+
+// sends a request with the given Authorization header, returns the status, body and challenge
+func requestWithAuthorization(t *testing.T, url string, authorization string) (status int, body string, challenge string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, strings.TrimSpace(string(data)), resp.Header.Get("WWW-Authenticate")
+}
+
+func TestTokenValidatorMiddleware(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	srv.AddClient(serviceClientId, serviceClientSecret)
+	ctx := srv.Context(context.Background())
+
+	validator := globus.NewTokenValidator(ctx, serviceClientId, serviceClientSecret, serviceScope)
+	service := validatedServer(validator)
+	defer service.Close()
+
+	_, userToken := loginUser(t, ctx, srv, serviceScope)
+	// a token of the user for another resource server, which the app could pass on to the service
+	_, transferToken := loginUser(t, ctx, srv, transferScope)
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		challenge     string
+	}{
+		{"valid token", "Bearer " + userToken.AccessToken, http.StatusOK, ""},
+		{"lowercase scheme", "bearer " + userToken.AccessToken, http.StatusOK, ""},
+		{"no token", "", http.StatusUnauthorized, "Bearer"},
+		{"basic auth", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, "Bearer"},
+		{"empty token", "Bearer  ", http.StatusUnauthorized, "Bearer"},
+		{"unknown token", "Bearer unknown", http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"other resource server", "Bearer " + transferToken.AccessToken, http.StatusUnauthorized, `Bearer error="invalid_token"`},
+	}
+	for _, test := range tests {
+		status, body, challenge := requestWithAuthorization(t, service.URL, test.authorization)
+		if status != test.status {
+			t.Errorf("%s: got status %d (%s), want %d", test.name, status, body, test.status)
+		}
+		if !strings.HasPrefix(challenge, test.challenge) {
+			t.Errorf("%s: got challenge '%s', want '%s'", test.name, challenge, test.challenge)
+		}
+		if status == http.StatusOK && body != srv.User.IdentityId {
+			t.Errorf("%s: the handler got identity '%s', want %s", test.name, body, srv.User.IdentityId)
+		}
+	}
+}
+
+func TestTokenValidatorRequiredScopes(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	srv.AddClient(serviceClientId, serviceClientSecret)
+	ctx := srv.Context(context.Background())
+
+	// the token has the service's scope, but not the required one
+	const missingScope = "https://auth.globus.org/scopes/" + serviceClientId + "/admin"
+	validator := globus.NewTokenValidator(ctx, serviceClientId, serviceClientSecret, serviceScope, missingScope)
+	service := validatedServer(validator)
+	defer service.Close()
+
+	_, userToken := loginUser(t, ctx, srv, serviceScope)
+	status, _, challenge := requestWithAuthorization(t, service.URL, "Bearer "+userToken.AccessToken)
+	if status != http.StatusForbidden {
+		t.Errorf("got status %d, want 403", status)
+	}
+	if !strings.Contains(challenge, `error="insufficient_scope"`) || !strings.Contains(challenge, missingScope) {
+		t.Errorf("got challenge '%s', want insufficient_scope with the required scopes", challenge)
+	}
+
+	var validationErr *globus.TokenValidationError
+	if _, err := validator.Validate(context.Background(), userToken.AccessToken); !errors.As(err, &validationErr) || validationErr.Status != http.StatusForbidden {
+		t.Errorf("got %v, want a validation error with status 403", err)
+	}
+}
+
+func TestTokenValidatorCache(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	srv.AddClient(serviceClientId, serviceClientSecret)
+	ctx := srv.Context(context.Background())
+
+	validator := globus.NewTokenValidator(ctx, serviceClientId, serviceClientSecret)
+	validator.CacheTTL = 200 * time.Millisecond
+	uncached := globus.NewTokenValidator(ctx, serviceClientId, serviceClientSecret)
+	uncached.CacheTTL = 0
+
+	_, userToken := loginUser(t, ctx, srv, serviceScope)
+	for _, v := range []*globus.TokenValidator{validator, uncached} {
+		if _, err := v.Validate(context.Background(), userToken.AccessToken); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := globus.AuthRevokeToken(ctx, appClientId, appClientSecret, userToken.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// the revoked token is accepted from the cache until the TTL is over
+	if _, err := validator.Validate(context.Background(), userToken.AccessToken); err != nil {
+		t.Errorf("the cached introspection wasn't used: %v", err)
+	}
+	if _, err := uncached.Validate(context.Background(), userToken.AccessToken); err == nil {
+		t.Error("a revoked token was accepted without cache")
+	}
+	time.Sleep(validator.CacheTTL)
+	if _, err := validator.Validate(context.Background(), userToken.AccessToken); err == nil {
+		t.Error("a revoked token was accepted after the cache TTL")
+	}
+}
+
+func TestTokenValidatorAuthError(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	srv.AddClient(serviceClientId, serviceClientSecret)
+	ctx := srv.Context(context.Background())
+
+	// Globus Auth rejects the service's credentials: the service can't tell whether the token is valid
+	validator := globus.NewTokenValidator(ctx, serviceClientId, "wrong-secret")
+	service := validatedServer(validator)
+	defer service.Close()
+
+	_, userToken := loginUser(t, ctx, srv, serviceScope)
+	if status, _, _ := requestWithAuthorization(t, service.URL, "Bearer "+userToken.AccessToken); status != http.StatusBadGateway {
+		t.Errorf("got status %d, want 502", status)
+	}
+}
+
+func TestTokenValidatorAudience(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	ctx := srv.Context(context.Background())
+
+	// Globus Auth lets the client a token was issued to introspect it, although the token is meant
+	// for another resource server (Transfer)
+	_, transferToken := loginUser(t, ctx, srv, transferScope)
+	validator := globus.NewTokenValidator(ctx, appClientId, appClientSecret)
+	_, err := validator.Validate(context.Background(), transferToken.AccessToken)
+	var validationErr *globus.TokenValidationError
+	if !errors.As(err, &validationErr) || validationErr.Status != http.StatusUnauthorized || validationErr.Code != "invalid_token" {
+		t.Fatalf("got %v, want an invalid_token error", err)
+	}
+	t.Log(err)
+
+	validator = globus.NewTokenValidator(ctx, appClientId, appClientSecret)
+	validator.Audience = globus.ResourceServerTransfer
+	if _, err := validator.Validate(context.Background(), transferToken.AccessToken); err != nil {
+		t.Errorf("the token was rejected for its own audience: %v", err)
+	}
+}
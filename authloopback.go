@@ -0,0 +1,165 @@
+package globus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+const loopbackCallbackPath = "/callback"
+
+const loopbackSuccessPage = `<!DOCTYPE html>
+<html><head><title>Login successful</title></head>
+<body><h1>Login successful</h1><p>You can close this window and return to the application.</p></body>
+</html>
+`
+
+const loopbackErrorPage = `<!DOCTYPE html>
+<html><head><title>Login failed</title></head>
+<body><h1>Login failed</h1><p>%s</p></body>
+</html>
+`
+
+// A loopback redirect receives the redirect of the authorization code flow on a local port, so the
+// user doesn't have to copy the code from the browser. The client has to allow the redirect url
+// (e.g. "http://127.0.0.1:8765/callback" for port 8765) in its Globus registration.
+type LoopbackRedirect struct {
+	listener net.Listener
+	server   *http.Server
+	state    string
+
+	once   sync.Once
+	result chan loopbackResult
+}
+
+type loopbackResult struct {
+	code string
+	err  error
+}
+
+// Starts listening for the redirect on 127.0.0.1. Port 0 picks a random free port.
+func AuthStartLoopbackRedirect(port int) (*LoopbackRedirect, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return nil, fmt.Errorf("could not listen for the redirect: %v", err)
+	}
+	state, err := AuthGenerateState()
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	l := &LoopbackRedirect{
+		listener: listener,
+		state:    state,
+		result:   make(chan loopbackResult, 1),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(loopbackCallbackPath, l.handleRedirect)
+	l.server = &http.Server{Handler: mux}
+	go l.server.Serve(listener)
+	return l, nil
+}
+
+// the redirect url to use in the authorization request
+func (l *LoopbackRedirect) RedirectURL() string {
+	return "http://" + l.listener.Addr().String() + loopbackCallbackPath
+}
+
+// the random state the redirect must carry, to be used in the authorization request
+func (l *LoopbackRedirect) State() string {
+	return l.state
+}
+
+// waits until the redirect was received and returns the authorization code
+func (l *LoopbackRedirect) WaitForCode(ctx context.Context) (code string, err error) {
+	select {
+	case result := <-l.result:
+		return result.code, result.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// stops listening
+func (l *LoopbackRedirect) Close() error {
+	return l.server.Close()
+}
+
+func (l *LoopbackRedirect) handleRedirect(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var result loopbackResult
+	switch {
+	case query.Get("state") != l.state:
+		// not the answer to our request, keep waiting for the right one
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, loopbackErrorPage, "The state of the redirect doesn't match the login request.")
+		return
+	case query.Get("error") != "":
+		result.err = fmt.Errorf("authorization failed: %s %s", query.Get("error"), query.Get("error_description"))
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, loopbackErrorPage, html.EscapeString(query.Get("error")+": "+query.Get("error_description")))
+	case query.Get("code") == "":
+		result.err = errors.New("the redirect contains no authorization code")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, loopbackErrorPage, "The redirect contains no authorization code.")
+	default:
+		result.code = query.Get("code")
+		fmt.Fprint(w, loopbackSuccessPage)
+	}
+
+	l.once.Do(func() {
+		l.result <- result
+	})
+}
+
+// generates a random state for an authorization request
+func AuthGenerateState() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Runs the authorization code flow with PKCE and offline access through a loopback redirect: the
+// authorization url is passed to showURL (e.g. to print it or open a browser), the redirect is
+// received on the given port (0: random) and the code is exchanged for a token. The redirect url
+// of conf is replaced by the loopback one. If the token response contains an id token (with the
+// "openid" scope), it's verified.
+func AuthLoopbackLogin(ctx context.Context, conf oauth2.Config, port int, showURL func(url string), opts ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
+	redirect, err := AuthStartLoopbackRedirect(port)
+	if err != nil {
+		return nil, err
+	}
+	defer redirect.Close()
+
+	conf.RedirectURL = redirect.RedirectURL()
+	verifier := oauth2.GenerateVerifier()
+	opts = append([]oauth2.AuthCodeOption{oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier)}, opts...)
+	showURL(conf.AuthCodeURL(redirect.State(), opts...))
+
+	code, err := redirect.WaitForCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := conf.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := tok.Extra("id_token").(string); ok {
+		if _, err := AuthVerifyIDToken(ctx, conf.ClientID, tok); err != nil {
+			return nil, err
+		}
+	}
+	return tok, nil
+}
@@ -0,0 +1,62 @@
+package globus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Revokes an access or refresh token of a client, e.g. a long-lived refresh token printed by the
+// getRefreshToken command of the CLI. Revoking a refresh token also revokes the access tokens
+// obtained with it. Like Globus Auth, unknown and already revoked tokens aren't an error.
+func AuthRevokeToken(ctx context.Context, clientID string, clientSecret string, token string) error {
+	if _, err := authPostForm(ctx, clientID, clientSecret, "/oauth2/token/revoke", url.Values{"token": {token}}); err != nil {
+		return fmt.Errorf("error revoking token: %v", err)
+	}
+	return nil
+}
+
+// Logs a client out: revokes the stored access and refresh tokens of the client, for all resource
+// servers and identities, and deletes them from the store. Tokens that couldn't be revoked are
+// kept, so the logout can be retried; their errors are returned joined. Returns the number of
+// removed tokens.
+func AuthLogout(ctx context.Context, clientID string, clientSecret string, store TokenStore) (removed int, err error) {
+	tokens, err := store.List()
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	for _, stored := range tokens {
+		if stored.Key.ClientId != clientID {
+			continue
+		}
+		if err := revokeStoredToken(ctx, clientID, clientSecret, stored); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", stored.Key, err))
+			continue
+		}
+		if err := store.Delete(stored.Key); err != nil && !errors.Is(err, ErrTokenNotFound) {
+			errs = append(errs, err)
+			continue
+		}
+		removed++
+	}
+	return removed, errors.Join(errs...)
+}
+
+// revokes the refresh token first, which invalidates the access tokens issued with it as well
+func revokeStoredToken(ctx context.Context, clientID string, clientSecret string, stored StoredToken) error {
+	if stored.Token == nil {
+		return nil
+	}
+	if stored.Token.RefreshToken != "" {
+		if err := AuthRevokeToken(ctx, clientID, clientSecret, stored.Token.RefreshToken); err != nil {
+			return err
+		}
+	}
+	if stored.Token.AccessToken != "" {
+		return AuthRevokeToken(ctx, clientID, clientSecret, stored.Token.AccessToken)
+	}
+	return nil
+}
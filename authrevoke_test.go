@@ -0,0 +1,80 @@
+package globus_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+)
+
+func TestAuthRevokeToken(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	ctx := srv.Context(context.Background())
+
+	_, tok := loginUser(t, ctx, srv, transferScope)
+	if err := globus.AuthRevokeToken(ctx, appClientId, appClientSecret, tok.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := srv.Token(tok.AccessToken); ok {
+		t.Error("the access token is still valid")
+	}
+
+	// unknown and already revoked tokens aren't an error
+	if err := globus.AuthRevokeToken(ctx, appClientId, appClientSecret, tok.AccessToken); err != nil {
+		t.Errorf("revoking a revoked token failed: %v", err)
+	}
+}
+
+func TestAuthRevokeRefreshToken(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	ctx := srv.Context(context.Background())
+
+	conf, tok := loginUser(t, ctx, srv, transferScope)
+	if err := globus.AuthRevokeToken(ctx, appClientId, appClientSecret, tok.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	// the access tokens issued with the refresh token are revoked along with it
+	if _, ok := srv.Token(tok.AccessToken); ok {
+		t.Error("the access token of the refresh token is still valid")
+	}
+
+	expired := *tok
+	expired.Expiry = time.Now().Add(-time.Hour)
+	if _, err := conf.TokenSource(ctx, &expired).Token(); err == nil {
+		t.Error("the revoked refresh token can still be used")
+	}
+}
+
+func TestAuthLogout(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	ctx := srv.Context(context.Background())
+
+	_, tok := loginUser(t, ctx, srv, transferScope)
+	store := globus.NewMemoryTokenStore()
+	key := globus.TokenKey{ClientId: appClientId, ResourceServer: globus.ResourceServerTransfer}
+	if err := store.Put(globus.StoredToken{Key: key, Token: tok}); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := globus.AuthLogout(ctx, appClientId, appClientSecret, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("removed %d tokens, want 1", removed)
+	}
+	if _, ok := srv.Token(tok.AccessToken); ok {
+		t.Error("the access token is still valid after the logout")
+	}
+	if tokens, _ := store.List(); len(tokens) != 0 {
+		t.Errorf("%d tokens are left in the store", len(tokens))
+	}
+}
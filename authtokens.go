@@ -0,0 +1,152 @@
+package globus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// resource servers of the Globus services. The resource server of a collection's HTTPS
+// server is the collection id.
+const (
+	ResourceServerTransfer = "transfer.api.globus.org"
+	ResourceServerAuth     = "auth.globus.org"
+	ResourceServerGroups   = "groups.api.globus.org"
+)
+
+// Splits a Globus token response into its tokens, one per resource server. Globus returns the
+// token of one resource server at the top level and the others in "other_tokens", the oauth2
+// package only knows about the former. The returned tokens carry the "resource_server" and
+// "scope" of their response in their extra fields.
+func AuthParseTokenResponse(tok *oauth2.Token) (tokens map[string]*oauth2.Token, err error) {
+	tokens = map[string]*oauth2.Token{}
+	resourceServer, _ := tok.Extra("resource_server").(string)
+	tokens[resourceServer] = tok
+
+	others, ok := tok.Extra("other_tokens").([]any)
+	if !ok {
+		return tokens, nil
+	}
+	for i, other := range others {
+		fields, ok := other.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("other token %d is not an object", i)
+		}
+		token, err := parseOtherToken(fields)
+		if err != nil {
+			return nil, fmt.Errorf("other token %d: %v", i, err)
+		}
+		tokens[fields["resource_server"].(string)] = token
+	}
+	return tokens, nil
+}
+
+func parseOtherToken(fields map[string]any) (*oauth2.Token, error) {
+	accessToken, _ := fields["access_token"].(string)
+	resourceServer, _ := fields["resource_server"].(string)
+	if accessToken == "" || resourceServer == "" {
+		return nil, fmt.Errorf("access_token and resource_server are required")
+	}
+
+	token := &oauth2.Token{AccessToken: accessToken}
+	token.TokenType, _ = fields["token_type"].(string)
+	token.RefreshToken, _ = fields["refresh_token"].(string) // null without offline access
+	if expiresIn, ok := fields["expires_in"].(float64); ok && expiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	return token.WithExtra(fields), nil
+}
+
+// The tokens of a single Globus login, one per resource server, each refreshed independently
+// through its own refresh token. Use it to talk to several Globus services (e.g. Transfer and
+// Groups) or collection HTTPS servers with one login.
+type TokenSet struct {
+	ctx  context.Context
+	conf oauth2.Config
+
+	mu      sync.Mutex
+	sources map[string]oauth2.TokenSource
+	scopes  map[string][]string
+}
+
+// Creates a token set from a token response, e.g. from oauth2.Config.Exchange. The conf must
+// belong to the client the tokens were issued to, it's used to refresh them.
+func AuthNewTokenSet(ctx context.Context, conf oauth2.Config, tok *oauth2.Token) (*TokenSet, error) {
+	tokens, err := AuthParseTokenResponse(tok)
+	if err != nil {
+		return nil, err
+	}
+	return newTokenSet(ctx, conf, tokens), nil
+}
+
+func newTokenSet(ctx context.Context, conf oauth2.Config, tokens map[string]*oauth2.Token) *TokenSet {
+	set := &TokenSet{ctx: ctx, conf: conf, sources: map[string]oauth2.TokenSource{}, scopes: map[string][]string{}}
+	for resourceServer, token := range tokens {
+		set.sources[resourceServer] = conf.TokenSource(ctx, token)
+		if scope, ok := token.Extra("scope").(string); ok {
+			set.scopes[resourceServer] = strings.Fields(scope)
+		}
+	}
+	return set
+}
+
+// the resource servers the set has tokens for, sorted
+func (s *TokenSet) ResourceServers() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	servers := make([]string, 0, len(s.sources))
+	for server := range s.sources {
+		servers = append(servers, server)
+	}
+	sort.Strings(servers)
+	return servers
+}
+
+// the scopes granted for a resource server
+func (s *TokenSet) Scopes(resourceServer string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scopes[resourceServer]
+}
+
+// returns the auto-refreshing token source of a resource server
+func (s *TokenSet) TokenSource(resourceServer string) (oauth2.TokenSource, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	source, ok := s.sources[resourceServer]
+	if !ok {
+		return nil, fmt.Errorf("no token for resource server '%s', request one of its scopes when logging in", resourceServer)
+	}
+	return source, nil
+}
+
+// replaces the token source of a resource server, e.g. to wrap it with AuthPersistingTokenSource
+func (s *TokenSet) SetTokenSource(resourceServer string, source oauth2.TokenSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sources[resourceServer] = source
+}
+
+// returns an http client authenticated with the token of a resource server
+func (s *TokenSet) HTTPClient(resourceServer string) (*http.Client, error) {
+	source, err := s.TokenSource(resourceServer)
+	if err != nil {
+		return nil, err
+	}
+	return oauth2.NewClient(s.ctx, source), nil
+}
+
+// returns a client for the Transfer API, authenticated with the transfer token
+func (s *TokenSet) GlobusClient() (GlobusClient, error) {
+	client, err := s.HTTPClient(ResourceServerTransfer)
+	if err != nil {
+		return GlobusClient{}, err
+	}
+	return GlobusClient{client: client}, nil
+}
@@ -0,0 +1,116 @@
+package globus
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/adler32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/SwissOpenEM/globus/remotepath"
+)
+
+// checksum algorithms supported by Globus for external checksums
+const (
+	ChecksumMD5     = "MD5"
+	ChecksumSHA1    = "SHA1"
+	ChecksumSHA256  = "SHA256"
+	ChecksumSHA512  = "SHA512"
+	ChecksumADLER32 = "ADLER32"
+)
+
+func newChecksumHash(algorithm string) (hash.Hash, error) {
+	switch strings.ToUpper(algorithm) {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA1:
+		return sha1.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	case ChecksumADLER32:
+		return adler32.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm '%s'", algorithm)
+	}
+}
+
+// computes the hex encoded checksum of a local file
+func FileChecksum(filePath string, algorithm string) (string, error) {
+	h, err := newChecksumHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Computes the checksums of the local copies of the source files of a transfer and attaches them
+// to the items as external checksums. sourcePath is the base path of the items on the source
+// endpoint and localSourcePath is the directory where the same files can be read locally.
+// Directories (recursive items) and symlinks are left without checksum, items that already have
+// an external checksum are kept as they are. The files are hashed by the given number of parallel
+// workers.
+func TransferAttachChecksums(items []TransferItem, sourcePath string, localSourcePath string, algorithm string, workers int) error {
+	algorithm = strings.ToUpper(algorithm)
+	if _, err := newChecksumHash(algorithm); err != nil {
+		return err
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	indices := make(chan int)
+	errs := make([]error, len(items))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				rel, err := remotepath.Rel(sourcePath, items[i].SourcePath)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				checksum, err := FileChecksum(filepath.Join(localSourcePath, filepath.FromSlash(rel)), algorithm)
+				if err != nil {
+					errs[i] = fmt.Errorf("checksum of '%s': %v", items[i].SourcePath, err)
+					continue
+				}
+				items[i].ExternalChecksum = stringPointer(checksum)
+				items[i].ChecksumAlgorithm = stringPointer(algorithm)
+			}
+		}()
+	}
+
+	for i, item := range items {
+		if item.DataType != "transfer_item" || (item.Recursive != nil && *item.Recursive) || remotepath.IsDir(item.SourcePath) || item.ExternalChecksum != nil {
+			continue
+		}
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,137 @@
+package globus_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SwissOpenEM/globus"
+)
+
+// writes files with the given content below dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFileChecksum(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"abc.txt": "abc"})
+	path := filepath.Join(dir, "abc.txt")
+
+	tests := map[string]string{
+		"MD5":     "900150983cd24fb0d6963f7d28e17f72",
+		"sha1":    "a9993e364706816aba3e25717850c26c9cd0d89d",
+		"SHA256":  "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		"SHA512":  "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+		"ADLER32": "024d0127",
+	}
+	for algorithm, want := range tests {
+		got, err := globus.FileChecksum(path, algorithm)
+		if err != nil {
+			t.Errorf("%s: %v", algorithm, err)
+		} else if got != want {
+			t.Errorf("%s: got %s, want %s", algorithm, got, want)
+		}
+	}
+
+	if _, err := globus.FileChecksum(path, "CRC32"); err == nil {
+		t.Error("got a checksum for an unsupported algorithm")
+	}
+	if _, err := globus.FileChecksum(filepath.Join(dir, "missing"), "MD5"); err == nil {
+		t.Error("got a checksum of a missing file")
+	}
+}
+
+func TestTransferAttachChecksums(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "abc", "sub/b.txt": "abc", "sub/c.txt": "abc"})
+
+	recursive := true
+	existing := "given"
+	algorithm := "SHA1"
+	items := []globus.TransferItem{
+		{DataType: "transfer_item", SourcePath: "/data/a.txt", DestinationPath: "/archive/a.txt"},
+		{DataType: "transfer_item", SourcePath: "/data/sub/b.txt", DestinationPath: "/archive/sub/b.txt"},
+		{DataType: "transfer_item", SourcePath: "/data/sub/c.txt", DestinationPath: "/archive/sub/c.txt", ExternalChecksum: &existing, ChecksumAlgorithm: &algorithm},
+		{DataType: "transfer_item", SourcePath: "/data/sub/", DestinationPath: "/archive/sub/"},
+		{DataType: "transfer_item", SourcePath: "/data/sub", DestinationPath: "/archive/sub", Recursive: &recursive},
+		{DataType: "transfer_symlink_item", SourcePath: "/data/link", DestinationPath: "/archive/link"},
+	}
+	if err := globus.TransferAttachChecksums(items, "/data", dir, "md5", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	const md5 = "900150983cd24fb0d6963f7d28e17f72"
+	for _, item := range items[:2] {
+		if item.ExternalChecksum == nil || *item.ExternalChecksum != md5 || item.ChecksumAlgorithm == nil || *item.ChecksumAlgorithm != "MD5" {
+			t.Errorf("'%s' has no MD5 checksum", item.SourcePath)
+		}
+	}
+	if *items[2].ExternalChecksum != existing || *items[2].ChecksumAlgorithm != algorithm {
+		t.Error("the given checksum was replaced")
+	}
+	for _, item := range items[3:] {
+		if item.ExternalChecksum != nil {
+			t.Errorf("'%s' got a checksum", item.SourcePath)
+		}
+	}
+}
+
+func TestTransferAttachChecksumsBasePaths(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"data/a.txt": "abc"})
+
+	// the base path can be of any kind, as long as the items are of the same kind
+	for base, sourcePath := range map[string]string{
+		"/":   "/data/a.txt",
+		".":   "data/a.txt",
+		"~/":  "~/data/a.txt",
+		"~/.": "~/data/../data/a.txt",
+	} {
+		items := []globus.TransferItem{{DataType: "transfer_item", SourcePath: sourcePath, DestinationPath: "/archive/a.txt"}}
+		if err := globus.TransferAttachChecksums(items, base, dir, globus.ChecksumMD5, 1); err != nil {
+			t.Errorf("base '%s': %v", base, err)
+		} else if items[0].ExternalChecksum == nil {
+			t.Errorf("base '%s': '%s' has no checksum", base, sourcePath)
+		}
+	}
+}
+
+func TestTransferAttachChecksumsErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "abc"})
+
+	items := []globus.TransferItem{
+		{DataType: "transfer_item", SourcePath: "/data/a.txt", DestinationPath: "/archive/a.txt"},
+		{DataType: "transfer_item", SourcePath: "/data/missing.txt", DestinationPath: "/archive/missing.txt"},
+		{DataType: "transfer_item", SourcePath: "/other/b.txt", DestinationPath: "/archive/b.txt"},
+		{DataType: "transfer_item", SourcePath: "relative.txt", DestinationPath: "/archive/relative.txt"},
+	}
+	err := globus.TransferAttachChecksums(items, "/data", dir, globus.ChecksumSHA256, 4)
+	if err == nil {
+		t.Fatal("the checksums of missing files and files outside of the base path were attached")
+	}
+	for _, path := range []string{"/data/missing.txt", "/other/b.txt", "relative.txt"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("the error doesn't mention '%s': %v", path, err)
+		}
+	}
+	// the other items still get their checksum
+	if items[0].ExternalChecksum == nil {
+		t.Error("no checksum was attached to the existing file")
+	}
+
+	if err := globus.TransferAttachChecksums(items[:1], "/data", dir, "CRC32", 1); err == nil {
+		t.Error("checksums were attached with an unsupported algorithm")
+	}
+}
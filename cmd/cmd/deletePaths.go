@@ -0,0 +1,60 @@
+/*
+Copyright © 2024 The Swiss OpenEM Team
+*/
+package cmd
+
+import (
+	"log"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/spf13/cobra"
+)
+
+// deletePathsCmd represents the deletePaths command
+var deletePathsCmd = &cobra.Command{
+	Use:   "deletePaths [flags] path...",
+	Short: "Deletes files or folders on a Globus endpoint",
+	Long: `
+This command submits a delete task removing the given
+paths from a Globus endpoint. Folders are only deleted
+if the recursive flag is set.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		authCodeGrant, _ := cmd.Flags().GetBool("auth-code-grant")
+		clientID, _ := cmd.Flags().GetString("client-id")
+		clientSecret, _ := cmd.Flags().GetString("client-secret")
+		redirectURL, _ := cmd.Flags().GetString("redirect-url")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		endpoint, _ := cmd.Flags().GetString("endpoint")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+
+		scopes := globus.TransferDataAccessScopeCreator([]string{endpoint})
+
+		client, err := loginForSubmission(dryRun, authCodeGrant, clientID, clientSecret, redirectURL, scopes)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		err = submitWithConsent(client, authCodeGrant, clientID, clientSecret, redirectURL, func(client globus.GlobusClient) error {
+			result, err := client.TransferDeletePaths(endpoint, args, recursive)
+			if err != nil {
+				return err
+			}
+			printSubmissionResult(client, "Result of request", result)
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deletePathsCmd)
+
+	deletePathsCmd.Flags().String("endpoint", "", "set endpoint to delete from")
+	deletePathsCmd.Flags().Bool("recursive", false, "delete folders and their contents")
+
+	deletePathsCmd.MarkFlagRequired("endpoint")
+}
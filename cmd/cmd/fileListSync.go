@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/manifest"
+	"github.com/SwissOpenEM/globus/remotepath"
 	"github.com/spf13/cobra"
 )
 
@@ -19,13 +22,25 @@ This command will copy all files that are in a source
 endpoint at a specified path listed in a text file relative
 to a destination endpoint at its corresponding path. 
 Files that already exist and have the same checksum will 
-not be copied. This command does *not* support symlinks.`,
+not be copied. Entries ending with a slash are copied as
+directories, recursively. Entries can't point outside of
+the source path. This command does *not* support symlinks.
+
+Alternatively, the files can be given in the batch format of
+the official globus-cli with the batch flag: each line holds
+a source and a destination path, optionally followed by
+--recursive, --external-checksum SUM and --checksum-algorithm
+ALGO. Paths with spaces can be quoted, paths starting with '-'
+must follow '--', and lines starting with '#' are ignored. In this mode, src-path and dest-path are
+optional prefixes for relative paths. Use '-' to read the
+batch from stdin.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// getting auth. params
 		authCodeGrant, _ := cmd.Flags().GetBool("auth-code-grant")
 		clientID, _ := cmd.Flags().GetString("client-id")
 		clientSecret, _ := cmd.Flags().GetString("client-secret")
 		redirectURL, _ := cmd.Flags().GetString("redirect-url")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
 
 		// getting transfer params
 		srcEndpoint, _ := cmd.Flags().GetString("src-endpoint")
@@ -33,42 +48,90 @@ not be copied. This command does *not* support symlinks.`,
 		destEndpoint, _ := cmd.Flags().GetString("dest-endpoint")
 		destPath, _ := cmd.Flags().GetString("dest-path")
 		fileListPath, _ := cmd.Flags().GetString("file-list")
+		batchPath, _ := cmd.Flags().GetString("batch")
+		maxTaskItems, _ := cmd.Flags().GetInt("max-task-items")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		checksumAlgorithm, _ := cmd.Flags().GetString("external-checksum")
+		localSrcPath, _ := cmd.Flags().GetString("local-src-path")
+		checksumWorkers, _ := cmd.Flags().GetInt("checksum-workers")
 
-		// reading filelist
-		file, err := os.Open(fileListPath)
-		if err != nil {
-			log.Fatalf("Error occured when opening filelist: %v\n", err)
+		if (fileListPath == "") == (batchPath == "") {
+			log.Fatal("exactly one of file-list and batch must be set")
 		}
-		defer file.Close()
-
-		var files []string
-		scanner := bufio.NewScanner(file)
 
-		for scanner.Scan() {
-			files = append(files, scanner.Text())
+		var transfer globus.Transfer
+		var err error
+		if fileListPath != "" {
+			transfer, err = readFileList(fileListPath, srcEndpoint, srcPath, destEndpoint, destPath)
+		} else {
+			transfer, err = readBatch(batchPath, srcEndpoint, srcPath, destEndpoint, destPath)
 		}
-		if err := scanner.Err(); err != nil {
-			log.Fatalf("Error occured when reading filelist: %v\n", err)
+		if err != nil {
+			log.Fatal(err)
 		}
 
-		// note: Globus has some non-standard extensions to Oauth2, meaning that it can give out
-		// multiple tokens for different endpoints with the first one being the "default".
-		// To get a client that works with transfers while using a standard oauth2 library, we
-		// need to exclusively specify the scopes that are associated with the transfer api.
+		// the transfer scope with the data access of both collections
 		scopes := globus.TransferDataAccessScopeCreator([]string{srcEndpoint, destEndpoint})
 
 		// Authenticate
-		client, err := login(authCodeGrant, clientID, clientSecret, redirectURL, scopes)
+		client, err := loginForSubmission(dryRun, authCodeGrant, clientID, clientSecret, redirectURL, scopes)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		// Transfer - Sync folders                                                                                                        )
-		result, err := client.TransferFileList(srcEndpoint, srcPath, destEndpoint, destPath, files, []bool{}, true)
+		// attach checksums computed locally
+		if checksumAlgorithm != "" {
+			checksumBasePath := srcPath
+			if checksumBasePath == "" {
+				// batch paths without prefix are relative to their own root
+				checksumBasePath, err = batchSourceRoot(transfer.Data)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			if localSrcPath == "" {
+				if srcPath == "" && checksumBasePath != "/" {
+					log.Fatalf("the source paths of the batch are relative to '%s' on the collection, set --local-src-path to the local directory they're in", checksumBasePath)
+				}
+				localSrcPath = checksumBasePath
+			}
+			err = globus.TransferAttachChecksums(transfer.Data, checksumBasePath, localSrcPath, checksumAlgorithm, checksumWorkers)
+			if err != nil {
+				log.Fatal(err)
+			}
+			verifyChecksum := true
+			transfer.VerifyChecksum = &verifyChecksum
+		}
+
+		err = submitWithConsent(client, authCodeGrant, clientID, clientSecret, redirectURL, func(client globus.GlobusClient) error {
+			// Transfer - split into several tasks if requested
+			if maxTaskItems > 0 {
+				group, err := client.TransferPostTaskBatched(transfer, globus.BatchOptions{
+					MaxItems:    maxTaskItems,
+					Parallelism: parallel,
+				})
+				fmt.Printf("Task group: %s\n", group.GroupId)
+				for i, result := range group.Results {
+					printSubmissionResult(client, fmt.Sprintf("Result of part %d", i+1), result)
+				}
+				if err != nil && len(group.TaskIds()) > 0 {
+					// don't resubmit the parts that already succeeded
+					return fmt.Errorf("some parts were not submitted: %v", err)
+				}
+				return err
+			}
+
+			// Transfer - Sync files
+			result, err := client.TransferPostTask(transfer)
+			if err != nil {
+				return err
+			}
+			printSubmissionResult(client, "Result of request", result)
+			return nil
+		})
 		if err != nil {
 			log.Fatal(err)
 		}
-		fmt.Printf("Result of request: \n%+v\n", result)
 	},
 }
 
@@ -77,15 +140,108 @@ func init() {
 
 	// transfer params
 	fileListSyncCmd.Flags().String("src-endpoint", "", "set source endpoint")
-	fileListSyncCmd.Flags().String("src-path", "", "path on source endpoint to sync")
+	fileListSyncCmd.Flags().String("src-path", "", "path on source endpoint to sync (optional prefix for batch input)")
 	fileListSyncCmd.Flags().String("dest-endpoint", "", "set destination endpoint")
-	fileListSyncCmd.Flags().String("dest-path", "", "path on destination endpoint to sync to")
+	fileListSyncCmd.Flags().String("dest-path", "", "path on destination endpoint to sync to (optional prefix for batch input)")
 	fileListSyncCmd.Flags().String("file-list", "", "list of files to sync (relative to src-path)")
+	fileListSyncCmd.Flags().String("batch", "", "globus-cli style batch file with one 'SRC DST [--recursive] [--external-checksum X]' per line ('-' for stdin)")
+	fileListSyncCmd.Flags().Int("max-task-items", 0, "split the list into several tasks with at most this many files each (0: single task)")
+	fileListSyncCmd.Flags().Int("parallel", 1, "number of tasks submitted in parallel when splitting the list")
+	fileListSyncCmd.Flags().String("external-checksum", "", "compute checksums locally with this algorithm (MD5, SHA1, SHA256, SHA512, ADLER32) and let Globus verify them")
+	fileListSyncCmd.Flags().String("local-src-path", "", "local directory containing the source files for checksum computation (default: src-path, or / for absolute batch paths)")
+	fileListSyncCmd.Flags().Int("checksum-workers", 4, "number of files checksummed in parallel")
 
 	// mark flags as obligatory
 	fileListSyncCmd.MarkFlagRequired("src-endpoint")
-	fileListSyncCmd.MarkFlagRequired("src-path")
 	fileListSyncCmd.MarkFlagRequired("dest-endpoint")
-	fileListSyncCmd.MarkFlagRequired("dest-path")
-	fileListSyncCmd.MarkFlagRequired("file-list")
+	fileListSyncCmd.MarkFlagsMutuallyExclusive("file-list", "batch")
+}
+
+// reads a newline-separated list of files relative to srcPath
+func readFileList(fileListPath string, srcEndpoint string, srcPath string, destEndpoint string, destPath string) (globus.Transfer, error) {
+	if srcPath == "" || destPath == "" {
+		return globus.Transfer{}, fmt.Errorf("src-path and dest-path are required with a file list")
+	}
+
+	file, err := os.Open(fileListPath)
+	if err != nil {
+		return globus.Transfer{}, fmt.Errorf("error occured when opening filelist: %v", err)
+	}
+	defer file.Close()
+
+	var files []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// an empty entry would be the source path itself
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		files = append(files, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return globus.Transfer{}, fmt.Errorf("error occured when reading filelist: %v", err)
+	}
+
+	storeBasePath := true
+	transfer := globus.Transfer{
+		CommonTransfer: globus.CommonTransfer{
+			DataType:          "transfer",
+			StoreBasePathInfo: &storeBasePath,
+		},
+		SourceEndpoint:      srcEndpoint,
+		DestinationEndpoint: destEndpoint,
+	}
+	transfer.Data, err = globus.TransferFileListItems(srcPath, destPath, files, []bool{})
+	return transfer, err
+}
+
+// reads globus-cli style batch input, with srcPath and destPath as optional prefixes
+func readBatch(batchPath string, srcEndpoint string, srcPath string, destEndpoint string, destPath string) (globus.Transfer, error) {
+	input := os.Stdin
+	if batchPath != "-" {
+		file, err := os.Open(batchPath)
+		if err != nil {
+			return globus.Transfer{}, fmt.Errorf("error occured when opening batch file: %v", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	items, err := manifest.ParseBatch(input)
+	if err != nil {
+		return globus.Transfer{}, err
+	}
+
+	storeBasePath := true
+	m := manifest.Manifest{
+		SourceEndpoint:      srcEndpoint,
+		DestinationEndpoint: destEndpoint,
+		SourceBasePath:      srcPath,
+		DestinationBasePath: destPath,
+		Options:             manifest.Options{StoreBasePathInfo: &storeBasePath},
+		Items:               items,
+	}
+	return m.Transfer()
+}
+
+// returns the root the source paths of a batch have in common: "/" for absolute paths, "~/" for
+// paths relative to the home directory and "." for relative ones
+func batchSourceRoot(items []globus.TransferItem) (string, error) {
+	root := ""
+	for _, item := range items {
+		itemRoot := "."
+		if remotepath.IsHomeRelative(item.SourcePath) {
+			itemRoot = "~/"
+		} else if remotepath.IsRooted(item.SourcePath) {
+			itemRoot = "/"
+		}
+		if root != "" && itemRoot != root {
+			return "", fmt.Errorf("the batch mixes source paths relative to '%s' and '%s', set --src-path to compute checksums", root, itemRoot)
+		}
+		root = itemRoot
+	}
+	if root == "" {
+		root = "/"
+	}
+	return root, nil
 }
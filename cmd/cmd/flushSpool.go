@@ -0,0 +1,103 @@
+/*
+Copyright © 2024 The Swiss OpenEM Team
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/spf13/cobra"
+)
+
+// flushSpoolCmd represents the flushSpool command
+var flushSpoolCmd = &cobra.Command{
+	Use:   "flushSpool [flags]",
+	Short: "Submits the transfers kept in a spool directory",
+	Long: `
+This command retries the submission of all transfers that were
+spooled because the Transfer API was unreachable (see the spool
+flag of the submit command). Without the wait flag, every spooled
+transfer is retried once. With it, the command keeps retrying with
+backoff until the spool is empty. Transfers rejected by Globus
+are moved aside with a ".rejected" extension.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		authCodeGrant, _ := cmd.Flags().GetBool("auth-code-grant")
+		clientID, _ := cmd.Flags().GetString("client-id")
+		clientSecret, _ := cmd.Flags().GetString("client-secret")
+		redirectURL, _ := cmd.Flags().GetString("redirect-url")
+
+		spoolDir, _ := cmd.Flags().GetString("spool")
+		wait, _ := cmd.Flags().GetBool("wait")
+
+		opts := globus.SpoolOptions{
+			OnSubmitted: func(entry globus.SpoolEntry, result globus.TransferResult) {
+				fmt.Printf("Spooled transfer %s was submitted as task %s\n", entry.Id, result.TaskId)
+			},
+			OnRejected: func(entry globus.SpoolEntry, err error) {
+				fmt.Printf("Spooled transfer %s was rejected: %v\n", entry.Id, err)
+			},
+		}
+
+		// find the endpoints of the spooled transfers for the scopes
+		spool, err := globus.NewTransferSpool(globus.GlobusClient{}, spoolDir, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		entries, err := spool.Entries()
+		if err != nil {
+			log.Println(err)
+		}
+		if len(entries) == 0 {
+			fmt.Println("The spool is empty")
+			return
+		}
+		var endpoints []string
+		for _, entry := range entries {
+			endpoints = append(endpoints, entry.Transfer.SourceEndpoint, entry.Transfer.DestinationEndpoint)
+		}
+		scopes := globus.TransferDataAccessScopeCreator(endpoints)
+
+		client, err := login(authCodeGrant, clientID, clientSecret, redirectURL, scopes)
+		if err != nil {
+			log.Fatal(err)
+		}
+		spool, err = globus.NewTransferSpool(client, spoolDir, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		depth, err := spool.Flush(true)
+		if err != nil {
+			log.Println(err)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		for wait && depth > 0 && ctx.Err() == nil {
+			fmt.Printf("%d transfers left in the spool, retrying...\n", depth)
+			select {
+			case <-ctx.Done():
+			case <-time.After(10 * time.Second):
+			}
+			depth, err = spool.Flush(false)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+		fmt.Printf("%d transfers left in the spool\n", depth)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(flushSpoolCmd)
+
+	flushSpoolCmd.Flags().String("spool", "", "spool directory")
+	flushSpoolCmd.Flags().Bool("wait", false, "keep retrying until the spool is empty")
+
+	flushSpoolCmd.MarkFlagRequired("spool")
+}
@@ -4,7 +4,6 @@ Copyright © 2024 The Swiss OpenEM Team
 package cmd
 
 import (
-	"fmt"
 	"log"
 
 	"github.com/SwissOpenEM/globus"
@@ -26,6 +25,7 @@ have the same checksum will not be copied.`,
 		clientID, _ := cmd.Flags().GetString("client-id")
 		clientSecret, _ := cmd.Flags().GetString("client-secret")
 		redirectURL, _ := cmd.Flags().GetString("redirect-url")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
 
 		// getting transfer params
 		srcEndpoint, _ := cmd.Flags().GetString("src-endpoint")
@@ -33,24 +33,27 @@ have the same checksum will not be copied.`,
 		destEndpoint, _ := cmd.Flags().GetString("dest-endpoint")
 		destPath, _ := cmd.Flags().GetString("dest-path")
 
-		// note: Globus has some non-standard extensions to Oauth2, meaning that it can give out
-		// multiple tokens for different endpoints with the first one being the "default".
-		// To get a client that works with transfers while using a standard oauth2 library, we
-		// need to exclusively specify the scopes that are associated with the transfer api.
+		// the transfer scope with the data access of both collections
 		scopes := globus.TransferDataAccessScopeCreator([]string{srcEndpoint, destEndpoint})
 
 		// Authenticate
-		client, err := login(authCodeGrant, clientID, clientSecret, redirectURL, scopes)
+		client, err := loginForSubmission(dryRun, authCodeGrant, clientID, clientSecret, redirectURL, scopes)
 		if err != nil {
 			log.Fatal(err)
 		}
 
 		// Transfer - Sync folders
-		result, err := client.TransferFolderSync(srcEndpoint, srcPath, destEndpoint, destPath, true)
+		err = submitWithConsent(client, authCodeGrant, clientID, clientSecret, redirectURL, func(client globus.GlobusClient) error {
+			result, err := client.TransferFolderSync(srcEndpoint, srcPath, destEndpoint, destPath, true)
+			if err != nil {
+				return err
+			}
+			printSubmissionResult(client, "Result of request", result)
+			return nil
+		})
 		if err != nil {
 			log.Fatal(err)
 		}
-		fmt.Printf("Result of request: \n%+v\n", result)
 	},
 }
 
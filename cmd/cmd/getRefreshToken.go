@@ -17,7 +17,8 @@ var getRefreshToken = &cobra.Command{
 This command will execute a 3-legged OAuth authentication, with the OfflineAccess flag enabled,
 which will give the application a Refresh Token. This refresh token is then returned to the command line.
 Be careful with the refresh token, it provides access to the API using the identity of the user and is 
-valid by default for a long period of time.`,
+valid by default for a long period of time. The token can be passed to the other commands with the
+--refresh-token flag or the GLOBUS_REFRESH_TOKEN environment variable.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// getting auth. params
 		clientID, _ := cmd.Flags().GetString("client-id")
@@ -28,10 +29,7 @@ valid by default for a long period of time.`,
 		srcEndpoint, _ := cmd.Flags().GetString("src-endpoint")
 		destEndpoint, _ := cmd.Flags().GetString("dest-endpoint")
 
-		// note: Globus has some non-standard extensions to Oauth2, meaning that it can give out
-		// multiple tokens for different endpoints with the first one being the "default".
-		// To get a client that works with transfers while using a standard oauth2 library, we
-		// need to exclusively specify the scopes that are associated with the transfer api.
+		// the transfer scope with the data access of the given collections
 		endpoints := []string{}
 		if srcEndpoint != "" {
 			endpoints = append(endpoints, srcEndpoint)
@@ -40,6 +38,9 @@ valid by default for a long period of time.`,
 			endpoints = append(endpoints, destEndpoint)
 		}
 		scopes := globus.TransferDataAccessScopeCreator(endpoints)
+		if len(scopes) == 0 {
+			scopes = []string{globus.ScopeTransferAll}
+		}
 
 		// Authenticate
 		// create config
@@ -49,7 +50,23 @@ valid by default for a long period of time.`,
 		if err != nil {
 			log.Fatal(err)
 		}
-		fmt.Printf("Your refresh token is: \"%s\"\n", token.RefreshToken)
+
+		// Globus issues one token per resource server, the one of Transfer is the one the other
+		// commands need
+		tokens, err := globus.AuthNewTokenSet(ctx, conf, token)
+		if err != nil {
+			log.Fatal(err)
+		}
+		src, err := tokens.TokenSource(globus.ResourceServerTransfer)
+		if err != nil {
+			log.Fatal(err)
+		}
+		transferToken, err := src.Token()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Your refresh token is: \"%s\"\n", transferToken.RefreshToken)
+		fmt.Printf("Pass it to other commands with --refresh-token or the %s environment variable to skip the login.\n", refreshTokenEnv)
 	},
 }
 
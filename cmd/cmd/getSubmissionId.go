@@ -0,0 +1,49 @@
+/*
+Copyright © 2024 The Swiss OpenEM Team
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// getSubmissionIdCmd represents the getSubmissionId command
+var getSubmissionIdCmd = &cobra.Command{
+	Use:   "getSubmissionId [flags]",
+	Short: "Pre-allocates a submission id for an idempotent submission",
+	Long: `
+This command requests a new submission id from Globus and
+prints it. Passing it to the submit command with the
+submission-id flag makes the submission idempotent:
+submitting again with the same id returns the task of the
+first submission instead of creating a new one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		authCodeGrant, _ := cmd.Flags().GetBool("auth-code-grant")
+		clientID, _ := cmd.Flags().GetString("client-id")
+		clientSecret, _ := cmd.Flags().GetString("client-secret")
+		redirectURL, _ := cmd.Flags().GetString("redirect-url")
+
+		scopes := []string{
+			"urn:globus:auth:scope:transfer.api.globus.org:all",
+		}
+
+		client, err := login(authCodeGrant, clientID, clientSecret, redirectURL, scopes)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		submissionId, err := client.TransferGetSubmissionId()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("Submission id: %s\n", submissionId)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(getSubmissionIdCmd)
+}
@@ -4,9 +4,11 @@ Copyright © 2024 The Swiss OpenEM Team
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 
+	"github.com/SwissOpenEM/globus"
 	"github.com/spf13/cobra"
 )
 
@@ -18,13 +20,16 @@ var getTaskListCmd = &cobra.Command{
 It requests the current transfer task list of the user
 or service account that is provided. It will then print
 out the results, with each task being printed out as
-a raw struct.`,
+a raw struct. With resolve-owners, the owner of each task
+is shown by username, using the stored token of Globus Auth
+(see the login command).`,
 	Run: func(cmd *cobra.Command, args []string) {
 		authCodeGrant, _ := cmd.Flags().GetBool("auth-code-grant")
 		clientID, _ := cmd.Flags().GetString("client-id")
 		clientSecret, _ := cmd.Flags().GetString("client-secret")
 		redirectURL, _ := cmd.Flags().GetString("redirect-url")
 		limit, _ := cmd.Flags().GetUint("limit")
+		resolveOwners, _ := cmd.Flags().GetBool("resolve-owners")
 
 		if limit < 1 {
 			log.Fatal(fmt.Errorf("limit can't be less than 1"))
@@ -61,9 +66,30 @@ a raw struct.`,
 			log.Fatal(err)
 		}
 
+		// look up the owners, the list only contains their identity ids
+		owners := map[string]globus.Identity{}
+		if resolveOwners {
+			ctx := context.Background()
+			ts, err := storedAuthTokenSource(ctx, clientID, clientSecret)
+			if err != nil {
+				log.Fatal(err)
+			}
+			var ownerIds []string
+			for _, transfer := range transferList.Data {
+				ownerIds = append(ownerIds, transfer.OwnerId)
+			}
+			owners, err = globus.NewIdentityResolver(ts, len(ownerIds)).Resolve(ctx, ownerIds...)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
 		// present results
 		fmt.Print("Result of request: \n")
 		for _, transfer := range transferList.Data {
+			if owner, ok := owners[transfer.OwnerId]; ok {
+				fmt.Printf("\nowner: %s (%s)", owner.Username, owner.Name)
+			}
 			fmt.Printf("\n%+v\n", transfer)
 		}
 	},
@@ -75,5 +101,6 @@ func init() {
 	getTaskListCmd.Flags().Uint("offset", 0, "set the initial offset of the list for pagination (can't use with page)")
 	getTaskListCmd.Flags().Uint("limit", 50, "set the max. size of the requested list")
 	getTaskListCmd.Flags().Uint("page", 1, "set the page on the task list (can't use with offset)")
+	getTaskListCmd.Flags().Bool("resolve-owners", false, "show the usernames of the task owners")
 	getTaskListCmd.MarkFlagsMutuallyExclusive("offset", "page")
 }
@@ -0,0 +1,55 @@
+/*
+Copyright © 2024 The Swiss OpenEM Team
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/spf13/cobra"
+)
+
+// identitiesCmd represents the identities command
+var identitiesCmd = &cobra.Command{
+	Use:   "identities [flags] <id or username>...",
+	Short: "Looks up Globus identities by id or username",
+	Long: `
+This command resolves identity ids (e.g. the owner of a task) and
+usernames (e.g. user@example.org) to Globus identities and prints
+them, using the stored token of Globus Auth obtained with the
+login command. Unknown identities are reported as such.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		clientID, _ := cmd.Flags().GetString("client-id")
+		clientSecret, _ := cmd.Flags().GetString("client-secret")
+
+		ctx := context.Background()
+		ts, err := storedAuthTokenSource(ctx, clientID, clientSecret)
+		if err != nil {
+			log.Fatal(err)
+		}
+		identities, err := globus.NewIdentityResolver(ts, len(args)).Resolve(ctx, args...)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, arg := range args {
+			identity, ok := identities[arg]
+			if !ok {
+				fmt.Printf("%s: unknown identity\n", arg)
+				continue
+			}
+			fmt.Printf("%s:\n", arg)
+			fmt.Printf("  id: %s, username: %s, status: %s\n", identity.Id, identity.Username, identity.Status)
+			fmt.Printf("  name: %s, email: %s\n", identity.Name, identity.Email)
+			fmt.Printf("  organization: %s, identity provider: %s\n", identity.Organization, identity.IdentityProvider)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(identitiesCmd)
+}
@@ -2,14 +2,54 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/SwissOpenEM/globus"
 	"golang.org/x/oauth2"
 )
 
+// the refresh token set with the global --refresh-token flag or the GLOBUS_REFRESH_TOKEN variable
+var refreshToken string
+
+const refreshTokenEnv = "GLOBUS_REFRESH_TOKEN"
+
+// Logs in with, in this order, the refresh token passed to the CLI, the token stored by the
+// login command or, if neither is available, the authentication flow selected by the flags.
 func login(authCodeGrant bool, clientID string, clientSecret string, redirectURL string, scopes []string) (globus.GlobusClient, error) {
 	ctx := context.Background()
+	if refreshToken == "" {
+		refreshToken = os.Getenv(refreshTokenEnv)
+	}
+
+	if refreshToken != "" {
+		// login without user interaction, using a refresh token (e.g. one printed by getRefreshToken)
+		return globus.AuthCreateClientFromRefreshToken(ctx, clientID, clientSecret, refreshToken, scopes, func(newRefreshToken string) {
+			fmt.Fprintf(os.Stderr, "The refresh token was rotated, the old one is no longer valid. Your new refresh token is: \"%s\"\n", newRefreshToken)
+		})
+	}
+
+	if tokenStoreExists() {
+		// login without user interaction, using the token stored by the login command
+		store, err := openTokenStore(false)
+		if err != nil {
+			return globus.GlobusClient{}, err
+		}
+		if stored, err := findStoredToken(store, clientID, transferResourceServer); err == nil {
+			conf := globus.AuthGenerateOauthClientConfig(ctx, clientID, clientSecret, redirectURL, scopes)
+			ts, err := globus.AuthStoredTokenSource(ctx, conf, store, stored.Key, func(err error) {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			})
+			if err != nil {
+				return globus.GlobusClient{}, err
+			}
+			return globus.HttpClientToGlobusClient(oauth2.NewClient(ctx, ts)), nil
+		} else if !errors.Is(err, globus.ErrTokenNotFound) {
+			return globus.GlobusClient{}, err
+		}
+	}
+
 	if authCodeGrant {
 		// 3-legged OAuth2 authentication - client software authenticates as a user
 
@@ -24,11 +64,12 @@ func login(authCodeGrant bool, clientID string, clientSecret string, redirectURL
 			return globus.GlobusClient{}, err
 		}
 
-		// setup auto-refresh & create client
-		ts := conf.TokenSource(ctx, tok)
-		client := oauth2.NewClient(ctx, ts)
-
-		return globus.HttpClientToGlobusClient(client), nil
+		// setup auto-refresh & create client from the transfer token (Globus issues one per resource server)
+		tokens, err := globus.AuthNewTokenSet(ctx, conf, tok)
+		if err != nil {
+			return globus.GlobusClient{}, err
+		}
+		return tokens.GlobusClient()
 	} else {
 		// 2-legged OAuth2 authentication - client software authenticates as itself
 
@@ -48,18 +89,132 @@ func login(authCodeGrant bool, clientID string, clientSecret string, redirectURL
 	}
 }
 
-func getToken(ctx context.Context, clientID string, clientSecret string, redirectURL string, scopes []string, conf oauth2.Config) (*oauth2.Token, error) {
+// returns an unauthenticated client in dry-run mode, as nothing will be sent, and logs in otherwise
+func loginForSubmission(dryRun bool, authCodeGrant bool, clientID string, clientSecret string, redirectURL string, scopes []string) (globus.GlobusClient, error) {
+	if dryRun {
+		return globus.GlobusClient{}.WithDryRun(true), nil
+	}
+	return login(authCodeGrant, clientID, clientSecret, redirectURL, scopes)
+}
+
+// prints the result of a submission, or the document that would have been submitted in dry-run mode
+func printSubmissionResult(client globus.GlobusClient, header string, result globus.TransferResult) {
+	if client.IsDryRun() {
+		fmt.Printf("%s (dry run, not submitted): \n%s\n", header, string(result.Payload))
+		return
+	}
+	fmt.Printf("%s: \n%+v\n", header, result)
+}
+
+// loopback redirect settings, set with global flags
+var (
+	useLoopback  bool
+	loopbackPort int
+)
+
+func getToken(ctx context.Context, clientID string, clientSecret string, redirectURL string, scopes []string, conf oauth2.Config, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
 	// PKCE verifier
 	verifier := oauth2.GenerateVerifier()
+	opts = append([]oauth2.AuthCodeOption{oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier)}, opts...)
 
-	// redirect user to consent page to ask for permission and obtain the code
-	url := conf.AuthCodeURL("state", oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
-	fmt.Printf("Visit the URL for the auth dialog: %v\n\nEnter the received code here: ", url)
+	state, err := globus.AuthGenerateState()
+	if err != nil {
+		return nil, err
+	}
+
+	// receive the redirect locally if possible, otherwise the user has to copy the code
+	var redirect *globus.LoopbackRedirect
+	if useLoopback {
+		redirect, err = globus.AuthStartLoopbackRedirect(loopbackPort)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, falling back to entering the code manually\n", err)
+		} else {
+			defer redirect.Close()
+			conf.RedirectURL = redirect.RedirectURL()
+			state = redirect.State()
+		}
+	}
+	if conf.RedirectURL == "" {
+		return nil, fmt.Errorf("a redirect url is required for the login, unless the redirect is received with --loopback")
+	}
 
-	// read-in and exchange code for token
+	// redirect user to consent page to ask for permission and obtain the code
+	url := conf.AuthCodeURL(state, opts...)
 	var code string
-	if _, err := fmt.Scan(&code); err != nil {
+	if redirect == nil {
+		fmt.Printf("Visit the URL for the auth dialog: %v\n\nEnter the received code here: ", url)
+		if _, err := fmt.Scan(&code); err != nil {
+			return nil, err
+		}
+	} else {
+		// on headless machines, the browser can't reach the redirect, so the user can still enter the code
+		fmt.Printf("Visit the URL for the auth dialog: %v\n\nWaiting for the login to complete (or enter the code of the redirect url here): ", url)
+		typed := make(chan string, 1)
+		go func() {
+			var code string
+			if _, err := fmt.Scan(&code); err == nil {
+				typed <- code
+			}
+		}()
+		received := make(chan error, 1)
+		var receivedCode string
+		go func() {
+			var err error
+			receivedCode, err = redirect.WaitForCode(ctx)
+			received <- err
+		}()
+
+		select {
+		case err := <-received:
+			if err != nil {
+				return nil, err
+			}
+			code = receivedCode
+			fmt.Println("\nLogin completed")
+		case code = <-typed:
+		}
+	}
+
+	// exchange code for token
+	tok, err := conf.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
 		return nil, err
 	}
-	return conf.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+
+	// with the openid scope, Globus also returns an id token, which must be genuine
+	if _, ok := tok.Extra("id_token").(string); ok {
+		if _, err := globus.AuthVerifyIDToken(ctx, clientID, tok); err != nil {
+			return nil, err
+		}
+	}
+	return tok, nil
+}
+
+// Runs a submission and, if it fails because consent is required for additional scopes,
+// runs the authorization code flow again for exactly those scopes and retries it once.
+// This is only possible in three-legged mode, as service clients can't give consents.
+func submitWithConsent(client globus.GlobusClient, authCodeGrant bool, clientID string, clientSecret string, redirectURL string, submit func(globus.GlobusClient) error) error {
+	err := submit(client)
+	var consentErr *globus.ConsentRequiredError
+	if !authCodeGrant || !errors.As(err, &consentErr) {
+		return err
+	}
+
+	fmt.Printf("Additional consent is required for the following scopes: %v\n", consentErr.RequiredScopes)
+	ctx := context.Background()
+	conf := globus.AuthGenerateOauthClientConfig(ctx, clientID, clientSecret, redirectURL, consentErr.RequiredScopes)
+	tok, err := getToken(ctx, clientID, clientSecret, redirectURL, consentErr.RequiredScopes, conf, globus.AuthConsentOptions(globus.ConsentSession{})...)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := globus.AuthNewTokenSet(ctx, conf, tok)
+	if err != nil {
+		return err
+	}
+	consentedClient, err := tokens.GlobusClient()
+	if err != nil {
+		return err
+	}
+	return submit(consentedClient.WithDryRun(client.IsDryRun()))
 }
@@ -0,0 +1,88 @@
+/*
+Copyright © 2024 The Swiss OpenEM Team
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/spf13/cobra"
+)
+
+// loginCmd represents the login command
+var loginCmd = &cobra.Command{
+	Use:   "login [flags]",
+	Short: "Lets the user log in and stores the tokens for the other commands",
+	Long: `
+This command will execute a 3-legged OAuth authentication with offline
+access and store the resulting tokens in the token store. The other
+commands use the stored tokens (refreshing them as needed) instead of
+asking the user to log in again, until the logout command is run.
+Consents for the data access of the given endpoints are requested
+along with the login. Scopes of other services can be added, Globus
+then issues one token per service, which are all stored. The identity
+of the user is requested as well (openid, profile and email scopes),
+see the whoami command, and access to look up other identities, see
+the identities command.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		authCodeGrant, _ := cmd.Flags().GetBool("auth-code-grant")
+		clientID, _ := cmd.Flags().GetString("client-id")
+		clientSecret, _ := cmd.Flags().GetString("client-secret")
+		redirectURL, _ := cmd.Flags().GetString("redirect-url")
+
+		srcEndpoint, _ := cmd.Flags().GetString("src-endpoint")
+		destEndpoint, _ := cmd.Flags().GetString("dest-endpoint")
+
+		if !authCodeGrant {
+			log.Fatal("login is only needed in three-legged mode (--auth-code-grant), service clients log in with their secret")
+		}
+
+		extraScopes, _ := cmd.Flags().GetStringSlice("scope")
+
+		// Globus issues one token per resource server, all of them are stored
+		scopes := globus.TransferDataAccessScopeCreator([]string{srcEndpoint, destEndpoint})
+		if len(scopes) == 0 {
+			scopes = []string{globus.ScopeTransferAll}
+		}
+		scopes = append(scopes, globus.ScopeOpenId, globus.ScopeProfile, globus.ScopeEmail, globus.ScopeAuthViewIdentities)
+		scopes = append(scopes, extraScopes...)
+
+		store, err := openTokenStore(true)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		ctx := context.Background()
+		conf := globus.AuthGenerateOauthClientConfig(ctx, clientID, clientSecret, redirectURL, scopes)
+		token, err := getToken(ctx, clientID, clientSecret, redirectURL, scopes, conf)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// the identity from the id token (verified by getToken already, the keys are cached)
+		claims, err := globus.AuthVerifyIDToken(ctx, clientID, token)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		keys, err := globus.AuthStoreTokens(store, clientID, token)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Logged in as %s, the tokens are stored in '%s':\n", claims.PreferredUsername, tokenStorePath)
+		for _, key := range keys {
+			fmt.Printf("  %s\n", key.ResourceServer)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+
+	loginCmd.Flags().String("src-endpoint", "", "set source endpoint to consent to")
+	loginCmd.Flags().String("dest-endpoint", "", "set destination endpoint to consent to")
+	loginCmd.Flags().StringSlice("scope", nil, "request additional scopes, e.g. of other Globus services (repeatable)")
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2024 The Swiss OpenEM Team
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/spf13/cobra"
+)
+
+// logoutCmd represents the logout command
+var logoutCmd = &cobra.Command{
+	Use:   "logout [flags]",
+	Short: "Revokes and removes the stored tokens of the client",
+	Long: `
+This command revokes the access and refresh tokens of the client
+that were stored by the login command, for all resource servers,
+and removes them from the token store. A refresh token passed with
+--refresh-token or the GLOBUS_REFRESH_TOKEN environment variable
+(e.g. one printed by getRefreshToken) is revoked as well. The
+token store file is deleted once it's empty. Afterwards, the other
+commands ask the user to log in again.
+
+Tokens of other clients can only be revoked with their own
+credentials, with --all they are removed without being revoked.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		clientID, _ := cmd.Flags().GetString("client-id")
+		clientSecret, _ := cmd.Flags().GetString("client-secret")
+		all, _ := cmd.Flags().GetBool("all")
+
+		ctx := context.Background()
+		if refreshToken == "" {
+			refreshToken = os.Getenv(refreshTokenEnv)
+		}
+		if refreshToken != "" {
+			if err := globus.AuthRevokeToken(ctx, clientID, clientSecret, refreshToken); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println("Revoked the refresh token")
+		}
+
+		if !tokenStoreExists() {
+			fmt.Println("No tokens stored")
+			return
+		}
+		store, err := openTokenStore(false)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		removed, err := globus.AuthLogout(ctx, clientID, clientSecret, store)
+		fmt.Printf("Revoked and removed %d token(s)\n", removed)
+		if err != nil {
+			log.Fatalf("some tokens couldn't be revoked and were kept, run logout again: %v", err)
+		}
+
+		tokens, err := store.List()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if all {
+			for _, token := range tokens {
+				if err := store.Delete(token.Key); err != nil {
+					log.Fatal(err)
+				}
+			}
+			if len(tokens) > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: removed %d token(s) of other clients without revoking them\n", len(tokens))
+			}
+			tokens = nil
+		}
+
+		// wipe the store once it's empty, nothing is left behind on disk
+		if len(tokens) == 0 {
+			if err := os.Remove(tokenStorePath); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("Removed the token store '%s'\n", tokenStorePath)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logoutCmd)
+
+	logoutCmd.Flags().Bool("all", false, "also remove the tokens of other clients (without revoking them)")
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2024 The Swiss OpenEM Team
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/manifest"
+	"github.com/spf13/cobra"
+)
+
+// queueManifestsCmd represents the queueManifests command
+var queueManifestsCmd = &cobra.Command{
+	Use:   "queueManifests [flags] manifest...",
+	Short: "Submits many manifests while limiting the number of active tasks",
+	Long: `
+This command reads the given transfer manifests and queues
+them. It keeps at most max-active tasks running at the same
+time (and at most max-active-per-pair between the same two
+endpoints), submitting the next manifest whenever a task
+finishes, until all of them are done. Manifests listed
+first are submitted first.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		authCodeGrant, _ := cmd.Flags().GetBool("auth-code-grant")
+		clientID, _ := cmd.Flags().GetString("client-id")
+		clientSecret, _ := cmd.Flags().GetString("client-secret")
+		redirectURL, _ := cmd.Flags().GetString("redirect-url")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		maxActive, _ := cmd.Flags().GetInt("max-active")
+		maxActivePerPair, _ := cmd.Flags().GetInt("max-active-per-pair")
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		// read manifests
+		var transfers []globus.Transfer
+		var endpoints []string
+		for _, manifestPath := range args {
+			m, err := manifest.ReadFile(manifestPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			transfer, err := m.Transfer()
+			if err != nil {
+				log.Fatalf("%s: %v", manifestPath, err)
+			}
+			transfers = append(transfers, transfer)
+			endpoints = append(endpoints, transfer.SourceEndpoint, transfer.DestinationEndpoint)
+		}
+
+		scopes := globus.TransferDataAccessScopeCreator(endpoints)
+
+		client, err := loginForSubmission(dryRun, authCodeGrant, clientID, clientSecret, redirectURL, scopes)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		queue := globus.NewTransferQueue(client, globus.QueueOptions{
+			MaxActive:                maxActive,
+			MaxActivePerEndpointPair: maxActivePerPair,
+			PollInterval:             interval,
+			OnChange: func(job globus.QueuedJob) {
+				fmt.Printf("%s: job %s is %s", time.Now().Format(time.RFC3339), job.Id, job.State)
+				if job.TaskId != "" {
+					fmt.Printf(" (task %s)", job.TaskId)
+				}
+				if job.Error != "" {
+					fmt.Printf(": %s", job.Error)
+				}
+				fmt.Println()
+				if job.State == globus.JobDryRun {
+					fmt.Printf("%s\n", string(job.Payload))
+				}
+			},
+		})
+		for i, transfer := range transfers {
+			// earlier manifests get a higher priority
+			if _, err := queue.Enqueue(transfer, len(transfers)-i); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		err = queue.Drain(ctx, func(err error) {
+			log.Printf("error while polling tasks: %v\n", err)
+		})
+
+		state := queue.State()
+		fmt.Printf("%d jobs finished, %d active, %d still queued\n", len(state.Finished), len(state.Active), len(state.Queued))
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(queueManifestsCmd)
+
+	queueManifestsCmd.Flags().Int("max-active", 3, "max. number of active tasks at the same time (0: no limit)")
+	queueManifestsCmd.Flags().Int("max-active-per-pair", 0, "max. number of active tasks between the same endpoints (0: no limit)")
+	queueManifestsCmd.Flags().Duration("interval", time.Minute, "time between status polls of active tasks")
+}
@@ -27,10 +27,17 @@ func Execute() {
 	rootCmd.PersistentFlags().String("client-id", "", "set client ID of application")
 	rootCmd.PersistentFlags().String("client-secret", "", "set client secret of application")
 	rootCmd.PersistentFlags().String("redirect-url", "", "set redirect url (only used in three-legged mode)")
+	rootCmd.PersistentFlags().BoolVar(&useLoopback, "loopback", false, "receive the login redirect on a local port instead of entering the code (the client must allow http://127.0.0.1:<port>/callback)")
+	rootCmd.PersistentFlags().IntVar(&loopbackPort, "loopback-port", 0, "set the port of the loopback redirect (0: random port)")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "validate and print submissions instead of sending them")
+	rootCmd.PersistentFlags().StringVar(&tokenStorePath, "token-store", defaultTokenStorePath(), "set the file the login command stores tokens in")
+	rootCmd.PersistentFlags().StringVar(&tokenStorePassphrase, "token-passphrase", "", "encrypt the token store with a passphrase (can also be set with the "+tokenPassphraseEnv+" environment variable)")
+	rootCmd.PersistentFlags().StringVar(&tokenStoreKeyFile, "token-key-file", "", "encrypt the token store with the key derived from a file")
+	rootCmd.PersistentFlags().StringVar(&refreshToken, "refresh-token", "", "log in with a refresh token instead (can also be set with the "+refreshTokenEnv+" environment variable)")
 
 	rootCmd.MarkFlagRequired("client-id")
 	rootCmd.MarkFlagsMutuallyExclusive("client-secret", "auth-code-grant")
-	rootCmd.MarkFlagsRequiredTogether("redirect-url", "auth-code-grant")
+	rootCmd.MarkFlagsMutuallyExclusive("token-passphrase", "token-key-file")
 
 	err := rootCmd.Execute()
 	if err != nil {
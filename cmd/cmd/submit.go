@@ -0,0 +1,144 @@
+/*
+Copyright © 2024 The Swiss OpenEM Team
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/manifest"
+	"github.com/spf13/cobra"
+)
+
+// submitCmd represents the submit command
+var submitCmd = &cobra.Command{
+	Use:   "submit [flags]",
+	Short: "Submits a transfer described by a manifest file",
+	Long: `
+This command reads a transfer manifest (JSON, YAML or CSV,
+determined by the file extension) describing the endpoints,
+the transfer options and every item to transfer with its own
+settings, validates it and submits it as a transfer task.
+
+In the CSV format, the endpoints and options are given as
+comment lines of the form "# key: value" before the header
+row (values with line breaks are written as JSON strings),
+for example:
+
+  # source_endpoint: <uuid>
+  # destination_endpoint: <uuid>
+  # label: "run 17\nfirst pass"
+  # verify_checksum: true
+  source,destination,recursive,symlink,external_checksum,checksum_algorithm
+  /data/a.tiff,/archive/a.tiff,false,false,9e107d9d372bb6826bd81d3542a419d6,MD5
+
+If a submission id obtained with getSubmissionId is given,
+the submission can safely be retried: Globus only creates
+the task once. Alternatively, the submission can be recorded
+in a task store file under a job key: submitting the same job
+key again reuses its submission id, and watchTasks keeps
+track of the task's status. With a spool directory, a
+transfer that can't be submitted because the Transfer API is
+unreachable is saved there and can be submitted later with
+the flushSpool command.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		authCodeGrant, _ := cmd.Flags().GetBool("auth-code-grant")
+		clientID, _ := cmd.Flags().GetString("client-id")
+		clientSecret, _ := cmd.Flags().GetString("client-secret")
+		redirectURL, _ := cmd.Flags().GetString("redirect-url")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		manifestPath, _ := cmd.Flags().GetString("manifest")
+		submissionId, _ := cmd.Flags().GetString("submission-id")
+		storePath, _ := cmd.Flags().GetString("store")
+		jobKey, _ := cmd.Flags().GetString("job-key")
+		spoolDir, _ := cmd.Flags().GetString("spool")
+
+		// read manifest
+		m, err := manifest.ReadFile(manifestPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		transfer, err := m.Transfer()
+		if err != nil {
+			log.Fatal(err)
+		}
+		transfer.SubmissionId = submissionId
+
+		scopes := globus.TransferDataAccessScopeCreator([]string{transfer.SourceEndpoint, transfer.DestinationEndpoint})
+
+		client, err := loginForSubmission(dryRun, authCodeGrant, clientID, clientSecret, redirectURL, scopes)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		err = submitWithConsent(client, authCodeGrant, clientID, clientSecret, redirectURL, func(client globus.GlobusClient) error {
+			if storePath != "" {
+				// tracked submission, resubmitting a job reuses its recorded submission id
+				store, err := globus.NewFileTaskStore(storePath)
+				if err != nil {
+					return err
+				}
+				record, result, err := client.TransferPostTaskTracked(store, jobKey, transfer)
+				if errors.Is(err, globus.ErrAlreadySubmitted) {
+					fmt.Printf("Job '%s' was already submitted as task %s\n", record.JobKey, record.TaskId)
+					return nil
+				} else if err != nil {
+					return err
+				}
+				printSubmissionResult(client, "Result of request", result)
+				return nil
+			}
+
+			if spoolDir != "" {
+				// submissions failing because Globus is unreachable are kept for flushSpool
+				spool, err := globus.NewTransferSpool(client, spoolDir, globus.SpoolOptions{})
+				if err != nil {
+					return err
+				}
+				result, spooled, err := spool.Submit(transfer)
+				if err != nil {
+					return err
+				}
+				if spooled {
+					fmt.Printf("Globus is not reachable, the transfer was spooled in '%s'\n", spoolDir)
+					return nil
+				}
+				printSubmissionResult(client, "Result of request", result)
+				return nil
+			}
+
+			result, err := client.TransferPostTask(transfer)
+			if err != nil {
+				return err
+			}
+			if result.IsDuplicate() {
+				fmt.Printf("Submission id was already used, task %s was not submitted again\n", result.TaskId)
+			}
+			printSubmissionResult(client, "Result of request", result)
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(submitCmd)
+
+	submitCmd.Flags().String("manifest", "", "manifest file describing the transfer (.json, .yaml/.yml or .csv)")
+	submitCmd.Flags().String("submission-id", "", "pre-allocated submission id (see getSubmissionId), makes resubmissions idempotent")
+
+	submitCmd.Flags().String("store", "", "task store file to record the submission in (see watchTasks)")
+	submitCmd.Flags().String("job-key", "", "key of the job in the task store")
+	submitCmd.Flags().String("spool", "", "spool directory keeping the transfer if Globus is unreachable (see flushSpool)")
+
+	submitCmd.MarkFlagRequired("manifest")
+	submitCmd.MarkFlagsRequiredTogether("store", "job-key")
+	submitCmd.MarkFlagsMutuallyExclusive("store", "submission-id")
+	submitCmd.MarkFlagsMutuallyExclusive("store", "spool")
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/SwissOpenEM/globus"
+	"golang.org/x/oauth2"
+)
+
+// token store settings, set with global flags
+var (
+	tokenStorePath       string
+	tokenStorePassphrase string
+	tokenStoreKeyFile    string
+)
+
+const tokenPassphraseEnv = "GLOBUS_TOKEN_PASSPHRASE"
+
+// the resource server of the tokens used by the commands
+const transferResourceServer = "transfer.api.globus.org"
+
+// the default location of the token store: <user config dir>/openem-globus/tokens.json
+func defaultTokenStorePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "tokens.json"
+	}
+	return filepath.Join(dir, "openem-globus", "tokens.json")
+}
+
+// opens the token store set with the global flags, creating its directory if create is set
+func openTokenStore(create bool) (*globus.FileTokenStore, error) {
+	if create {
+		if err := os.MkdirAll(filepath.Dir(tokenStorePath), 0700); err != nil {
+			return nil, err
+		}
+	}
+	passphrase := tokenStorePassphrase
+	if passphrase == "" {
+		passphrase = os.Getenv(tokenPassphraseEnv)
+	}
+	return globus.NewFileTokenStore(tokenStorePath, globus.TokenEncryption{Passphrase: passphrase, KeyFile: tokenStoreKeyFile})
+}
+
+// returns the most recently updated token of a client for a resource server, or ErrTokenNotFound
+func findStoredToken(store globus.TokenStore, clientID string, resourceServer string) (globus.StoredToken, error) {
+	tokens, err := store.List()
+	if err != nil {
+		return globus.StoredToken{}, err
+	}
+	var found *globus.StoredToken
+	for i, token := range tokens {
+		if token.Key.ClientId != clientID || token.Key.ResourceServer != resourceServer {
+			continue
+		}
+		if found == nil || token.UpdatedAt.After(found.UpdatedAt) {
+			found = &tokens[i]
+		}
+	}
+	if found == nil {
+		return globus.StoredToken{}, globus.ErrTokenNotFound
+	}
+	return *found, nil
+}
+
+// true if the token store file exists
+func tokenStoreExists() bool {
+	_, err := os.Stat(tokenStorePath)
+	return !errors.Is(err, os.ErrNotExist)
+}
+
+// Returns a token source for the stored token of Globus Auth itself, which the login command
+// obtains along with the identity of the user.
+func storedAuthTokenSource(ctx context.Context, clientID string, clientSecret string) (oauth2.TokenSource, error) {
+	if !tokenStoreExists() {
+		return nil, errors.New("not logged in, run the login command first")
+	}
+	store, err := openTokenStore(false)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := findStoredToken(store, clientID, globus.ResourceServerAuth)
+	if errors.Is(err, globus.ErrTokenNotFound) {
+		return nil, errors.New("no Globus Auth token stored for the client, log in again with the login command")
+	} else if err != nil {
+		return nil, err
+	}
+
+	conf := globus.AuthGenerateOauthClientConfig(ctx, clientID, clientSecret, "", nil)
+	return globus.AuthStoredTokenSource(ctx, conf, store, stored.Key, func(err error) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	})
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2024 The Swiss OpenEM Team
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// tokensCmd represents the tokens command
+var tokensCmd = &cobra.Command{
+	Use:   "tokens [flags]",
+	Short: "Lists the stored tokens",
+	Long: `
+This command lists the tokens in the token store, without
+revealing the tokens themselves: the client, identity and
+resource server they belong to, their scopes, when the access
+token expires and whether it can be refreshed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !tokenStoreExists() {
+			fmt.Println("No tokens stored")
+			return
+		}
+		store, err := openTokenStore(false)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tokens, err := store.List()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(tokens) == 0 {
+			fmt.Println("No tokens stored")
+			return
+		}
+
+		for _, token := range tokens {
+			identity := token.Key.IdentityId
+			if identity == "" {
+				identity = "unknown"
+			}
+			expiry := "never"
+			if !token.Token.Expiry.IsZero() {
+				expiry = token.Token.Expiry.Format(time.RFC3339)
+			}
+			fmt.Printf("client: %s, identity: %s, resource server: %s\n", token.Key.ClientId, identity, token.Key.ResourceServer)
+			fmt.Printf("  scopes: %s\n", strings.Join(token.Scopes, " "))
+			fmt.Printf("  expires: %s, refreshable: %t, updated: %s\n", expiry, token.Token.RefreshToken != "", token.UpdatedAt.Format(time.RFC3339))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tokensCmd)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2024 The Swiss OpenEM Team
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/spf13/cobra"
+)
+
+// watchTasksCmd represents the watchTasks command
+var watchTasksCmd = &cobra.Command{
+	Use:   "watchTasks [flags]",
+	Short: "Watches the unfinished tasks recorded in a task store",
+	Long: `
+This command loads a task store file (written by the submit
+command) and periodically polls the status of all tasks that
+are not finished yet, recording it in the store. It stops once
+all tasks are finished. If it's interrupted, running it again
+resumes watching where it left off.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		authCodeGrant, _ := cmd.Flags().GetBool("auth-code-grant")
+		clientID, _ := cmd.Flags().GetString("client-id")
+		clientSecret, _ := cmd.Flags().GetString("client-secret")
+		redirectURL, _ := cmd.Flags().GetString("redirect-url")
+
+		storePath, _ := cmd.Flags().GetString("store")
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		store, err := globus.NewFileTaskStore(storePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		scopes := []string{
+			"urn:globus:auth:scope:transfer.api.globus.org:all",
+		}
+
+		client, err := login(authCodeGrant, clientID, clientSecret, redirectURL, scopes)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		monitor := globus.TaskMonitor{
+			Client:   client,
+			Store:    store,
+			Interval: interval,
+			OnUpdate: func(record globus.TaskRecord, task globus.Task) {
+				fmt.Printf("%s: job '%s' (task %s) is %s, %d/%d files transferred\n",
+					record.UpdatedAt.Format(time.RFC3339), record.JobKey, record.TaskId, record.Status, task.FilesTransferred, task.Files)
+			},
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		err = monitor.Run(ctx, func(err error) {
+			log.Printf("error while polling tasks: %v\n", err)
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("All tasks are finished")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchTasksCmd)
+
+	watchTasksCmd.Flags().String("store", "", "task store file")
+	watchTasksCmd.Flags().Duration("interval", time.Minute, "time between status polls")
+
+	watchTasksCmd.MarkFlagRequired("store")
+}
@@ -0,0 +1,57 @@
+/*
+Copyright © 2024 The Swiss OpenEM Team
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/spf13/cobra"
+)
+
+// whoamiCmd represents the whoami command
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami [flags]",
+	Short: "Shows the identity of the logged in user",
+	Long: `
+This command asks Globus Auth who the user logged in with the
+login command is, using the stored token of Globus Auth itself,
+and prints the identity along with its linked identities.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		clientID, _ := cmd.Flags().GetString("client-id")
+		clientSecret, _ := cmd.Flags().GetString("client-secret")
+
+		ctx := context.Background()
+		ts, err := storedAuthTokenSource(ctx, clientID, clientSecret)
+		if err != nil {
+			log.Fatal(err)
+		}
+		info, err := globus.AuthGetUserInfo(ctx, ts)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("id: %s\n", info.Sub)
+		fmt.Printf("username: %s\n", info.PreferredUsername)
+		fmt.Printf("name: %s\n", info.Name)
+		fmt.Printf("email: %s\n", info.Email)
+		fmt.Printf("organization: %s\n", info.Organization)
+		fmt.Printf("identity provider: %s\n", info.IdentityProviderDisplayName)
+		if len(info.IdentitySet) > 1 {
+			fmt.Println("linked identities:")
+			for _, identity := range info.IdentitySet {
+				if identity.Sub == info.Sub {
+					continue
+				}
+				fmt.Printf("  %s (%s)\n", identity.Username, identity.Sub)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+}
@@ -0,0 +1,41 @@
+package globus
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// returned when the Transfer API requires the user to consent to additional scopes
+// (e.g. data access on a collection) before the request can be fulfilled.
+// The missing scopes are listed in RequiredScopes.
+type ConsentRequiredError struct {
+	ConsentRequired
+}
+
+func (e *ConsentRequiredError) Error() string {
+	return fmt.Sprintf("consent is required for scopes %v: %s", e.RequiredScopes, e.Message)
+}
+
+// returned when the Transfer API answers a submission with an unexpected http status
+type APIError struct {
+	StatusCode int
+	Body       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Reports whether a submission that failed with this error might succeed if it's retried later:
+// network errors, timeouts, rate limiting and server-side errors are temporary, while rejected
+// requests (e.g. invalid documents, missing consents) will fail again the same way.
+func IsTemporaryError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == 429 || apiErr.StatusCode == 408
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -1,13 +1,46 @@
 package globus
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+)
 
 const transferBaseUrl = "https://transfer.api.globusonline.org/v0.10"
 
 type GlobusClient struct {
-	client *http.Client
+	client      *http.Client
+	dryRun      bool
+	transferUrl string // overrides transferBaseUrl, e.g. for a test server
 }
 
 func (g GlobusClient) IsClientSet() bool {
 	return g.client != nil
 }
+
+// Returns a copy of the client with dry-run mode enabled or disabled. In dry-run mode, submissions
+// are validated and marshalled but never sent, and no submission id is requested. The resulting
+// JSON document is returned in the Payload field of the TransferResult instead.
+// A dry-run client doesn't need to be authenticated.
+func (g GlobusClient) WithDryRun(enabled bool) GlobusClient {
+	g.dryRun = enabled
+	return g
+}
+
+func (g GlobusClient) IsDryRun() bool {
+	return g.dryRun
+}
+
+// Returns a copy of the client that sends its Transfer API requests to another base url,
+// e.g. to a fake Transfer API server (see the globustest package).
+// An empty url restores the default one.
+func (g GlobusClient) WithTransferBaseUrl(url string) GlobusClient {
+	g.transferUrl = strings.TrimSuffix(url, "/")
+	return g
+}
+
+func (g GlobusClient) transferURL() string {
+	if g.transferUrl != "" {
+		return g.transferUrl
+	}
+	return transferBaseUrl
+}
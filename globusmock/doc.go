@@ -0,0 +1,17 @@
+// Package globusmock provides a fake implementation of globus.TransferAPI for unit tests.
+//
+// FakeTransferAPI records the arguments of every call and returns scripted results:
+//
+//	fake := &globusmock.FakeTransferAPI{}
+//	fake.TransferPostTaskReturns(globus.TransferResult{TaskId: "task-1"}, nil)
+//	fake.TransferGetTaskByIDReturnsOnCall(0, globus.Task{Status: "ACTIVE"}, nil)
+//	fake.TransferGetTaskByIDReturnsOnCall(1, globus.Task{Status: "SUCCEEDED"}, nil)
+//
+//	// ... run the code under test with fake ...
+//
+//	fake.TransferPostTaskCallCount()   // number of submissions
+//	fake.TransferPostTaskArgsForCall(0) // the submitted transfer
+//
+// The fake is generated with counterfeiter, run "go generate" in the globus package
+// after changing the TransferAPI interface.
+package globusmock
@@ -0,0 +1,1519 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package globusmock
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/SwissOpenEM/globus"
+)
+
+type FakeTransferAPI struct {
+	TransferCancelTaskByIDStub        func(string) (globus.Result, error)
+	transferCancelTaskByIDMutex       sync.RWMutex
+	transferCancelTaskByIDArgsForCall []struct {
+		arg1 string
+	}
+	transferCancelTaskByIDReturns struct {
+		result1 globus.Result
+		result2 error
+	}
+	transferCancelTaskByIDReturnsOnCall map[int]struct {
+		result1 globus.Result
+		result2 error
+	}
+	TransferCopyFileStub        func(*http.Client, string, string, string, string) (globus.TransferResult, error)
+	transferCopyFileMutex       sync.RWMutex
+	transferCopyFileArgsForCall []struct {
+		arg1 *http.Client
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 string
+	}
+	transferCopyFileReturns struct {
+		result1 globus.TransferResult
+		result2 error
+	}
+	transferCopyFileReturnsOnCall map[int]struct {
+		result1 globus.TransferResult
+		result2 error
+	}
+	TransferDeletePathsStub        func(string, []string, bool) (globus.TransferResult, error)
+	transferDeletePathsMutex       sync.RWMutex
+	transferDeletePathsArgsForCall []struct {
+		arg1 string
+		arg2 []string
+		arg3 bool
+	}
+	transferDeletePathsReturns struct {
+		result1 globus.TransferResult
+		result2 error
+	}
+	transferDeletePathsReturnsOnCall map[int]struct {
+		result1 globus.TransferResult
+		result2 error
+	}
+	TransferDeletePostTaskStub        func(globus.Delete) (globus.TransferResult, error)
+	transferDeletePostTaskMutex       sync.RWMutex
+	transferDeletePostTaskArgsForCall []struct {
+		arg1 globus.Delete
+	}
+	transferDeletePostTaskReturns struct {
+		result1 globus.TransferResult
+		result2 error
+	}
+	transferDeletePostTaskReturnsOnCall map[int]struct {
+		result1 globus.TransferResult
+		result2 error
+	}
+	TransferFileListStub        func(string, string, string, string, []string, []bool, bool) (globus.TransferResult, error)
+	transferFileListMutex       sync.RWMutex
+	transferFileListArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 []string
+		arg6 []bool
+		arg7 bool
+	}
+	transferFileListReturns struct {
+		result1 globus.TransferResult
+		result2 error
+	}
+	transferFileListReturnsOnCall map[int]struct {
+		result1 globus.TransferResult
+		result2 error
+	}
+	TransferFolderSyncStub        func(string, string, string, string, bool) (globus.TransferResult, error)
+	transferFolderSyncMutex       sync.RWMutex
+	transferFolderSyncArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+	}
+	transferFolderSyncReturns struct {
+		result1 globus.TransferResult
+		result2 error
+	}
+	transferFolderSyncReturnsOnCall map[int]struct {
+		result1 globus.TransferResult
+		result2 error
+	}
+	TransferGetSubmissionIdStub        func() (string, error)
+	transferGetSubmissionIdMutex       sync.RWMutex
+	transferGetSubmissionIdArgsForCall []struct {
+	}
+	transferGetSubmissionIdReturns struct {
+		result1 string
+		result2 error
+	}
+	transferGetSubmissionIdReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	TransferGetTaskByIDStub        func(string) (globus.Task, error)
+	transferGetTaskByIDMutex       sync.RWMutex
+	transferGetTaskByIDArgsForCall []struct {
+		arg1 string
+	}
+	transferGetTaskByIDReturns struct {
+		result1 globus.Task
+		result2 error
+	}
+	transferGetTaskByIDReturnsOnCall map[int]struct {
+		result1 globus.Task
+		result2 error
+	}
+	TransferGetTaskEventListStub        func(string, uint, uint) (globus.EventList, error)
+	transferGetTaskEventListMutex       sync.RWMutex
+	transferGetTaskEventListArgsForCall []struct {
+		arg1 string
+		arg2 uint
+		arg3 uint
+	}
+	transferGetTaskEventListReturns struct {
+		result1 globus.EventList
+		result2 error
+	}
+	transferGetTaskEventListReturnsOnCall map[int]struct {
+		result1 globus.EventList
+		result2 error
+	}
+	TransferGetTaskGroupStatusStub        func(globus.TaskGroup) (globus.TaskGroupStatus, error)
+	transferGetTaskGroupStatusMutex       sync.RWMutex
+	transferGetTaskGroupStatusArgsForCall []struct {
+		arg1 globus.TaskGroup
+	}
+	transferGetTaskGroupStatusReturns struct {
+		result1 globus.TaskGroupStatus
+		result2 error
+	}
+	transferGetTaskGroupStatusReturnsOnCall map[int]struct {
+		result1 globus.TaskGroupStatus
+		result2 error
+	}
+	TransferGetTaskListStub        func(uint, uint) (globus.TaskList, error)
+	transferGetTaskListMutex       sync.RWMutex
+	transferGetTaskListArgsForCall []struct {
+		arg1 uint
+		arg2 uint
+	}
+	transferGetTaskListReturns struct {
+		result1 globus.TaskList
+		result2 error
+	}
+	transferGetTaskListReturnsOnCall map[int]struct {
+		result1 globus.TaskList
+		result2 error
+	}
+	TransferGetTaskPauseInfoStub        func(string) (globus.PauseInfoLimited, error)
+	transferGetTaskPauseInfoMutex       sync.RWMutex
+	transferGetTaskPauseInfoArgsForCall []struct {
+		arg1 string
+	}
+	transferGetTaskPauseInfoReturns struct {
+		result1 globus.PauseInfoLimited
+		result2 error
+	}
+	transferGetTaskPauseInfoReturnsOnCall map[int]struct {
+		result1 globus.PauseInfoLimited
+		result2 error
+	}
+	TransferGetTaskSkippedErrorsStub        func(string, uint) (globus.SkippedErrors, error)
+	transferGetTaskSkippedErrorsMutex       sync.RWMutex
+	transferGetTaskSkippedErrorsArgsForCall []struct {
+		arg1 string
+		arg2 uint
+	}
+	transferGetTaskSkippedErrorsReturns struct {
+		result1 globus.SkippedErrors
+		result2 error
+	}
+	transferGetTaskSkippedErrorsReturnsOnCall map[int]struct {
+		result1 globus.SkippedErrors
+		result2 error
+	}
+	TransferGetTaskSuccessfulTransfersStub        func(string, uint) (globus.SuccessfulTransfers, error)
+	transferGetTaskSuccessfulTransfersMutex       sync.RWMutex
+	transferGetTaskSuccessfulTransfersArgsForCall []struct {
+		arg1 string
+		arg2 uint
+	}
+	transferGetTaskSuccessfulTransfersReturns struct {
+		result1 globus.SuccessfulTransfers
+		result2 error
+	}
+	transferGetTaskSuccessfulTransfersReturnsOnCall map[int]struct {
+		result1 globus.SuccessfulTransfers
+		result2 error
+	}
+	TransferPostTaskStub        func(globus.Transfer) (globus.TransferResult, error)
+	transferPostTaskMutex       sync.RWMutex
+	transferPostTaskArgsForCall []struct {
+		arg1 globus.Transfer
+	}
+	transferPostTaskReturns struct {
+		result1 globus.TransferResult
+		result2 error
+	}
+	transferPostTaskReturnsOnCall map[int]struct {
+		result1 globus.TransferResult
+		result2 error
+	}
+	TransferPostTaskBatchedStub        func(globus.Transfer, globus.BatchOptions) (globus.TaskGroup, error)
+	transferPostTaskBatchedMutex       sync.RWMutex
+	transferPostTaskBatchedArgsForCall []struct {
+		arg1 globus.Transfer
+		arg2 globus.BatchOptions
+	}
+	transferPostTaskBatchedReturns struct {
+		result1 globus.TaskGroup
+		result2 error
+	}
+	transferPostTaskBatchedReturnsOnCall map[int]struct {
+		result1 globus.TaskGroup
+		result2 error
+	}
+	TransferPostTaskTrackedStub        func(globus.TaskStore, string, globus.Transfer) (globus.TaskRecord, globus.TransferResult, error)
+	transferPostTaskTrackedMutex       sync.RWMutex
+	transferPostTaskTrackedArgsForCall []struct {
+		arg1 globus.TaskStore
+		arg2 string
+		arg3 globus.Transfer
+	}
+	transferPostTaskTrackedReturns struct {
+		result1 globus.TaskRecord
+		result2 globus.TransferResult
+		result3 error
+	}
+	transferPostTaskTrackedReturnsOnCall map[int]struct {
+		result1 globus.TaskRecord
+		result2 globus.TransferResult
+		result3 error
+	}
+	TransferRemoveTaskByIDStub        func(string) (globus.Result, error)
+	transferRemoveTaskByIDMutex       sync.RWMutex
+	transferRemoveTaskByIDArgsForCall []struct {
+		arg1 string
+	}
+	transferRemoveTaskByIDReturns struct {
+		result1 globus.Result
+		result2 error
+	}
+	transferRemoveTaskByIDReturnsOnCall map[int]struct {
+		result1 globus.Result
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeTransferAPI) TransferCancelTaskByID(arg1 string) (globus.Result, error) {
+	fake.transferCancelTaskByIDMutex.Lock()
+	ret, specificReturn := fake.transferCancelTaskByIDReturnsOnCall[len(fake.transferCancelTaskByIDArgsForCall)]
+	fake.transferCancelTaskByIDArgsForCall = append(fake.transferCancelTaskByIDArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.TransferCancelTaskByIDStub
+	fakeReturns := fake.transferCancelTaskByIDReturns
+	fake.recordInvocation("TransferCancelTaskByID", []interface{}{arg1})
+	fake.transferCancelTaskByIDMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferCancelTaskByIDCallCount() int {
+	fake.transferCancelTaskByIDMutex.RLock()
+	defer fake.transferCancelTaskByIDMutex.RUnlock()
+	return len(fake.transferCancelTaskByIDArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferCancelTaskByIDCalls(stub func(string) (globus.Result, error)) {
+	fake.transferCancelTaskByIDMutex.Lock()
+	defer fake.transferCancelTaskByIDMutex.Unlock()
+	fake.TransferCancelTaskByIDStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferCancelTaskByIDArgsForCall(i int) string {
+	fake.transferCancelTaskByIDMutex.RLock()
+	defer fake.transferCancelTaskByIDMutex.RUnlock()
+	argsForCall := fake.transferCancelTaskByIDArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeTransferAPI) TransferCancelTaskByIDReturns(result1 globus.Result, result2 error) {
+	fake.transferCancelTaskByIDMutex.Lock()
+	defer fake.transferCancelTaskByIDMutex.Unlock()
+	fake.TransferCancelTaskByIDStub = nil
+	fake.transferCancelTaskByIDReturns = struct {
+		result1 globus.Result
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferCancelTaskByIDReturnsOnCall(i int, result1 globus.Result, result2 error) {
+	fake.transferCancelTaskByIDMutex.Lock()
+	defer fake.transferCancelTaskByIDMutex.Unlock()
+	fake.TransferCancelTaskByIDStub = nil
+	if fake.transferCancelTaskByIDReturnsOnCall == nil {
+		fake.transferCancelTaskByIDReturnsOnCall = make(map[int]struct {
+			result1 globus.Result
+			result2 error
+		})
+	}
+	fake.transferCancelTaskByIDReturnsOnCall[i] = struct {
+		result1 globus.Result
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferCopyFile(arg1 *http.Client, arg2 string, arg3 string, arg4 string, arg5 string) (globus.TransferResult, error) {
+	fake.transferCopyFileMutex.Lock()
+	ret, specificReturn := fake.transferCopyFileReturnsOnCall[len(fake.transferCopyFileArgsForCall)]
+	fake.transferCopyFileArgsForCall = append(fake.transferCopyFileArgsForCall, struct {
+		arg1 *http.Client
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 string
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.TransferCopyFileStub
+	fakeReturns := fake.transferCopyFileReturns
+	fake.recordInvocation("TransferCopyFile", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.transferCopyFileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferCopyFileCallCount() int {
+	fake.transferCopyFileMutex.RLock()
+	defer fake.transferCopyFileMutex.RUnlock()
+	return len(fake.transferCopyFileArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferCopyFileCalls(stub func(*http.Client, string, string, string, string) (globus.TransferResult, error)) {
+	fake.transferCopyFileMutex.Lock()
+	defer fake.transferCopyFileMutex.Unlock()
+	fake.TransferCopyFileStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferCopyFileArgsForCall(i int) (*http.Client, string, string, string, string) {
+	fake.transferCopyFileMutex.RLock()
+	defer fake.transferCopyFileMutex.RUnlock()
+	argsForCall := fake.transferCopyFileArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeTransferAPI) TransferCopyFileReturns(result1 globus.TransferResult, result2 error) {
+	fake.transferCopyFileMutex.Lock()
+	defer fake.transferCopyFileMutex.Unlock()
+	fake.TransferCopyFileStub = nil
+	fake.transferCopyFileReturns = struct {
+		result1 globus.TransferResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferCopyFileReturnsOnCall(i int, result1 globus.TransferResult, result2 error) {
+	fake.transferCopyFileMutex.Lock()
+	defer fake.transferCopyFileMutex.Unlock()
+	fake.TransferCopyFileStub = nil
+	if fake.transferCopyFileReturnsOnCall == nil {
+		fake.transferCopyFileReturnsOnCall = make(map[int]struct {
+			result1 globus.TransferResult
+			result2 error
+		})
+	}
+	fake.transferCopyFileReturnsOnCall[i] = struct {
+		result1 globus.TransferResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferDeletePaths(arg1 string, arg2 []string, arg3 bool) (globus.TransferResult, error) {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.transferDeletePathsMutex.Lock()
+	ret, specificReturn := fake.transferDeletePathsReturnsOnCall[len(fake.transferDeletePathsArgsForCall)]
+	fake.transferDeletePathsArgsForCall = append(fake.transferDeletePathsArgsForCall, struct {
+		arg1 string
+		arg2 []string
+		arg3 bool
+	}{arg1, arg2Copy, arg3})
+	stub := fake.TransferDeletePathsStub
+	fakeReturns := fake.transferDeletePathsReturns
+	fake.recordInvocation("TransferDeletePaths", []interface{}{arg1, arg2Copy, arg3})
+	fake.transferDeletePathsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferDeletePathsCallCount() int {
+	fake.transferDeletePathsMutex.RLock()
+	defer fake.transferDeletePathsMutex.RUnlock()
+	return len(fake.transferDeletePathsArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferDeletePathsCalls(stub func(string, []string, bool) (globus.TransferResult, error)) {
+	fake.transferDeletePathsMutex.Lock()
+	defer fake.transferDeletePathsMutex.Unlock()
+	fake.TransferDeletePathsStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferDeletePathsArgsForCall(i int) (string, []string, bool) {
+	fake.transferDeletePathsMutex.RLock()
+	defer fake.transferDeletePathsMutex.RUnlock()
+	argsForCall := fake.transferDeletePathsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeTransferAPI) TransferDeletePathsReturns(result1 globus.TransferResult, result2 error) {
+	fake.transferDeletePathsMutex.Lock()
+	defer fake.transferDeletePathsMutex.Unlock()
+	fake.TransferDeletePathsStub = nil
+	fake.transferDeletePathsReturns = struct {
+		result1 globus.TransferResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferDeletePathsReturnsOnCall(i int, result1 globus.TransferResult, result2 error) {
+	fake.transferDeletePathsMutex.Lock()
+	defer fake.transferDeletePathsMutex.Unlock()
+	fake.TransferDeletePathsStub = nil
+	if fake.transferDeletePathsReturnsOnCall == nil {
+		fake.transferDeletePathsReturnsOnCall = make(map[int]struct {
+			result1 globus.TransferResult
+			result2 error
+		})
+	}
+	fake.transferDeletePathsReturnsOnCall[i] = struct {
+		result1 globus.TransferResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferDeletePostTask(arg1 globus.Delete) (globus.TransferResult, error) {
+	fake.transferDeletePostTaskMutex.Lock()
+	ret, specificReturn := fake.transferDeletePostTaskReturnsOnCall[len(fake.transferDeletePostTaskArgsForCall)]
+	fake.transferDeletePostTaskArgsForCall = append(fake.transferDeletePostTaskArgsForCall, struct {
+		arg1 globus.Delete
+	}{arg1})
+	stub := fake.TransferDeletePostTaskStub
+	fakeReturns := fake.transferDeletePostTaskReturns
+	fake.recordInvocation("TransferDeletePostTask", []interface{}{arg1})
+	fake.transferDeletePostTaskMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferDeletePostTaskCallCount() int {
+	fake.transferDeletePostTaskMutex.RLock()
+	defer fake.transferDeletePostTaskMutex.RUnlock()
+	return len(fake.transferDeletePostTaskArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferDeletePostTaskCalls(stub func(globus.Delete) (globus.TransferResult, error)) {
+	fake.transferDeletePostTaskMutex.Lock()
+	defer fake.transferDeletePostTaskMutex.Unlock()
+	fake.TransferDeletePostTaskStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferDeletePostTaskArgsForCall(i int) globus.Delete {
+	fake.transferDeletePostTaskMutex.RLock()
+	defer fake.transferDeletePostTaskMutex.RUnlock()
+	argsForCall := fake.transferDeletePostTaskArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeTransferAPI) TransferDeletePostTaskReturns(result1 globus.TransferResult, result2 error) {
+	fake.transferDeletePostTaskMutex.Lock()
+	defer fake.transferDeletePostTaskMutex.Unlock()
+	fake.TransferDeletePostTaskStub = nil
+	fake.transferDeletePostTaskReturns = struct {
+		result1 globus.TransferResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferDeletePostTaskReturnsOnCall(i int, result1 globus.TransferResult, result2 error) {
+	fake.transferDeletePostTaskMutex.Lock()
+	defer fake.transferDeletePostTaskMutex.Unlock()
+	fake.TransferDeletePostTaskStub = nil
+	if fake.transferDeletePostTaskReturnsOnCall == nil {
+		fake.transferDeletePostTaskReturnsOnCall = make(map[int]struct {
+			result1 globus.TransferResult
+			result2 error
+		})
+	}
+	fake.transferDeletePostTaskReturnsOnCall[i] = struct {
+		result1 globus.TransferResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferFileList(arg1 string, arg2 string, arg3 string, arg4 string, arg5 []string, arg6 []bool, arg7 bool) (globus.TransferResult, error) {
+	var arg5Copy []string
+	if arg5 != nil {
+		arg5Copy = make([]string, len(arg5))
+		copy(arg5Copy, arg5)
+	}
+	var arg6Copy []bool
+	if arg6 != nil {
+		arg6Copy = make([]bool, len(arg6))
+		copy(arg6Copy, arg6)
+	}
+	fake.transferFileListMutex.Lock()
+	ret, specificReturn := fake.transferFileListReturnsOnCall[len(fake.transferFileListArgsForCall)]
+	fake.transferFileListArgsForCall = append(fake.transferFileListArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 []string
+		arg6 []bool
+		arg7 bool
+	}{arg1, arg2, arg3, arg4, arg5Copy, arg6Copy, arg7})
+	stub := fake.TransferFileListStub
+	fakeReturns := fake.transferFileListReturns
+	fake.recordInvocation("TransferFileList", []interface{}{arg1, arg2, arg3, arg4, arg5Copy, arg6Copy, arg7})
+	fake.transferFileListMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferFileListCallCount() int {
+	fake.transferFileListMutex.RLock()
+	defer fake.transferFileListMutex.RUnlock()
+	return len(fake.transferFileListArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferFileListCalls(stub func(string, string, string, string, []string, []bool, bool) (globus.TransferResult, error)) {
+	fake.transferFileListMutex.Lock()
+	defer fake.transferFileListMutex.Unlock()
+	fake.TransferFileListStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferFileListArgsForCall(i int) (string, string, string, string, []string, []bool, bool) {
+	fake.transferFileListMutex.RLock()
+	defer fake.transferFileListMutex.RUnlock()
+	argsForCall := fake.transferFileListArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6, argsForCall.arg7
+}
+
+func (fake *FakeTransferAPI) TransferFileListReturns(result1 globus.TransferResult, result2 error) {
+	fake.transferFileListMutex.Lock()
+	defer fake.transferFileListMutex.Unlock()
+	fake.TransferFileListStub = nil
+	fake.transferFileListReturns = struct {
+		result1 globus.TransferResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferFileListReturnsOnCall(i int, result1 globus.TransferResult, result2 error) {
+	fake.transferFileListMutex.Lock()
+	defer fake.transferFileListMutex.Unlock()
+	fake.TransferFileListStub = nil
+	if fake.transferFileListReturnsOnCall == nil {
+		fake.transferFileListReturnsOnCall = make(map[int]struct {
+			result1 globus.TransferResult
+			result2 error
+		})
+	}
+	fake.transferFileListReturnsOnCall[i] = struct {
+		result1 globus.TransferResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferFolderSync(arg1 string, arg2 string, arg3 string, arg4 string, arg5 bool) (globus.TransferResult, error) {
+	fake.transferFolderSyncMutex.Lock()
+	ret, specificReturn := fake.transferFolderSyncReturnsOnCall[len(fake.transferFolderSyncArgsForCall)]
+	fake.transferFolderSyncArgsForCall = append(fake.transferFolderSyncArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.TransferFolderSyncStub
+	fakeReturns := fake.transferFolderSyncReturns
+	fake.recordInvocation("TransferFolderSync", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.transferFolderSyncMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferFolderSyncCallCount() int {
+	fake.transferFolderSyncMutex.RLock()
+	defer fake.transferFolderSyncMutex.RUnlock()
+	return len(fake.transferFolderSyncArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferFolderSyncCalls(stub func(string, string, string, string, bool) (globus.TransferResult, error)) {
+	fake.transferFolderSyncMutex.Lock()
+	defer fake.transferFolderSyncMutex.Unlock()
+	fake.TransferFolderSyncStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferFolderSyncArgsForCall(i int) (string, string, string, string, bool) {
+	fake.transferFolderSyncMutex.RLock()
+	defer fake.transferFolderSyncMutex.RUnlock()
+	argsForCall := fake.transferFolderSyncArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeTransferAPI) TransferFolderSyncReturns(result1 globus.TransferResult, result2 error) {
+	fake.transferFolderSyncMutex.Lock()
+	defer fake.transferFolderSyncMutex.Unlock()
+	fake.TransferFolderSyncStub = nil
+	fake.transferFolderSyncReturns = struct {
+		result1 globus.TransferResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferFolderSyncReturnsOnCall(i int, result1 globus.TransferResult, result2 error) {
+	fake.transferFolderSyncMutex.Lock()
+	defer fake.transferFolderSyncMutex.Unlock()
+	fake.TransferFolderSyncStub = nil
+	if fake.transferFolderSyncReturnsOnCall == nil {
+		fake.transferFolderSyncReturnsOnCall = make(map[int]struct {
+			result1 globus.TransferResult
+			result2 error
+		})
+	}
+	fake.transferFolderSyncReturnsOnCall[i] = struct {
+		result1 globus.TransferResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetSubmissionId() (string, error) {
+	fake.transferGetSubmissionIdMutex.Lock()
+	ret, specificReturn := fake.transferGetSubmissionIdReturnsOnCall[len(fake.transferGetSubmissionIdArgsForCall)]
+	fake.transferGetSubmissionIdArgsForCall = append(fake.transferGetSubmissionIdArgsForCall, struct {
+	}{})
+	stub := fake.TransferGetSubmissionIdStub
+	fakeReturns := fake.transferGetSubmissionIdReturns
+	fake.recordInvocation("TransferGetSubmissionId", []interface{}{})
+	fake.transferGetSubmissionIdMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferGetSubmissionIdCallCount() int {
+	fake.transferGetSubmissionIdMutex.RLock()
+	defer fake.transferGetSubmissionIdMutex.RUnlock()
+	return len(fake.transferGetSubmissionIdArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferGetSubmissionIdCalls(stub func() (string, error)) {
+	fake.transferGetSubmissionIdMutex.Lock()
+	defer fake.transferGetSubmissionIdMutex.Unlock()
+	fake.TransferGetSubmissionIdStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferGetSubmissionIdReturns(result1 string, result2 error) {
+	fake.transferGetSubmissionIdMutex.Lock()
+	defer fake.transferGetSubmissionIdMutex.Unlock()
+	fake.TransferGetSubmissionIdStub = nil
+	fake.transferGetSubmissionIdReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetSubmissionIdReturnsOnCall(i int, result1 string, result2 error) {
+	fake.transferGetSubmissionIdMutex.Lock()
+	defer fake.transferGetSubmissionIdMutex.Unlock()
+	fake.TransferGetSubmissionIdStub = nil
+	if fake.transferGetSubmissionIdReturnsOnCall == nil {
+		fake.transferGetSubmissionIdReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.transferGetSubmissionIdReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskByID(arg1 string) (globus.Task, error) {
+	fake.transferGetTaskByIDMutex.Lock()
+	ret, specificReturn := fake.transferGetTaskByIDReturnsOnCall[len(fake.transferGetTaskByIDArgsForCall)]
+	fake.transferGetTaskByIDArgsForCall = append(fake.transferGetTaskByIDArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.TransferGetTaskByIDStub
+	fakeReturns := fake.transferGetTaskByIDReturns
+	fake.recordInvocation("TransferGetTaskByID", []interface{}{arg1})
+	fake.transferGetTaskByIDMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskByIDCallCount() int {
+	fake.transferGetTaskByIDMutex.RLock()
+	defer fake.transferGetTaskByIDMutex.RUnlock()
+	return len(fake.transferGetTaskByIDArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskByIDCalls(stub func(string) (globus.Task, error)) {
+	fake.transferGetTaskByIDMutex.Lock()
+	defer fake.transferGetTaskByIDMutex.Unlock()
+	fake.TransferGetTaskByIDStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskByIDArgsForCall(i int) string {
+	fake.transferGetTaskByIDMutex.RLock()
+	defer fake.transferGetTaskByIDMutex.RUnlock()
+	argsForCall := fake.transferGetTaskByIDArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskByIDReturns(result1 globus.Task, result2 error) {
+	fake.transferGetTaskByIDMutex.Lock()
+	defer fake.transferGetTaskByIDMutex.Unlock()
+	fake.TransferGetTaskByIDStub = nil
+	fake.transferGetTaskByIDReturns = struct {
+		result1 globus.Task
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskByIDReturnsOnCall(i int, result1 globus.Task, result2 error) {
+	fake.transferGetTaskByIDMutex.Lock()
+	defer fake.transferGetTaskByIDMutex.Unlock()
+	fake.TransferGetTaskByIDStub = nil
+	if fake.transferGetTaskByIDReturnsOnCall == nil {
+		fake.transferGetTaskByIDReturnsOnCall = make(map[int]struct {
+			result1 globus.Task
+			result2 error
+		})
+	}
+	fake.transferGetTaskByIDReturnsOnCall[i] = struct {
+		result1 globus.Task
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskEventList(arg1 string, arg2 uint, arg3 uint) (globus.EventList, error) {
+	fake.transferGetTaskEventListMutex.Lock()
+	ret, specificReturn := fake.transferGetTaskEventListReturnsOnCall[len(fake.transferGetTaskEventListArgsForCall)]
+	fake.transferGetTaskEventListArgsForCall = append(fake.transferGetTaskEventListArgsForCall, struct {
+		arg1 string
+		arg2 uint
+		arg3 uint
+	}{arg1, arg2, arg3})
+	stub := fake.TransferGetTaskEventListStub
+	fakeReturns := fake.transferGetTaskEventListReturns
+	fake.recordInvocation("TransferGetTaskEventList", []interface{}{arg1, arg2, arg3})
+	fake.transferGetTaskEventListMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskEventListCallCount() int {
+	fake.transferGetTaskEventListMutex.RLock()
+	defer fake.transferGetTaskEventListMutex.RUnlock()
+	return len(fake.transferGetTaskEventListArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskEventListCalls(stub func(string, uint, uint) (globus.EventList, error)) {
+	fake.transferGetTaskEventListMutex.Lock()
+	defer fake.transferGetTaskEventListMutex.Unlock()
+	fake.TransferGetTaskEventListStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskEventListArgsForCall(i int) (string, uint, uint) {
+	fake.transferGetTaskEventListMutex.RLock()
+	defer fake.transferGetTaskEventListMutex.RUnlock()
+	argsForCall := fake.transferGetTaskEventListArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskEventListReturns(result1 globus.EventList, result2 error) {
+	fake.transferGetTaskEventListMutex.Lock()
+	defer fake.transferGetTaskEventListMutex.Unlock()
+	fake.TransferGetTaskEventListStub = nil
+	fake.transferGetTaskEventListReturns = struct {
+		result1 globus.EventList
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskEventListReturnsOnCall(i int, result1 globus.EventList, result2 error) {
+	fake.transferGetTaskEventListMutex.Lock()
+	defer fake.transferGetTaskEventListMutex.Unlock()
+	fake.TransferGetTaskEventListStub = nil
+	if fake.transferGetTaskEventListReturnsOnCall == nil {
+		fake.transferGetTaskEventListReturnsOnCall = make(map[int]struct {
+			result1 globus.EventList
+			result2 error
+		})
+	}
+	fake.transferGetTaskEventListReturnsOnCall[i] = struct {
+		result1 globus.EventList
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskGroupStatus(arg1 globus.TaskGroup) (globus.TaskGroupStatus, error) {
+	fake.transferGetTaskGroupStatusMutex.Lock()
+	ret, specificReturn := fake.transferGetTaskGroupStatusReturnsOnCall[len(fake.transferGetTaskGroupStatusArgsForCall)]
+	fake.transferGetTaskGroupStatusArgsForCall = append(fake.transferGetTaskGroupStatusArgsForCall, struct {
+		arg1 globus.TaskGroup
+	}{arg1})
+	stub := fake.TransferGetTaskGroupStatusStub
+	fakeReturns := fake.transferGetTaskGroupStatusReturns
+	fake.recordInvocation("TransferGetTaskGroupStatus", []interface{}{arg1})
+	fake.transferGetTaskGroupStatusMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskGroupStatusCallCount() int {
+	fake.transferGetTaskGroupStatusMutex.RLock()
+	defer fake.transferGetTaskGroupStatusMutex.RUnlock()
+	return len(fake.transferGetTaskGroupStatusArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskGroupStatusCalls(stub func(globus.TaskGroup) (globus.TaskGroupStatus, error)) {
+	fake.transferGetTaskGroupStatusMutex.Lock()
+	defer fake.transferGetTaskGroupStatusMutex.Unlock()
+	fake.TransferGetTaskGroupStatusStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskGroupStatusArgsForCall(i int) globus.TaskGroup {
+	fake.transferGetTaskGroupStatusMutex.RLock()
+	defer fake.transferGetTaskGroupStatusMutex.RUnlock()
+	argsForCall := fake.transferGetTaskGroupStatusArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskGroupStatusReturns(result1 globus.TaskGroupStatus, result2 error) {
+	fake.transferGetTaskGroupStatusMutex.Lock()
+	defer fake.transferGetTaskGroupStatusMutex.Unlock()
+	fake.TransferGetTaskGroupStatusStub = nil
+	fake.transferGetTaskGroupStatusReturns = struct {
+		result1 globus.TaskGroupStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskGroupStatusReturnsOnCall(i int, result1 globus.TaskGroupStatus, result2 error) {
+	fake.transferGetTaskGroupStatusMutex.Lock()
+	defer fake.transferGetTaskGroupStatusMutex.Unlock()
+	fake.TransferGetTaskGroupStatusStub = nil
+	if fake.transferGetTaskGroupStatusReturnsOnCall == nil {
+		fake.transferGetTaskGroupStatusReturnsOnCall = make(map[int]struct {
+			result1 globus.TaskGroupStatus
+			result2 error
+		})
+	}
+	fake.transferGetTaskGroupStatusReturnsOnCall[i] = struct {
+		result1 globus.TaskGroupStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskList(arg1 uint, arg2 uint) (globus.TaskList, error) {
+	fake.transferGetTaskListMutex.Lock()
+	ret, specificReturn := fake.transferGetTaskListReturnsOnCall[len(fake.transferGetTaskListArgsForCall)]
+	fake.transferGetTaskListArgsForCall = append(fake.transferGetTaskListArgsForCall, struct {
+		arg1 uint
+		arg2 uint
+	}{arg1, arg2})
+	stub := fake.TransferGetTaskListStub
+	fakeReturns := fake.transferGetTaskListReturns
+	fake.recordInvocation("TransferGetTaskList", []interface{}{arg1, arg2})
+	fake.transferGetTaskListMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskListCallCount() int {
+	fake.transferGetTaskListMutex.RLock()
+	defer fake.transferGetTaskListMutex.RUnlock()
+	return len(fake.transferGetTaskListArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskListCalls(stub func(uint, uint) (globus.TaskList, error)) {
+	fake.transferGetTaskListMutex.Lock()
+	defer fake.transferGetTaskListMutex.Unlock()
+	fake.TransferGetTaskListStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskListArgsForCall(i int) (uint, uint) {
+	fake.transferGetTaskListMutex.RLock()
+	defer fake.transferGetTaskListMutex.RUnlock()
+	argsForCall := fake.transferGetTaskListArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskListReturns(result1 globus.TaskList, result2 error) {
+	fake.transferGetTaskListMutex.Lock()
+	defer fake.transferGetTaskListMutex.Unlock()
+	fake.TransferGetTaskListStub = nil
+	fake.transferGetTaskListReturns = struct {
+		result1 globus.TaskList
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskListReturnsOnCall(i int, result1 globus.TaskList, result2 error) {
+	fake.transferGetTaskListMutex.Lock()
+	defer fake.transferGetTaskListMutex.Unlock()
+	fake.TransferGetTaskListStub = nil
+	if fake.transferGetTaskListReturnsOnCall == nil {
+		fake.transferGetTaskListReturnsOnCall = make(map[int]struct {
+			result1 globus.TaskList
+			result2 error
+		})
+	}
+	fake.transferGetTaskListReturnsOnCall[i] = struct {
+		result1 globus.TaskList
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskPauseInfo(arg1 string) (globus.PauseInfoLimited, error) {
+	fake.transferGetTaskPauseInfoMutex.Lock()
+	ret, specificReturn := fake.transferGetTaskPauseInfoReturnsOnCall[len(fake.transferGetTaskPauseInfoArgsForCall)]
+	fake.transferGetTaskPauseInfoArgsForCall = append(fake.transferGetTaskPauseInfoArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.TransferGetTaskPauseInfoStub
+	fakeReturns := fake.transferGetTaskPauseInfoReturns
+	fake.recordInvocation("TransferGetTaskPauseInfo", []interface{}{arg1})
+	fake.transferGetTaskPauseInfoMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskPauseInfoCallCount() int {
+	fake.transferGetTaskPauseInfoMutex.RLock()
+	defer fake.transferGetTaskPauseInfoMutex.RUnlock()
+	return len(fake.transferGetTaskPauseInfoArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskPauseInfoCalls(stub func(string) (globus.PauseInfoLimited, error)) {
+	fake.transferGetTaskPauseInfoMutex.Lock()
+	defer fake.transferGetTaskPauseInfoMutex.Unlock()
+	fake.TransferGetTaskPauseInfoStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskPauseInfoArgsForCall(i int) string {
+	fake.transferGetTaskPauseInfoMutex.RLock()
+	defer fake.transferGetTaskPauseInfoMutex.RUnlock()
+	argsForCall := fake.transferGetTaskPauseInfoArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskPauseInfoReturns(result1 globus.PauseInfoLimited, result2 error) {
+	fake.transferGetTaskPauseInfoMutex.Lock()
+	defer fake.transferGetTaskPauseInfoMutex.Unlock()
+	fake.TransferGetTaskPauseInfoStub = nil
+	fake.transferGetTaskPauseInfoReturns = struct {
+		result1 globus.PauseInfoLimited
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskPauseInfoReturnsOnCall(i int, result1 globus.PauseInfoLimited, result2 error) {
+	fake.transferGetTaskPauseInfoMutex.Lock()
+	defer fake.transferGetTaskPauseInfoMutex.Unlock()
+	fake.TransferGetTaskPauseInfoStub = nil
+	if fake.transferGetTaskPauseInfoReturnsOnCall == nil {
+		fake.transferGetTaskPauseInfoReturnsOnCall = make(map[int]struct {
+			result1 globus.PauseInfoLimited
+			result2 error
+		})
+	}
+	fake.transferGetTaskPauseInfoReturnsOnCall[i] = struct {
+		result1 globus.PauseInfoLimited
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskSkippedErrors(arg1 string, arg2 uint) (globus.SkippedErrors, error) {
+	fake.transferGetTaskSkippedErrorsMutex.Lock()
+	ret, specificReturn := fake.transferGetTaskSkippedErrorsReturnsOnCall[len(fake.transferGetTaskSkippedErrorsArgsForCall)]
+	fake.transferGetTaskSkippedErrorsArgsForCall = append(fake.transferGetTaskSkippedErrorsArgsForCall, struct {
+		arg1 string
+		arg2 uint
+	}{arg1, arg2})
+	stub := fake.TransferGetTaskSkippedErrorsStub
+	fakeReturns := fake.transferGetTaskSkippedErrorsReturns
+	fake.recordInvocation("TransferGetTaskSkippedErrors", []interface{}{arg1, arg2})
+	fake.transferGetTaskSkippedErrorsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskSkippedErrorsCallCount() int {
+	fake.transferGetTaskSkippedErrorsMutex.RLock()
+	defer fake.transferGetTaskSkippedErrorsMutex.RUnlock()
+	return len(fake.transferGetTaskSkippedErrorsArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskSkippedErrorsCalls(stub func(string, uint) (globus.SkippedErrors, error)) {
+	fake.transferGetTaskSkippedErrorsMutex.Lock()
+	defer fake.transferGetTaskSkippedErrorsMutex.Unlock()
+	fake.TransferGetTaskSkippedErrorsStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskSkippedErrorsArgsForCall(i int) (string, uint) {
+	fake.transferGetTaskSkippedErrorsMutex.RLock()
+	defer fake.transferGetTaskSkippedErrorsMutex.RUnlock()
+	argsForCall := fake.transferGetTaskSkippedErrorsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskSkippedErrorsReturns(result1 globus.SkippedErrors, result2 error) {
+	fake.transferGetTaskSkippedErrorsMutex.Lock()
+	defer fake.transferGetTaskSkippedErrorsMutex.Unlock()
+	fake.TransferGetTaskSkippedErrorsStub = nil
+	fake.transferGetTaskSkippedErrorsReturns = struct {
+		result1 globus.SkippedErrors
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskSkippedErrorsReturnsOnCall(i int, result1 globus.SkippedErrors, result2 error) {
+	fake.transferGetTaskSkippedErrorsMutex.Lock()
+	defer fake.transferGetTaskSkippedErrorsMutex.Unlock()
+	fake.TransferGetTaskSkippedErrorsStub = nil
+	if fake.transferGetTaskSkippedErrorsReturnsOnCall == nil {
+		fake.transferGetTaskSkippedErrorsReturnsOnCall = make(map[int]struct {
+			result1 globus.SkippedErrors
+			result2 error
+		})
+	}
+	fake.transferGetTaskSkippedErrorsReturnsOnCall[i] = struct {
+		result1 globus.SkippedErrors
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskSuccessfulTransfers(arg1 string, arg2 uint) (globus.SuccessfulTransfers, error) {
+	fake.transferGetTaskSuccessfulTransfersMutex.Lock()
+	ret, specificReturn := fake.transferGetTaskSuccessfulTransfersReturnsOnCall[len(fake.transferGetTaskSuccessfulTransfersArgsForCall)]
+	fake.transferGetTaskSuccessfulTransfersArgsForCall = append(fake.transferGetTaskSuccessfulTransfersArgsForCall, struct {
+		arg1 string
+		arg2 uint
+	}{arg1, arg2})
+	stub := fake.TransferGetTaskSuccessfulTransfersStub
+	fakeReturns := fake.transferGetTaskSuccessfulTransfersReturns
+	fake.recordInvocation("TransferGetTaskSuccessfulTransfers", []interface{}{arg1, arg2})
+	fake.transferGetTaskSuccessfulTransfersMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskSuccessfulTransfersCallCount() int {
+	fake.transferGetTaskSuccessfulTransfersMutex.RLock()
+	defer fake.transferGetTaskSuccessfulTransfersMutex.RUnlock()
+	return len(fake.transferGetTaskSuccessfulTransfersArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskSuccessfulTransfersCalls(stub func(string, uint) (globus.SuccessfulTransfers, error)) {
+	fake.transferGetTaskSuccessfulTransfersMutex.Lock()
+	defer fake.transferGetTaskSuccessfulTransfersMutex.Unlock()
+	fake.TransferGetTaskSuccessfulTransfersStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskSuccessfulTransfersArgsForCall(i int) (string, uint) {
+	fake.transferGetTaskSuccessfulTransfersMutex.RLock()
+	defer fake.transferGetTaskSuccessfulTransfersMutex.RUnlock()
+	argsForCall := fake.transferGetTaskSuccessfulTransfersArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskSuccessfulTransfersReturns(result1 globus.SuccessfulTransfers, result2 error) {
+	fake.transferGetTaskSuccessfulTransfersMutex.Lock()
+	defer fake.transferGetTaskSuccessfulTransfersMutex.Unlock()
+	fake.TransferGetTaskSuccessfulTransfersStub = nil
+	fake.transferGetTaskSuccessfulTransfersReturns = struct {
+		result1 globus.SuccessfulTransfers
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferGetTaskSuccessfulTransfersReturnsOnCall(i int, result1 globus.SuccessfulTransfers, result2 error) {
+	fake.transferGetTaskSuccessfulTransfersMutex.Lock()
+	defer fake.transferGetTaskSuccessfulTransfersMutex.Unlock()
+	fake.TransferGetTaskSuccessfulTransfersStub = nil
+	if fake.transferGetTaskSuccessfulTransfersReturnsOnCall == nil {
+		fake.transferGetTaskSuccessfulTransfersReturnsOnCall = make(map[int]struct {
+			result1 globus.SuccessfulTransfers
+			result2 error
+		})
+	}
+	fake.transferGetTaskSuccessfulTransfersReturnsOnCall[i] = struct {
+		result1 globus.SuccessfulTransfers
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferPostTask(arg1 globus.Transfer) (globus.TransferResult, error) {
+	fake.transferPostTaskMutex.Lock()
+	ret, specificReturn := fake.transferPostTaskReturnsOnCall[len(fake.transferPostTaskArgsForCall)]
+	fake.transferPostTaskArgsForCall = append(fake.transferPostTaskArgsForCall, struct {
+		arg1 globus.Transfer
+	}{arg1})
+	stub := fake.TransferPostTaskStub
+	fakeReturns := fake.transferPostTaskReturns
+	fake.recordInvocation("TransferPostTask", []interface{}{arg1})
+	fake.transferPostTaskMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskCallCount() int {
+	fake.transferPostTaskMutex.RLock()
+	defer fake.transferPostTaskMutex.RUnlock()
+	return len(fake.transferPostTaskArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskCalls(stub func(globus.Transfer) (globus.TransferResult, error)) {
+	fake.transferPostTaskMutex.Lock()
+	defer fake.transferPostTaskMutex.Unlock()
+	fake.TransferPostTaskStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskArgsForCall(i int) globus.Transfer {
+	fake.transferPostTaskMutex.RLock()
+	defer fake.transferPostTaskMutex.RUnlock()
+	argsForCall := fake.transferPostTaskArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskReturns(result1 globus.TransferResult, result2 error) {
+	fake.transferPostTaskMutex.Lock()
+	defer fake.transferPostTaskMutex.Unlock()
+	fake.TransferPostTaskStub = nil
+	fake.transferPostTaskReturns = struct {
+		result1 globus.TransferResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskReturnsOnCall(i int, result1 globus.TransferResult, result2 error) {
+	fake.transferPostTaskMutex.Lock()
+	defer fake.transferPostTaskMutex.Unlock()
+	fake.TransferPostTaskStub = nil
+	if fake.transferPostTaskReturnsOnCall == nil {
+		fake.transferPostTaskReturnsOnCall = make(map[int]struct {
+			result1 globus.TransferResult
+			result2 error
+		})
+	}
+	fake.transferPostTaskReturnsOnCall[i] = struct {
+		result1 globus.TransferResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskBatched(arg1 globus.Transfer, arg2 globus.BatchOptions) (globus.TaskGroup, error) {
+	fake.transferPostTaskBatchedMutex.Lock()
+	ret, specificReturn := fake.transferPostTaskBatchedReturnsOnCall[len(fake.transferPostTaskBatchedArgsForCall)]
+	fake.transferPostTaskBatchedArgsForCall = append(fake.transferPostTaskBatchedArgsForCall, struct {
+		arg1 globus.Transfer
+		arg2 globus.BatchOptions
+	}{arg1, arg2})
+	stub := fake.TransferPostTaskBatchedStub
+	fakeReturns := fake.transferPostTaskBatchedReturns
+	fake.recordInvocation("TransferPostTaskBatched", []interface{}{arg1, arg2})
+	fake.transferPostTaskBatchedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskBatchedCallCount() int {
+	fake.transferPostTaskBatchedMutex.RLock()
+	defer fake.transferPostTaskBatchedMutex.RUnlock()
+	return len(fake.transferPostTaskBatchedArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskBatchedCalls(stub func(globus.Transfer, globus.BatchOptions) (globus.TaskGroup, error)) {
+	fake.transferPostTaskBatchedMutex.Lock()
+	defer fake.transferPostTaskBatchedMutex.Unlock()
+	fake.TransferPostTaskBatchedStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskBatchedArgsForCall(i int) (globus.Transfer, globus.BatchOptions) {
+	fake.transferPostTaskBatchedMutex.RLock()
+	defer fake.transferPostTaskBatchedMutex.RUnlock()
+	argsForCall := fake.transferPostTaskBatchedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskBatchedReturns(result1 globus.TaskGroup, result2 error) {
+	fake.transferPostTaskBatchedMutex.Lock()
+	defer fake.transferPostTaskBatchedMutex.Unlock()
+	fake.TransferPostTaskBatchedStub = nil
+	fake.transferPostTaskBatchedReturns = struct {
+		result1 globus.TaskGroup
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskBatchedReturnsOnCall(i int, result1 globus.TaskGroup, result2 error) {
+	fake.transferPostTaskBatchedMutex.Lock()
+	defer fake.transferPostTaskBatchedMutex.Unlock()
+	fake.TransferPostTaskBatchedStub = nil
+	if fake.transferPostTaskBatchedReturnsOnCall == nil {
+		fake.transferPostTaskBatchedReturnsOnCall = make(map[int]struct {
+			result1 globus.TaskGroup
+			result2 error
+		})
+	}
+	fake.transferPostTaskBatchedReturnsOnCall[i] = struct {
+		result1 globus.TaskGroup
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskTracked(arg1 globus.TaskStore, arg2 string, arg3 globus.Transfer) (globus.TaskRecord, globus.TransferResult, error) {
+	fake.transferPostTaskTrackedMutex.Lock()
+	ret, specificReturn := fake.transferPostTaskTrackedReturnsOnCall[len(fake.transferPostTaskTrackedArgsForCall)]
+	fake.transferPostTaskTrackedArgsForCall = append(fake.transferPostTaskTrackedArgsForCall, struct {
+		arg1 globus.TaskStore
+		arg2 string
+		arg3 globus.Transfer
+	}{arg1, arg2, arg3})
+	stub := fake.TransferPostTaskTrackedStub
+	fakeReturns := fake.transferPostTaskTrackedReturns
+	fake.recordInvocation("TransferPostTaskTracked", []interface{}{arg1, arg2, arg3})
+	fake.transferPostTaskTrackedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskTrackedCallCount() int {
+	fake.transferPostTaskTrackedMutex.RLock()
+	defer fake.transferPostTaskTrackedMutex.RUnlock()
+	return len(fake.transferPostTaskTrackedArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskTrackedCalls(stub func(globus.TaskStore, string, globus.Transfer) (globus.TaskRecord, globus.TransferResult, error)) {
+	fake.transferPostTaskTrackedMutex.Lock()
+	defer fake.transferPostTaskTrackedMutex.Unlock()
+	fake.TransferPostTaskTrackedStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskTrackedArgsForCall(i int) (globus.TaskStore, string, globus.Transfer) {
+	fake.transferPostTaskTrackedMutex.RLock()
+	defer fake.transferPostTaskTrackedMutex.RUnlock()
+	argsForCall := fake.transferPostTaskTrackedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskTrackedReturns(result1 globus.TaskRecord, result2 globus.TransferResult, result3 error) {
+	fake.transferPostTaskTrackedMutex.Lock()
+	defer fake.transferPostTaskTrackedMutex.Unlock()
+	fake.TransferPostTaskTrackedStub = nil
+	fake.transferPostTaskTrackedReturns = struct {
+		result1 globus.TaskRecord
+		result2 globus.TransferResult
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeTransferAPI) TransferPostTaskTrackedReturnsOnCall(i int, result1 globus.TaskRecord, result2 globus.TransferResult, result3 error) {
+	fake.transferPostTaskTrackedMutex.Lock()
+	defer fake.transferPostTaskTrackedMutex.Unlock()
+	fake.TransferPostTaskTrackedStub = nil
+	if fake.transferPostTaskTrackedReturnsOnCall == nil {
+		fake.transferPostTaskTrackedReturnsOnCall = make(map[int]struct {
+			result1 globus.TaskRecord
+			result2 globus.TransferResult
+			result3 error
+		})
+	}
+	fake.transferPostTaskTrackedReturnsOnCall[i] = struct {
+		result1 globus.TaskRecord
+		result2 globus.TransferResult
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeTransferAPI) TransferRemoveTaskByID(arg1 string) (globus.Result, error) {
+	fake.transferRemoveTaskByIDMutex.Lock()
+	ret, specificReturn := fake.transferRemoveTaskByIDReturnsOnCall[len(fake.transferRemoveTaskByIDArgsForCall)]
+	fake.transferRemoveTaskByIDArgsForCall = append(fake.transferRemoveTaskByIDArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.TransferRemoveTaskByIDStub
+	fakeReturns := fake.transferRemoveTaskByIDReturns
+	fake.recordInvocation("TransferRemoveTaskByID", []interface{}{arg1})
+	fake.transferRemoveTaskByIDMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeTransferAPI) TransferRemoveTaskByIDCallCount() int {
+	fake.transferRemoveTaskByIDMutex.RLock()
+	defer fake.transferRemoveTaskByIDMutex.RUnlock()
+	return len(fake.transferRemoveTaskByIDArgsForCall)
+}
+
+func (fake *FakeTransferAPI) TransferRemoveTaskByIDCalls(stub func(string) (globus.Result, error)) {
+	fake.transferRemoveTaskByIDMutex.Lock()
+	defer fake.transferRemoveTaskByIDMutex.Unlock()
+	fake.TransferRemoveTaskByIDStub = stub
+}
+
+func (fake *FakeTransferAPI) TransferRemoveTaskByIDArgsForCall(i int) string {
+	fake.transferRemoveTaskByIDMutex.RLock()
+	defer fake.transferRemoveTaskByIDMutex.RUnlock()
+	argsForCall := fake.transferRemoveTaskByIDArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeTransferAPI) TransferRemoveTaskByIDReturns(result1 globus.Result, result2 error) {
+	fake.transferRemoveTaskByIDMutex.Lock()
+	defer fake.transferRemoveTaskByIDMutex.Unlock()
+	fake.TransferRemoveTaskByIDStub = nil
+	fake.transferRemoveTaskByIDReturns = struct {
+		result1 globus.Result
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) TransferRemoveTaskByIDReturnsOnCall(i int, result1 globus.Result, result2 error) {
+	fake.transferRemoveTaskByIDMutex.Lock()
+	defer fake.transferRemoveTaskByIDMutex.Unlock()
+	fake.TransferRemoveTaskByIDStub = nil
+	if fake.transferRemoveTaskByIDReturnsOnCall == nil {
+		fake.transferRemoveTaskByIDReturnsOnCall = make(map[int]struct {
+			result1 globus.Result
+			result2 error
+		})
+	}
+	fake.transferRemoveTaskByIDReturnsOnCall[i] = struct {
+		result1 globus.Result
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTransferAPI) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.transferCancelTaskByIDMutex.RLock()
+	defer fake.transferCancelTaskByIDMutex.RUnlock()
+	fake.transferCopyFileMutex.RLock()
+	defer fake.transferCopyFileMutex.RUnlock()
+	fake.transferDeletePathsMutex.RLock()
+	defer fake.transferDeletePathsMutex.RUnlock()
+	fake.transferDeletePostTaskMutex.RLock()
+	defer fake.transferDeletePostTaskMutex.RUnlock()
+	fake.transferFileListMutex.RLock()
+	defer fake.transferFileListMutex.RUnlock()
+	fake.transferFolderSyncMutex.RLock()
+	defer fake.transferFolderSyncMutex.RUnlock()
+	fake.transferGetSubmissionIdMutex.RLock()
+	defer fake.transferGetSubmissionIdMutex.RUnlock()
+	fake.transferGetTaskByIDMutex.RLock()
+	defer fake.transferGetTaskByIDMutex.RUnlock()
+	fake.transferGetTaskEventListMutex.RLock()
+	defer fake.transferGetTaskEventListMutex.RUnlock()
+	fake.transferGetTaskGroupStatusMutex.RLock()
+	defer fake.transferGetTaskGroupStatusMutex.RUnlock()
+	fake.transferGetTaskListMutex.RLock()
+	defer fake.transferGetTaskListMutex.RUnlock()
+	fake.transferGetTaskPauseInfoMutex.RLock()
+	defer fake.transferGetTaskPauseInfoMutex.RUnlock()
+	fake.transferGetTaskSkippedErrorsMutex.RLock()
+	defer fake.transferGetTaskSkippedErrorsMutex.RUnlock()
+	fake.transferGetTaskSuccessfulTransfersMutex.RLock()
+	defer fake.transferGetTaskSuccessfulTransfersMutex.RUnlock()
+	fake.transferPostTaskMutex.RLock()
+	defer fake.transferPostTaskMutex.RUnlock()
+	fake.transferPostTaskBatchedMutex.RLock()
+	defer fake.transferPostTaskBatchedMutex.RUnlock()
+	fake.transferPostTaskTrackedMutex.RLock()
+	defer fake.transferPostTaskTrackedMutex.RUnlock()
+	fake.transferRemoveTaskByIDMutex.RLock()
+	defer fake.transferRemoveTaskByIDMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeTransferAPI) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ globus.TransferAPI = new(FakeTransferAPI)
@@ -0,0 +1,821 @@
+package globustest
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+)
+
+// the identity that logs in through the authorization endpoint of an AuthServer
+type AuthUser struct {
+	IdentityId string
+	Username   string
+	Name       string
+	Email      string
+}
+
+// a token issued by an AuthServer
+type IssuedToken struct {
+	AccessToken    string
+	RefreshToken   string // empty if offline access wasn't requested
+	ClientId       string
+	IdentityId     string
+	ResourceServer string
+	Scopes         []string
+	Expiry         time.Time
+}
+
+// the grant behind a refresh token
+type refreshGrant struct {
+	clientId       string
+	identityId     string
+	resourceServer string
+	scopes         []string
+}
+
+type authCode struct {
+	clientId      string
+	redirectURI   string
+	scopes        []string
+	challenge     string
+	challengeType string
+	offline       bool
+}
+
+// Response of the token endpoint. Globus returns one token per resource server, the additional
+// ones in other_tokens.
+type tokenResponse struct {
+	AccessToken    string          `json:"access_token"`
+	TokenType      string          `json:"token_type"`
+	ExpiresIn      int             `json:"expires_in"`
+	Scope          string          `json:"scope"`
+	ResourceServer string          `json:"resource_server"`
+	RefreshToken   string          `json:"refresh_token,omitempty"`
+	State          string          `json:"state,omitempty"`
+	IdToken        string          `json:"id_token,omitempty"`
+	OtherTokens    []tokenResponse `json:"other_tokens,omitempty"`
+}
+
+// AuthServer is an in-process emulator of the Globus Auth token, authorization, introspection,
+// revocation, userinfo and identities endpoints. It supports the client credentials, authorization code (with PKCE), refresh
+// token and dependent token grants and, like Globus Auth, issues one token per resource server,
+// plus a signed id token if the "openid" scope is requested. The auth helpers of the library use
+// it when called with the context returned by Context:
+//
+//	srv := globustest.NewAuthServer()
+//	defer srv.Close()
+//	srv.AddClient("client", "secret")
+//	client, err := globus.AuthCreateServiceClient(srv.Context(ctx), "client", "secret", scopes)
+//
+// The authorization endpoint doesn't show a login page, it logs in User right away and redirects
+// back with a code. Use Approve to run it without following redirects.
+type AuthServer struct {
+	*httptest.Server
+
+	// the identity logged in by the authorization endpoint
+	User AuthUser
+	// lifetime of issued access tokens (default: 48 hours, like Globus Auth)
+	TokenLifetime time.Duration
+	// issue a new refresh token on every refresh and invalidate the old one
+	RotateRefreshTokens bool
+	// makes the authorization endpoint answer as if the user declined the consent
+	DenyConsent bool
+
+	mu              sync.Mutex
+	counter         int
+	clients         map[string]string // client id -> secret, empty for public clients
+	codes           map[string]*authCode
+	tokens          map[string]*IssuedToken // access token -> token
+	refreshTokens   map[string]*refreshGrant
+	consentRequired map[string]bool            // scopes that need a consent of the identity
+	consents        map[string]map[string]bool // identity id -> consented scopes
+	signingKey      *rsa.PrivateKey            // generated when the first id token is signed
+	signingKeyId    string
+	identities      []globus.Identity // known to the identities API besides User
+}
+
+func NewAuthServer() *AuthServer {
+	s := &AuthServer{
+		User: AuthUser{
+			IdentityId: "6c2e5f8b-0000-4000-8000-000000000002",
+			Username:   "user@example.org",
+			Name:       "Test User",
+			Email:      "user@example.org",
+		},
+		TokenLifetime:   48 * time.Hour,
+		clients:         map[string]string{},
+		codes:           map[string]*authCode{},
+		tokens:          map[string]*IssuedToken{},
+		refreshTokens:   map[string]*refreshGrant{},
+		consentRequired: map[string]bool{},
+		consents:        map[string]map[string]bool{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// the base url of the emulated Globus Auth API, to be used with globus.AuthContextWithBaseUrl
+func (s *AuthServer) BaseURL() string {
+	return s.URL + "/v2"
+}
+
+// returns a context that makes the auth helpers of the library use the server
+func (s *AuthServer) Context(ctx context.Context) context.Context {
+	return globus.AuthContextWithBaseUrl(ctx, s.BaseURL())
+}
+
+// registers a client, an empty secret registers a public (native app) client that has to use PKCE
+func (s *AuthServer) AddClient(clientId string, clientSecret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[clientId] = clientSecret
+}
+
+// Makes the given scopes require a consent of the identity. Authorization code grants include the
+// consent of the user, client credentials and refresh token grants for scopes without a consent
+// fail with a "consent_required" error.
+func (s *AuthServer) RequireConsent(scopes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, scope := range scopes {
+		s.consentRequired[scope] = true
+	}
+}
+
+// records a consent of an identity (e.g. a client's "<client id>@clients.auth.globus.org") for the given scopes
+func (s *AuthServer) GrantConsent(identityId string, scopes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grantConsent(identityId, scopes)
+}
+
+// makes an identity known to the identities API, in addition to User
+func (s *AuthServer) AddIdentity(identity globus.Identity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.identities = append(s.identities, identity)
+}
+
+// returns the token issued for an access token, if it's known (revoked tokens aren't)
+func (s *AuthServer) Token(accessToken string) (IssuedToken, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[accessToken]
+	if !ok {
+		return IssuedToken{}, false
+	}
+	return *t, true
+}
+
+// returns all issued access tokens, oldest first
+func (s *AuthServer) Tokens() []IssuedToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tokens []IssuedToken
+	for _, t := range s.tokens {
+		tokens = append(tokens, *t)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].AccessToken < tokens[j].AccessToken
+	})
+	return tokens
+}
+
+// Runs the authorization endpoint for an authorization url (e.g. from oauth2.Config.AuthCodeURL)
+// and returns the url it redirects to, which contains the code and state, or an error parameter.
+func (s *AuthServer) Approve(authURL string) (redirect *url.URL, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authorize(u.Query())
+}
+
+// Convenience function for the authorization code flow: approves the authorization url and
+// returns the code from the redirect.
+func (s *AuthServer) ApproveCode(authURL string) (code string, err error) {
+	redirect, err := s.Approve(authURL)
+	if err != nil {
+		return "", err
+	}
+	if e := redirect.Query().Get("error"); e != "" {
+		return "", fmt.Errorf("authorization failed: %s", e)
+	}
+	return redirect.Query().Get("code"), nil
+}
+
+func (s *AuthServer) nextToken(kind string) string {
+	s.counter++
+	return fmt.Sprintf("fake-%s-%06d", kind, s.counter)
+}
+
+func (s *AuthServer) grantConsent(identityId string, scopes []string) {
+	if s.consents[identityId] == nil {
+		s.consents[identityId] = map[string]bool{}
+	}
+	for _, scope := range scopes {
+		s.consents[identityId][scope] = true
+	}
+}
+
+// returns the scopes an identity lacks a required consent for
+func (s *AuthServer) missingConsents(identityId string, scopes []string) (missing []string) {
+	for _, scope := range scopes {
+		if s.consentRequired[scope] && !s.consents[identityId][scope] {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+func (s *AuthServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strings.TrimSuffix(r.URL.Path, "/") {
+	case "/v2/oauth2/authorize":
+		redirect, err := s.authorize(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	case "/v2/oauth2/token":
+		if r.Method != http.MethodPost {
+			writeOauthError(w, http.StatusMethodNotAllowed, "invalid_request", "the token endpoint only accepts POST")
+			return
+		}
+		s.handleToken(w, r)
+	case "/jwk.json":
+		s.handleJWKS(w)
+	case "/v2/oauth2/userinfo":
+		s.handleUserInfo(w, r)
+	case "/v2/api/identities":
+		s.handleIdentities(w, r)
+	case "/v2/oauth2/token/revoke":
+		if r.Method != http.MethodPost {
+			writeOauthError(w, http.StatusMethodNotAllowed, "invalid_request", "the revocation endpoint only accepts POST")
+			return
+		}
+		s.handleRevoke(w, r)
+	case "/v2/oauth2/token/introspect":
+		if r.Method != http.MethodPost {
+			writeOauthError(w, http.StatusMethodNotAllowed, "invalid_request", "the introspection endpoint only accepts POST")
+			return
+		}
+		s.handleIntrospect(w, r)
+	default:
+		writeOauthError(w, http.StatusNotFound, "not_found", "No such resource: "+r.URL.Path)
+	}
+}
+
+// Revokes an access token or a refresh token together with the access tokens issued with it.
+// Like Globus Auth, unknown tokens and tokens of other clients are silently ignored.
+func (s *AuthServer) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOauthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	clientId, ok := s.authenticateClient(r)
+	if !ok {
+		writeOauthError(w, http.StatusUnauthorized, "invalid_client", "unknown client or wrong secret")
+		return
+	}
+
+	value := r.PostForm.Get("token")
+	if token, ok := s.tokens[value]; ok && token.ClientId == clientId {
+		delete(s.tokens, value)
+	}
+	if grant, ok := s.refreshTokens[value]; ok && grant.clientId == clientId {
+		delete(s.refreshTokens, value)
+		for access, token := range s.tokens {
+			if token.RefreshToken == value {
+				delete(s.tokens, access)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"active": false})
+}
+
+// Answers an introspection request. Like Globus Auth, only the resource server and the client
+// the token was issued to can see its claims, for other clients it's inactive.
+func (s *AuthServer) handleIntrospect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOauthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	clientId, ok := s.authenticateClient(r)
+	if !ok {
+		writeOauthError(w, http.StatusUnauthorized, "invalid_client", "unknown client or wrong secret")
+		return
+	}
+
+	token, ok := s.tokens[r.PostForm.Get("token")]
+	if !ok || time.Now().After(token.Expiry) || (clientId != token.ResourceServer && clientId != token.ClientId) {
+		writeJSON(w, http.StatusOK, globus.TokenIntrospection{Active: false})
+		return
+	}
+	introspection := globus.TokenIntrospection{
+		Active:   true,
+		Scope:    strings.Join(token.Scopes, " "),
+		ClientId: token.ClientId,
+		Sub:      token.IdentityId,
+		Aud:      []string{token.ResourceServer},
+		Iss:      "https://auth.globus.org",
+		Exp:      token.Expiry.Unix(),
+		Iat:      token.Expiry.Add(-s.TokenLifetime).Unix(),
+		Nbf:      token.Expiry.Add(-s.TokenLifetime).Unix(),
+	}
+	if token.IdentityId == s.User.IdentityId {
+		introspection.Username = s.User.Username
+		introspection.Name = s.User.Name
+		introspection.Email = s.User.Email
+	}
+	if strings.Contains(r.PostForm.Get("include"), "identity_set") {
+		introspection.IdentitySet = []string{token.IdentityId}
+	}
+	writeJSON(w, http.StatusOK, introspection)
+}
+
+// Logs the user in, records their consent and returns the redirect with a new authorization code.
+// Errors concerning the client or redirect uri are returned, as there's nowhere to redirect to.
+func (s *AuthServer) authorize(params url.Values) (*url.URL, error) {
+	clientId := params.Get("client_id")
+	if _, ok := s.clients[clientId]; !ok {
+		return nil, fmt.Errorf("unknown client '%s'", clientId)
+	}
+	redirect, err := url.Parse(params.Get("redirect_uri"))
+	if err != nil || params.Get("redirect_uri") == "" {
+		return nil, fmt.Errorf("invalid redirect uri '%s'", params.Get("redirect_uri"))
+	}
+
+	query := redirect.Query()
+	if state := params.Get("state"); state != "" {
+		query.Set("state", state)
+	}
+	switch {
+	case params.Get("response_type") != "code":
+		query.Set("error", "unsupported_response_type")
+	case s.clients[clientId] == "" && params.Get("code_challenge") == "":
+		query.Set("error", "invalid_request")
+		query.Set("error_description", "public clients must use PKCE")
+	case s.DenyConsent:
+		query.Set("error", "access_denied")
+	default:
+		scopes := splitScopes(params.Get("scope"))
+		s.grantConsent(s.User.IdentityId, scopes)
+		code := s.nextToken("code")
+		s.codes[code] = &authCode{
+			clientId:      clientId,
+			redirectURI:   params.Get("redirect_uri"),
+			scopes:        scopes,
+			challenge:     params.Get("code_challenge"),
+			challengeType: params.Get("code_challenge_method"),
+			offline:       params.Get("access_type") == "offline",
+		}
+		query.Set("code", code)
+	}
+	redirect.RawQuery = query.Encode()
+	return redirect, nil
+}
+
+func (s *AuthServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOauthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	clientId, ok := s.authenticateClient(r)
+	if !ok {
+		writeOauthError(w, http.StatusUnauthorized, "invalid_client", "unknown client or wrong secret")
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "client_credentials":
+		if s.clients[clientId] == "" {
+			writeOauthError(w, http.StatusUnauthorized, "unauthorized_client", "public clients can't use the client credentials grant")
+			return
+		}
+		scopes := splitScopes(r.PostForm.Get("scope"))
+		identityId := clientId + "@clients.auth.globus.org"
+		if missing := s.missingConsents(identityId, scopes); len(missing) > 0 {
+			writeConsentRequired(w, missing)
+			return
+		}
+		writeJSON(w, http.StatusOK, s.issue(clientId, identityId, scopes, false, ""))
+	case "authorization_code":
+		code, ok := s.codes[r.PostForm.Get("code")]
+		if !ok || code.clientId != clientId || code.redirectURI != r.PostForm.Get("redirect_uri") {
+			writeOauthError(w, http.StatusBadRequest, "invalid_grant", "invalid authorization code")
+			return
+		}
+		delete(s.codes, r.PostForm.Get("code"))
+		if !verifyChallenge(code.challenge, code.challengeType, r.PostForm.Get("code_verifier")) {
+			writeOauthError(w, http.StatusBadRequest, "invalid_grant", "invalid code verifier")
+			return
+		}
+		response := s.issue(clientId, s.User.IdentityId, code.scopes, code.offline, "")
+		if contains(code.scopes, "openid") {
+			idToken, err := s.idToken(clientId, code.scopes)
+			if err != nil {
+				writeOauthError(w, http.StatusInternalServerError, "server_error", err.Error())
+				return
+			}
+			response.IdToken = idToken
+		}
+		writeJSON(w, http.StatusOK, response)
+	case "refresh_token":
+		refreshToken := r.PostForm.Get("refresh_token")
+		grant, ok := s.refreshTokens[refreshToken]
+		if !ok || grant.clientId != clientId {
+			writeOauthError(w, http.StatusBadRequest, "invalid_grant", "invalid refresh token")
+			return
+		}
+		if missing := s.missingConsents(grant.identityId, grant.scopes); len(missing) > 0 {
+			writeConsentRequired(w, missing)
+			return
+		}
+		response := s.issueToken(clientId, grant.identityId, grant.resourceServer, grant.scopes, false)
+		if s.RotateRefreshTokens {
+			delete(s.refreshTokens, refreshToken)
+			response.RefreshToken = s.nextToken("refresh-token")
+			s.refreshTokens[response.RefreshToken] = grant
+		} else {
+			response.RefreshToken = refreshToken
+		}
+		s.tokens[response.AccessToken].RefreshToken = response.RefreshToken
+		writeJSON(w, http.StatusOK, response)
+	case "urn:globus:auth:grant_type:dependent_token":
+		token, ok := s.tokens[r.PostForm.Get("token")]
+		if !ok || time.Now().After(token.Expiry) {
+			writeOauthError(w, http.StatusBadRequest, "invalid_grant", "invalid or expired token")
+			return
+		}
+		if token.ResourceServer != clientId {
+			writeOauthError(w, http.StatusForbidden, "invalid_grant", "the token wasn't issued for the client's resource server")
+			return
+		}
+		scopes := dependentScopes(token, splitScopes(r.PostForm.Get("scope")))
+		if len(scopes) == 0 {
+			writeJSON(w, http.StatusOK, []tokenResponse{})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.issueEach(clientId, token.IdentityId, scopes, r.PostForm.Get("access_type") == "offline"))
+	default:
+		writeOauthError(w, http.StatusBadRequest, "unsupported_grant_type", "unsupported grant type '"+r.PostForm.Get("grant_type")+"'")
+	}
+}
+
+// identifies the client by HTTP basic authentication or the client_id/client_secret form values
+func (s *AuthServer) authenticateClient(r *http.Request) (clientId string, ok bool) {
+	clientId, secret, basic := r.BasicAuth()
+	if !basic {
+		clientId, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	expected, known := s.clients[clientId]
+	if !known {
+		return "", false
+	}
+	return clientId, subtle.ConstantTimeCompare([]byte(expected), []byte(secret)) == 1
+}
+
+// Issues one token per resource server of the scopes. The token of the first scope's resource
+// server is the main one, the others are returned in other_tokens.
+func (s *AuthServer) issue(clientId string, identityId string, scopes []string, offline bool, state string) tokenResponse {
+	tokens := s.issueEach(clientId, identityId, scopes, offline)
+	response := tokens[0]
+	response.State = state
+	response.OtherTokens = tokens[1:]
+	return response
+}
+
+// issues one token per resource server of the scopes, in the order of the scopes
+func (s *AuthServer) issueEach(clientId string, identityId string, scopes []string, offline bool) []tokenResponse {
+	var servers []string
+	scopesByServer := map[string][]string{}
+	for _, scope := range scopes {
+		server := scopeResourceServer(scope)
+		if _, ok := scopesByServer[server]; !ok {
+			servers = append(servers, server)
+		}
+		scopesByServer[server] = append(scopesByServer[server], scope)
+	}
+	if len(servers) == 0 {
+		servers = []string{"auth.globus.org"}
+	}
+
+	tokens := make([]tokenResponse, len(servers))
+	for i, server := range servers {
+		tokens[i] = s.issueToken(clientId, identityId, server, scopesByServer[server], offline)
+	}
+	return tokens
+}
+
+// Returns the dependent scopes of a token the client received as resource server, restricted to
+// the requested ones if any. Like Globus Auth, the dependent scopes are the ones the user
+// consented to as dependencies of the token's scopes.
+func dependentScopes(token *IssuedToken, requested []string) []string {
+	var scopes []string
+	for _, str := range token.Scopes {
+		scope, err := globus.ParseScope(str)
+		if err != nil {
+			continue
+		}
+		for _, dependency := range scope.Dependencies {
+			scopes = append(scopes, globus.Scope{Value: dependency.Value, Dependencies: dependency.Dependencies}.String())
+		}
+	}
+	if len(requested) == 0 {
+		return scopes
+	}
+	var restricted []string
+	for _, scope := range scopes {
+		for _, r := range requested {
+			if r == scope {
+				restricted = append(restricted, scope)
+			}
+		}
+	}
+	return restricted
+}
+
+func (s *AuthServer) issueToken(clientId string, identityId string, resourceServer string, scopes []string, offline bool) tokenResponse {
+	token := &IssuedToken{
+		AccessToken:    s.nextToken("access-token"),
+		ClientId:       clientId,
+		IdentityId:     identityId,
+		ResourceServer: resourceServer,
+		Scopes:         scopes,
+		Expiry:         time.Now().Add(s.TokenLifetime),
+	}
+	if offline {
+		token.RefreshToken = s.nextToken("refresh-token")
+		s.refreshTokens[token.RefreshToken] = &refreshGrant{
+			clientId:       clientId,
+			identityId:     identityId,
+			resourceServer: resourceServer,
+			scopes:         scopes,
+		}
+	}
+	s.tokens[token.AccessToken] = token
+
+	return tokenResponse{
+		AccessToken:    token.AccessToken,
+		TokenType:      "Bearer",
+		ExpiresIn:      int(s.TokenLifetime.Seconds()),
+		Scope:          strings.Join(scopes, " "),
+		ResourceServer: resourceServer,
+		RefreshToken:   token.RefreshToken,
+	}
+}
+
+// Replaces the key signing the id tokens, like a key rotation of Globus Auth. Tokens signed with
+// the old key can't be verified anymore, as only the new one is served.
+func (s *AuthServer) RotateSigningKey() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.newSigningKey()
+}
+
+func (s *AuthServer) newSigningKey() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	s.counter++
+	s.signingKey, s.signingKeyId = key, fmt.Sprintf("fake-signing-key-%d", s.counter)
+	return nil
+}
+
+// signs any claims like an id token, e.g. to test the verification of invalid ones
+func (s *AuthServer) SignIDToken(claims globus.IDTokenClaims) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.signIDToken(claims)
+}
+
+func (s *AuthServer) signIDToken(claims globus.IDTokenClaims) (string, error) {
+	if s.signingKey == nil {
+		if err := s.newSigningKey(); err != nil {
+			return "", err
+		}
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS512", "typ": "JWT", "kid": s.signingKeyId})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha512.Sum512([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.signingKey, crypto.SHA512, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Returns an id token of the user for a client, signed with RS512 like the ones of Globus Auth.
+// The profile and email claims are only included with the respective scopes.
+func (s *AuthServer) idToken(clientId string, scopes []string) (string, error) {
+	now := time.Now()
+	claims := globus.IDTokenClaims{
+		UserInfo: s.userInfo(scopes),
+		Iss:      "https://auth.globus.org",
+		Aud:      []string{clientId},
+		Exp:      now.Add(s.TokenLifetime).Unix(),
+		Iat:      now.Unix(),
+		AuthTime: now.Unix(),
+	}
+	return s.signIDToken(claims)
+}
+
+// the claims of the user that the scopes give access to
+func (s *AuthServer) userInfo(scopes []string) globus.UserInfo {
+	info := globus.UserInfo{Sub: s.User.IdentityId}
+	identity := globus.IdentitySetEntry{Sub: s.User.IdentityId, IdentityProvider: "fake-identity-provider"}
+	if contains(scopes, "profile") {
+		info.Name, info.PreferredUsername = s.User.Name, s.User.Username
+		info.Organization, info.IdentityProvider = "Example Organization", "fake-identity-provider"
+		info.IdentityProviderDisplayName = "Fake Identity Provider"
+		identity.Name, identity.Username, identity.Organization = s.User.Name, s.User.Username, "Example Organization"
+	}
+	if contains(scopes, "email") {
+		info.Email, identity.Email = s.User.Email, s.User.Email
+	}
+	info.IdentitySet = []globus.IdentitySetEntry{identity}
+	return info
+}
+
+// Looks up identities by the comma separated ids or usernames, up to 100 at a time like Globus
+// Auth. Requires a token for Globus Auth itself.
+func (s *AuthServer) handleIdentities(w http.ResponseWriter, r *http.Request) {
+	accessToken, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	token, ok := s.tokens[accessToken]
+	if !ok || time.Now().After(token.Expiry) || token.ResourceServer != "auth.globus.org" {
+		writeOauthError(w, http.StatusUnauthorized, "invalid_token", "invalid or expired token")
+		return
+	}
+
+	query := r.URL.Query()
+	var values []string
+	for _, param := range []string{"ids", "usernames"} {
+		if query.Get(param) != "" {
+			values = append(values, strings.Split(query.Get(param), ",")...)
+		}
+	}
+	if len(values) > 100 {
+		writeOauthError(w, http.StatusBadRequest, "invalid_request", "at most 100 identities can be looked up at a time")
+		return
+	}
+
+	known := append([]globus.Identity{{
+		Id:               s.User.IdentityId,
+		Username:         s.User.Username,
+		Name:             s.User.Name,
+		Email:            s.User.Email,
+		Organization:     "Example Organization",
+		Status:           "used",
+		IdentityProvider: "fake-identity-provider",
+	}}, s.identities...)
+	identities := []globus.Identity{}
+	for _, value := range values {
+		for _, identity := range known {
+			if identity.Id == value || strings.EqualFold(identity.Username, value) {
+				identities = append(identities, identity)
+				break
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"identities": identities})
+}
+
+// serves the public signing key
+func (s *AuthServer) handleJWKS(w http.ResponseWriter) {
+	keys := []map[string]string{}
+	if s.signingKey != nil {
+		keys = append(keys, map[string]string{
+			"kty": "RSA",
+			"alg": "RS512",
+			"use": "sig",
+			"kid": s.signingKeyId,
+			"n":   base64.RawURLEncoding.EncodeToString(s.signingKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.signingKey.E)).Bytes()),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"keys": keys})
+}
+
+// answers with the user's claims for a token of Globus Auth itself with the openid scope
+func (s *AuthServer) handleUserInfo(w http.ResponseWriter, r *http.Request) {
+	accessToken, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	token, ok := s.tokens[accessToken]
+	if !ok || time.Now().After(token.Expiry) {
+		writeOauthError(w, http.StatusUnauthorized, "invalid_token", "invalid or expired token")
+		return
+	}
+	if token.ResourceServer != "auth.globus.org" || !contains(token.Scopes, "openid") {
+		writeOauthError(w, http.StatusForbidden, "insufficient_scope", "the token lacks the openid scope")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.userInfo(token.Scopes))
+}
+
+func verifyChallenge(challenge string, method string, verifier string) bool {
+	switch {
+	case challenge == "":
+		return verifier == ""
+	case method == "S256":
+		sum := sha256.Sum256([]byte(verifier))
+		return base64.RawURLEncoding.EncodeToString(sum[:]) == challenge
+	default: // plain
+		return verifier == challenge
+	}
+}
+
+// Splits a space separated scope string. Spaces within the brackets of dependent scopes
+// don't separate scopes.
+func splitScopes(scope string) (scopes []string) {
+	depth, start := 0, 0
+	for i, c := range scope + " " {
+		switch {
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == ' ' && depth == 0:
+			if i > start {
+				scopes = append(scopes, scope[start:i])
+			}
+			start = i + 1
+		}
+	}
+	return scopes
+}
+
+// the resource server a scope belongs to, e.g. "transfer.api.globus.org" for
+// "urn:globus:auth:scope:transfer.api.globus.org:all[...]"
+func scopeResourceServer(scope string) string {
+	scope, _, _ = strings.Cut(scope, "[")
+	switch {
+	case scope == "openid" || scope == "profile" || scope == "email" || scope == "offline_access":
+		return "auth.globus.org"
+	case strings.HasPrefix(scope, "urn:globus:auth:scope:"):
+		rest := strings.TrimPrefix(scope, "urn:globus:auth:scope:")
+		if i := strings.LastIndex(rest, ":"); i > 0 {
+			return rest[:i]
+		}
+		return rest
+	case strings.HasPrefix(scope, "https://auth.globus.org/scopes/"):
+		server, _, _ := strings.Cut(strings.TrimPrefix(scope, "https://auth.globus.org/scopes/"), "/")
+		return server
+	default:
+		return scope
+	}
+}
+
+func writeOauthError(w http.ResponseWriter, status int, code string, description string) {
+	writeJSON(w, status, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func writeConsentRequired(w http.ResponseWriter, scopes []string) {
+	writeJSON(w, http.StatusForbidden, map[string]any{
+		"error":             "consent_required",
+		"error_description": "missing consent for the requested scopes",
+		"required_scopes":   scopes,
+	})
+}
+
+// Returns a fault that makes the Transfer API emulator answer with a consent required error for
+// the given scopes, e.g. to test the consent handling of a submission.
+func ConsentRequiredFault(pathPrefix string, scopes ...string) Fault {
+	body, _ := json.Marshal(globus.ConsentRequired{
+		Code:           "ConsentRequired",
+		Message:        "Missing required data_access consent",
+		RequestId:      "fake-request",
+		RequiredScopes: scopes,
+	})
+	return Fault{PathPrefix: pathPrefix, StatusCode: http.StatusForbidden, Body: string(body)}
+}
@@ -0,0 +1,268 @@
+package globustest
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+type fileEntry struct {
+	dir      bool
+	size     int
+	modified time.Time
+}
+
+// the emulated file system of an endpoint, a flat map of clean absolute paths to entries
+type fileSystem struct {
+	entries map[string]*fileEntry
+}
+
+// an entry of the ls operation's file list
+type lsEntry struct {
+	DataType     string `json:"DATA_TYPE"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Size         int    `json:"size"`
+	LastModified string `json:"last_modified"`
+	Permissions  string `json:"permissions"`
+	User         string `json:"user"`
+	Group        string `json:"group"`
+}
+
+type lsResult struct {
+	DataType string    `json:"DATA_TYPE"`
+	Endpoint string    `json:"endpoint"`
+	Path     string    `json:"path"`
+	Length   int       `json:"length"`
+	Total    int       `json:"total"`
+	Data     []lsEntry `json:"DATA"`
+}
+
+// returns the file system of an endpoint, creating an empty one on first use. Must be called with the lock held.
+func (s *Server) fileSystem(endpoint string) *fileSystem {
+	fs, ok := s.endpoints[endpoint]
+	if !ok {
+		fs = &fileSystem{entries: map[string]*fileEntry{}}
+		s.endpoints[endpoint] = fs
+	}
+	return fs
+}
+
+// creates a file of the given size on an endpoint, including its parent directories
+func (s *Server) AddFile(endpoint string, filePath string, size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fileSystem(endpoint).put(cleanPath(filePath), &fileEntry{size: size, modified: s.now})
+}
+
+// creates a directory on an endpoint, including its parents
+func (s *Server) AddDir(endpoint string, dirPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fileSystem(endpoint).put(cleanPath(dirPath), &fileEntry{dir: true, modified: s.now})
+}
+
+// true if a file or directory exists on an endpoint
+func (s *Server) Exists(endpoint string, filePath string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.fileSystem(endpoint).get(cleanPath(filePath))
+	return ok
+}
+
+// Maps Globus paths onto the emulated file system: paths relative to the home directory ("~/...")
+// are kept below "/~", everything is cleaned and absolute.
+func cleanPath(p string) string {
+	if strings.HasPrefix(p, "~") {
+		p = "/" + p
+	}
+	return path.Clean("/" + p)
+}
+
+func (fs *fileSystem) get(p string) (*fileEntry, bool) {
+	if p == "/" || p == "/~" {
+		return &fileEntry{dir: true}, true
+	}
+	e, ok := fs.entries[p]
+	return e, ok
+}
+
+// adds an entry and any missing parent directories
+func (fs *fileSystem) put(p string, e *fileEntry) {
+	for dir := path.Dir(p); dir != "/" && dir != "/~"; dir = path.Dir(dir) {
+		if _, ok := fs.entries[dir]; !ok {
+			fs.entries[dir] = &fileEntry{dir: true, modified: e.modified}
+		}
+	}
+	fs.entries[p] = e
+}
+
+// the size of a file, 0 if it doesn't exist
+func (fs *fileSystem) size(p string) int {
+	if e, ok := fs.get(cleanPath(p)); ok {
+		return e.size
+	}
+	return 0
+}
+
+// the files below a source directory paired with their destination below dstDir, sorted by path
+func (fs *fileSystem) expand(srcDir string, dstDir string) []filePair {
+	srcDir = cleanPath(srcDir)
+	var files []filePair
+	for p, e := range fs.entries {
+		if e.dir || !isBelow(p, srcDir) {
+			continue
+		}
+		files = append(files, filePair{
+			source:      p,
+			destination: path.Join(cleanPath(dstDir), strings.TrimPrefix(p, srcDir)),
+			size:        e.size,
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].source < files[j].source
+	})
+	return files
+}
+
+// copies a file from another file system. Files that don't exist in the source are created empty,
+// so tests don't have to populate the source endpoint.
+func (fs *fileSystem) copyFrom(src *fileSystem, srcPath string, dstPath string, modified time.Time) {
+	size := 0
+	if e, ok := src.get(cleanPath(srcPath)); ok {
+		size = e.size
+	}
+	fs.put(cleanPath(dstPath), &fileEntry{size: size, modified: modified})
+}
+
+// removes a file or a directory with all of its contents
+func (fs *fileSystem) remove(p string) {
+	p = cleanPath(p)
+	for entry := range fs.entries {
+		if entry == p || isBelow(entry, p) {
+			delete(fs.entries, entry)
+		}
+	}
+}
+
+// moves a file or a directory with all of its contents
+func (fs *fileSystem) rename(oldPath string, newPath string) {
+	moved := map[string]*fileEntry{}
+	for entry, e := range fs.entries {
+		if entry == oldPath || isBelow(entry, oldPath) {
+			moved[newPath+strings.TrimPrefix(entry, oldPath)] = e
+			delete(fs.entries, entry)
+		}
+	}
+	for entry, e := range moved {
+		fs.put(entry, e)
+	}
+}
+
+// the direct children of a directory, sorted by name
+func (fs *fileSystem) list(dir string) []string {
+	var names []string
+	for entry := range fs.entries {
+		if entry != dir && path.Dir(entry) == dir {
+			names = append(names, path.Base(entry))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isBelow(p string, dir string) bool {
+	return strings.HasPrefix(p, strings.TrimSuffix(dir, "/")+"/")
+}
+
+// handles the ls, mkdir and rename operations on an endpoint
+func (s *Server) handleOperation(w http.ResponseWriter, r *http.Request, endpoint string, op string) {
+	fs := s.fileSystem(endpoint)
+	switch {
+	case op == "ls" && r.Method == http.MethodGet:
+		p := r.URL.Query().Get("path")
+		if p == "" {
+			p = "/~/"
+		}
+		dir := cleanPath(p)
+		e, ok := fs.get(dir)
+		if !ok {
+			writeError(w, http.StatusNotFound, "ClientError.NotFound", "Directory '"+p+"' not found on endpoint")
+			return
+		}
+		if !e.dir {
+			writeError(w, http.StatusBadRequest, "ExternalError.DirListingFailed.NotDirectory", "Path '"+p+"' is not a directory")
+			return
+		}
+
+		result := lsResult{DataType: "file_list", Endpoint: endpoint, Path: p, Data: []lsEntry{}}
+		for _, name := range fs.list(dir) {
+			child := fs.entries[path.Join(dir, name)]
+			entry := lsEntry{
+				DataType:     "file",
+				Name:         name,
+				Type:         "file",
+				Size:         child.size,
+				LastModified: child.modified.UTC().Format("2006-01-02 15:04:05+00:00"),
+				Permissions:  "0644",
+				User:         "globus",
+				Group:        "globus",
+			}
+			if child.dir {
+				entry.Type = "dir"
+				entry.Permissions = "0755"
+			}
+			result.Data = append(result.Data, entry)
+		}
+		result.Length = len(result.Data)
+		result.Total = len(result.Data)
+		writeJSON(w, http.StatusOK, result)
+	case op == "mkdir" && r.Method == http.MethodPost:
+		var body struct {
+			Path string `json:"path"`
+		}
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		p := cleanPath(body.Path)
+		if _, ok := fs.get(p); ok {
+			writeError(w, http.StatusBadGateway, "ExternalError.MkdirFailed.Exists", "Path '"+body.Path+"' already exists")
+			return
+		}
+		fs.put(p, &fileEntry{dir: true, modified: s.now})
+		writeResult(w, "mkdir_result", "DirectoryCreated", "The directory was created successfully")
+	case op == "rename" && r.Method == http.MethodPost:
+		var body struct {
+			OldPath string `json:"old_path"`
+			NewPath string `json:"new_path"`
+		}
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		oldPath, newPath := cleanPath(body.OldPath), cleanPath(body.NewPath)
+		if _, ok := fs.get(oldPath); !ok {
+			writeError(w, http.StatusNotFound, "ClientError.NotFound", "Path '"+body.OldPath+"' not found on endpoint")
+			return
+		}
+		if _, ok := fs.get(newPath); ok {
+			writeError(w, http.StatusBadGateway, "ExternalError.RenameFailed.Exists", "Path '"+body.NewPath+"' already exists")
+			return
+		}
+		fs.rename(oldPath, newPath)
+		writeResult(w, "result", "FileRenamed", "File or directory renamed successfully")
+	default:
+		writeError(w, http.StatusNotFound, "ClientError.NotFound", "No such resource: "+r.URL.Path)
+	}
+}
+
+func writeResult(w http.ResponseWriter, dataType string, code string, message string) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"DATA_TYPE":  dataType,
+		"code":       code,
+		"message":    message,
+		"request_id": "fake-request",
+		"resource":   "",
+	})
+}
@@ -0,0 +1,164 @@
+package globustest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/SwissOpenEM/globus"
+)
+
+// the library type each Transfer API document is decoded into, by DATA_TYPE
+var fixtureTypes = map[string]reflect.Type{
+	"task":                 reflect.TypeOf(globus.Task{}),
+	"task_list":            reflect.TypeOf(globus.TaskList{}),
+	"event_list":           reflect.TypeOf(globus.EventList{}),
+	"successful_transfers": reflect.TypeOf(globus.SuccessfulTransfers{}),
+	"skipped_errors":       reflect.TypeOf(globus.SkippedErrors{}),
+	"pause_info_limited":   reflect.TypeOf(globus.PauseInfoLimited{}),
+	"transfer_result":      reflect.TypeOf(globus.TransferResult{}),
+	"delete_result":        reflect.TypeOf(globus.TransferResult{}),
+	"submission_id":        reflect.TypeOf(globus.SubmissionId{}),
+	"result":               reflect.TypeOf(globus.Result{}),
+}
+
+// Decodes a JSON document into v like json.Unmarshal, but fails if the document contains keys
+// that v has no field for, or lacks keys of fields that aren't tagged omitempty. Keys are
+// compared case-sensitively, unlike in json.Unmarshal. This catches misspelled json tags, which
+// otherwise silently leave fields empty.
+func DecodeStrict(data []byte, v any) error {
+	var doc any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return err
+	}
+	if err := checkFields(doc, reflect.TypeOf(v), "$"); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Checks a Transfer API response body (e.g. from a golden file) with DecodeStrict, using the
+// library type matching its DATA_TYPE. Documents without a library type fail, so a fixture of a
+// new document type can't pass unchecked.
+func CheckFixture(data []byte) error {
+	var header struct {
+		DataType string `json:"DATA_TYPE"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	t, ok := fixtureTypes[header.DataType]
+	if !ok {
+		return fmt.Errorf("unknown DATA_TYPE '%s'", header.DataType)
+	}
+	if err := DecodeStrict(data, reflect.New(t).Interface()); err != nil {
+		return fmt.Errorf("%s: %v", header.DataType, err)
+	}
+	return nil
+}
+
+// checks the JSON response bodies of successful recorded interactions with CheckFixture
+func CheckInteractions(interactions []Interaction) error {
+	var errs []error
+	for _, interaction := range interactions {
+		body := []byte(interaction.Response.Body)
+		if !json.Valid(body) || interaction.Response.StatusCode >= 300 {
+			continue
+		}
+		if err := CheckFixture(body); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %v", interaction.Request.Method, interaction.Request.URL, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compares the keys of a decoded JSON document with the json tags of a type, recursively
+func checkFields(doc any, t reflect.Type, path string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if doc == nil {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := doc.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an object", path)
+		}
+		fields := map[string]reflect.StructField{}
+		var required []string
+		collectFields(t, fields, &required)
+
+		var errs []error
+		for _, key := range sortedKeys(object) {
+			field, ok := fields[key]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown field '%s'", path, key))
+				continue
+			}
+			if err := checkFields(object[key], field.Type, path+"."+key); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		for _, key := range required {
+			if _, ok := object[key]; !ok {
+				errs = append(errs, fmt.Errorf("%s: missing field '%s'", path, key))
+			}
+		}
+		return errors.Join(errs...)
+	case reflect.Slice, reflect.Array:
+		list, ok := doc.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an array", path)
+		}
+		var errs []error
+		for i, item := range list {
+			if err := checkFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	default:
+		return nil
+	}
+}
+
+// collects the json keys of a struct's fields, including those of embedded structs
+func collectFields(t reflect.Type, fields map[string]reflect.StructField, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			collectFields(field.Type, fields, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,113 @@
+package globustest_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+)
+
+// Checks the Transfer API documents in testdata, written from the examples and field lists of
+// the Transfer API documentation, against the library types. DecodeStrict fails on unknown or
+// missing fields, so a json tag that doesn't match the API is noticed.
+func TestTransferAPIFixtures(t *testing.T) {
+	read := func(t *testing.T, name string, v any) {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := globustest.CheckFixture(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := globustest.DecodeStrict(data, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("task", func(t *testing.T) {
+		var task globus.Task
+		read(t, "task.json", &task)
+		if task.Status != "ACTIVE" || task.Files != 12 || task.FilesSkipped == nil || *task.FilesSkipped != 3 {
+			t.Errorf("got task %s with %d files, want an ACTIVE task with 12 files and 3 skipped", task.Status, task.Files)
+		}
+		if task.FilterRules == nil || len(*task.FilterRules) != 1 || (*task.FilterRules)[0].Name != "*.tmp" {
+			t.Errorf("got filter rules %v, want the *.tmp rule", task.FilterRules)
+		}
+		if task.CompletionTime != nil || task.SourceLocalUser != nil {
+			t.Error("null fields are set")
+		}
+	})
+	t.Run("task_list", func(t *testing.T) {
+		var list globus.TaskList
+		read(t, "task_list.json", &list)
+		if list.Length != 1 || list.Total != 2 || len(list.Data) != 1 {
+			t.Errorf("got %d tasks (length %d) of %d, want 1 of 2", len(list.Data), list.Length, list.Total)
+		}
+		if task := list.Data[0]; task.Type != "DELETE" || task.DestinationEndpointId != nil {
+			t.Errorf("got a %s task to %v, want a delete task without destination", task.Type, task.DestinationEndpointId)
+		}
+	})
+	t.Run("event_list", func(t *testing.T) {
+		var events globus.EventList
+		read(t, "event_list.json", &events)
+		if events.Length != 2 || len(events.Data) != 2 || !events.Data[0].IsError || events.Data[0].Code != "PERMISSION_DENIED" {
+			t.Errorf("got events %+v, want a PERMISSION_DENIED error first", events.Data)
+		}
+	})
+	t.Run("successful_transfers", func(t *testing.T) {
+		var transfers globus.SuccessfulTransfers
+		read(t, "successful_transfers.json", &transfers)
+		if len(transfers.Data) != 2 || transfers.NextMarker == nil || *transfers.NextMarker != 2 {
+			t.Errorf("got %d transfers and next marker %v, want 2 and a next marker", len(transfers.Data), transfers.NextMarker)
+		}
+	})
+	t.Run("skipped_errors", func(t *testing.T) {
+		var skipped globus.SkippedErrors
+		read(t, "skipped_errors.json", &skipped)
+		if len(skipped.Data) != 1 || skipped.Data[0].ErrorCode != "PERMISSION_DENIED" || skipped.Data[0].ErrorTime == "" {
+			t.Errorf("got skipped errors %+v, want one PERMISSION_DENIED", skipped.Data)
+		}
+		if skipped.NextMarker != nil {
+			t.Errorf("got next marker %d on the last page", *skipped.NextMarker)
+		}
+	})
+	t.Run("pause_info_limited", func(t *testing.T) {
+		var info globus.PauseInfoLimited
+		read(t, "pause_info_limited.json", &info)
+		if len(info.PauseRules) != 1 || !info.PauseRules[0].PauseTaskTransferWrite || info.DestinationPauseMessage == nil {
+			t.Errorf("got pause info %+v, want a rule pausing writes to the destination", info)
+		}
+	})
+}
+
+func TestCheckFixture(t *testing.T) {
+	task, err := json.Marshal(globus.Task{DataType: "task", TaskId: "6f1b0c3e-0000-4000-8000-000000000001", Status: "ACTIVE", RequestTime: time.Now().Format(time.RFC3339)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := globustest.CheckFixture(task); err != nil {
+		t.Errorf("a marshalled task doesn't pass: %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(task, &doc); err != nil {
+		t.Fatal(err)
+	}
+	doc["unknown_field"] = true
+	delete(doc, "status")
+	data, _ := json.Marshal(doc)
+	err = globustest.CheckFixture(data)
+	if err == nil {
+		t.Fatal("a task with an unknown and a missing field passes")
+	}
+	t.Log(err)
+
+	if err := globustest.CheckFixture([]byte(`{"DATA_TYPE": "endpoint", "id": "x"}`)); err == nil {
+		t.Error("a document of an unknown DATA_TYPE passes")
+	}
+}
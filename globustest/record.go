@@ -0,0 +1,327 @@
+package globustest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// placeholder for scrubbed secrets
+const Redacted = "REDACTED"
+
+// a request and its response as stored in a golden file
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Secrets and personal data removed from recorded interactions. Secrets are replaced by Redacted,
+// values of IdentityKeys by stable fake UUIDs, so recordings stay consistent (e.g. the same owner
+// in several tasks) without exposing identities.
+type ScrubOptions struct {
+	SecretHeaders []string // headers whose values are redacted
+	SecretParams  []string // query parameters and form fields whose values are redacted
+	SecretKeys    []string // JSON keys whose values are redacted
+	IdentityKeys  []string // JSON keys whose values are replaced by fake identity ids
+}
+
+// the scrubbing applied by NewRecorder
+func DefaultScrubOptions() ScrubOptions {
+	return ScrubOptions{
+		SecretHeaders: []string{"Authorization", "Cookie", "Set-Cookie"},
+		SecretParams:  []string{"code", "code_verifier", "client_secret", "refresh_token", "token"},
+		SecretKeys:    []string{"access_token", "refresh_token", "id_token", "client_secret", "username", "email"},
+		IdentityKeys:  []string{"owner_id", "identity_id", "sub", "id_token_sub", "effective_identity"},
+	}
+}
+
+// A Recorder is an http.RoundTripper that passes requests on to a real transport and records
+// them with their responses, scrubbed of tokens and identities. Save writes the recording to a
+// golden file that can be served back by a Replayer:
+//
+//	rec := globustest.NewRecorder(http.DefaultTransport)
+//	client := globus.HttpClientToGlobusClient(&http.Client{Transport: &oauth2.Transport{Source: ts, Base: rec}})
+//	// ... use the client against the real API ...
+//	err := rec.Save("testdata/task.json")
+type Recorder struct {
+	Transport http.RoundTripper
+	Scrub     ScrubOptions
+
+	mu           sync.Mutex
+	interactions []Interaction
+	identities   map[string]string // real -> fake identity id
+}
+
+func NewRecorder(transport http.RoundTripper) *Recorder {
+	return &Recorder{
+		Transport:  transport,
+		Scrub:      DefaultScrubOptions(),
+		identities: map[string]string{},
+	}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    r.scrubURL(req.URL),
+			Header: r.scrubHeader(req.Header),
+			Body:   r.scrubBody(req.Header.Get("Content-Type"), reqBody),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.scrubHeader(resp.Header),
+			Body:       r.scrubBody(resp.Header.Get("Content-Type"), respBody),
+		},
+	})
+	return resp, nil
+}
+
+// returns the interactions recorded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// writes the recorded interactions to a golden file
+func (r *Recorder) Save(path string) error {
+	data, err := json.MarshalIndent(r.Interactions(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func (r *Recorder) scrubHeader(header http.Header) http.Header {
+	scrubbed := header.Clone()
+	for _, name := range r.Scrub.SecretHeaders {
+		if scrubbed.Get(name) != "" {
+			scrubbed.Set(name, Redacted)
+		}
+	}
+	return scrubbed
+}
+
+func (r *Recorder) scrubURL(u *url.URL) string {
+	scrubbed := *u
+	query := scrubbed.Query()
+	for _, key := range r.Scrub.SecretParams {
+		if query.Has(key) {
+			query.Set(key, Redacted)
+		}
+	}
+	scrubbed.RawQuery = query.Encode()
+	return scrubbed.String()
+}
+
+// scrubs JSON and form bodies, other bodies are kept as-is
+func (r *Recorder) scrubBody(contentType string, body []byte) string {
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		for _, key := range r.Scrub.SecretParams {
+			if form.Has(key) {
+				form.Set(key, Redacted)
+			}
+		}
+		return form.Encode()
+	case len(body) > 0 && json.Valid(body):
+		var doc any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			return string(body)
+		}
+		scrubbed, err := json.Marshal(r.scrubValue(doc))
+		if err != nil {
+			return string(body)
+		}
+		return string(scrubbed)
+	default:
+		return string(body)
+	}
+}
+
+func (r *Recorder) scrubValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			switch {
+			case contains(r.Scrub.SecretKeys, key) && value != nil:
+				v[key] = Redacted
+			case contains(r.Scrub.IdentityKeys, key):
+				v[key] = r.scrubIdentity(value)
+			default:
+				v[key] = r.scrubValue(value)
+			}
+		}
+	case []any:
+		for i := range v {
+			v[i] = r.scrubValue(v[i])
+		}
+	}
+	return v
+}
+
+func (r *Recorder) scrubIdentity(v any) any {
+	switch v := v.(type) {
+	case string:
+		fake, ok := r.identities[v]
+		if !ok {
+			fake = uuidFromCounter(len(r.identities) + 1)
+			r.identities[v] = fake
+		}
+		return fake
+	case []any:
+		for i := range v {
+			v[i] = r.scrubIdentity(v[i])
+		}
+	}
+	return v
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// A Replayer is an http.RoundTripper that answers requests from a golden file written by a
+// Recorder. Requests are matched by method, path and query (the host is ignored), recordings of
+// the same request are served in recorded order. Unmatched requests fail.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// loads a golden file
+func NewReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("could not parse golden file '%s': %v", path, err)
+	}
+	return &Replayer{interactions: interactions, used: make([]bool, len(interactions))}, nil
+}
+
+// returns an http client that's served by the replayer
+func (r *Replayer) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.interactions {
+		if r.used[i] || !matches(interaction.Request, req) {
+			continue
+		}
+		r.used[i] = true
+		header := interaction.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		header.Del("Content-Length") // the scrubbed body may differ in length
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction left for %s %s", req.Method, req.URL)
+}
+
+// returns the recorded interactions that weren't replayed (yet)
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, interaction := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+func matches(recorded RecordedRequest, req *http.Request) bool {
+	if recorded.Method != req.Method {
+		return false
+	}
+	u, err := url.Parse(recorded.URL)
+	if err != nil || u.Path != req.URL.Path {
+		return false
+	}
+	// compare the queries in their canonical form, secrets were scrubbed while recording
+	recordedQuery, query := u.Query(), req.URL.Query()
+	for key := range recordedQuery {
+		if recordedQuery.Get(key) == Redacted && query.Has(key) {
+			query.Set(key, Redacted)
+		}
+	}
+	return recordedQuery.Encode() == query.Encode()
+}
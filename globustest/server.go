@@ -0,0 +1,233 @@
+// Package globustest provides in-process emulators of the Globus Transfer and Auth APIs for tests.
+//
+// A Server emulates the Transfer API. It keeps the submitted tasks, a small file system per endpoint and a fake clock.
+// Tasks progress only when the clock is advanced, so tests can drive a transfer through
+// its life cycle deterministically:
+//
+//	srv := globustest.NewServer()
+//	defer srv.Close()
+//	client := srv.Client()
+//
+//	result, _ := client.TransferFolderSync("src", "/data", "dst", "/archive", true)
+//	srv.Advance(srv.TaskDuration)
+//	task, _ := client.TransferGetTaskByID(result.TaskId) // task.Status == "SUCCEEDED"
+//
+// Faults, pauses and skipped errors can be injected to test error handling.
+//
+// An AuthServer emulates the token and authorization endpoints of Globus Auth (see NewAuthServer).
+package globustest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+)
+
+// the owner of all tasks created through the server
+const OwnerId = "6c2e5f8b-0000-4000-8000-000000000001"
+
+// An injected fault: the next Times requests matching Method and PathPrefix are answered
+// with StatusCode and Body instead of being processed.
+type Fault struct {
+	Method     string // empty matches any method
+	PathPrefix string // relative to the API root, e.g. "/transfer" or "/task/"
+	StatusCode int
+	Body       string // a Globus error document is generated from the status code if empty
+	Times      int    // 0 means once
+}
+
+type Server struct {
+	*httptest.Server
+
+	// time a task takes from submission to completion on the fake clock (default: 1 minute)
+	TaskDuration time.Duration
+
+	mu          sync.Mutex
+	now         time.Time
+	counter     int
+	tasks       map[string]*task
+	taskOrder   []string
+	submissions map[string]string // submission id -> task id, "" if not used yet
+	faults      []*Fault
+	endpoints   map[string]*fileSystem
+}
+
+// starts a new emulator, the fake clock starts at 2024-01-01T00:00:00Z
+func NewServer() *Server {
+	s := &Server{
+		TaskDuration: time.Minute,
+		now:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		tasks:        map[string]*task{},
+		submissions:  map[string]string{},
+		endpoints:    map[string]*fileSystem{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// returns a client sending its Transfer API requests to the server
+func (s *Server) Client() globus.GlobusClient {
+	return globus.HttpClientToGlobusClient(s.Server.Client()).WithTransferBaseUrl(s.URL)
+}
+
+// returns the current time of the fake clock
+func (s *Server) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+// moves the fake clock forward, progressing all active tasks that aren't paused
+func (s *Server) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = s.now.Add(d)
+	for _, id := range s.taskOrder {
+		s.tasks[id].advance(s, d)
+	}
+}
+
+// makes matching requests fail (see Fault)
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.Times <= 0 {
+		f.Times = 1
+	}
+	s.faults = append(s.faults, &f)
+}
+
+// returns the current state of a task
+func (s *Server) Task(taskId string) (globus.Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[taskId]
+	if !ok {
+		return globus.Task{}, false
+	}
+	return t.Task, true
+}
+
+// returns the documents of all submitted tasks, oldest first
+func (s *Server) Submitted() (transfers []globus.Transfer, deletes []globus.Delete) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range s.taskOrder {
+		t := s.tasks[id]
+		if t.transfer != nil {
+			transfers = append(transfers, *t.transfer)
+		} else {
+			deletes = append(deletes, *t.delete)
+		}
+	}
+	return transfers, deletes
+}
+
+func (s *Server) nextId() string {
+	s.counter++
+	return uuidFromCounter(s.counter)
+}
+
+// generates stable, valid looking UUIDs
+func uuidFromCounter(n int) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012x", n)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.injectFault(w, r) {
+		return
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	switch {
+	case r.Method == http.MethodGet && path == "submission_id":
+		s.handleSubmissionId(w)
+	case r.Method == http.MethodPost && path == "transfer":
+		s.handleTransfer(w, r)
+	case r.Method == http.MethodPost && path == "delete":
+		s.handleDelete(w, r)
+	case r.Method == http.MethodGet && path == "task_list":
+		s.handleTaskList(w, r)
+	case len(parts) >= 2 && parts[0] == "task":
+		s.handleTask(w, r, parts[1], parts[2:])
+	case len(parts) == 4 && parts[0] == "operation" && parts[1] == "endpoint":
+		s.handleOperation(w, r, parts[2], parts[3])
+	default:
+		writeError(w, http.StatusNotFound, "ClientError.NotFound", "No such resource: "+r.URL.Path)
+	}
+}
+
+// answers the request with an injected fault, if one matches
+func (s *Server) injectFault(w http.ResponseWriter, r *http.Request) bool {
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != r.Method {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, f.PathPrefix) {
+			continue
+		}
+
+		f.Times--
+		if f.Times <= 0 {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+		}
+		if f.Body == "" {
+			writeError(w, f.StatusCode, errorCode(f.StatusCode), "injected fault")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(f.StatusCode)
+			w.Write([]byte(f.Body))
+		}
+		return true
+	}
+	return false
+}
+
+func errorCode(status int) string {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return "RequestLimitExceeded"
+	case status == http.StatusServiceUnavailable:
+		return "ServiceUnavailable"
+	case status >= 500:
+		return "ServerError.InternalError"
+	case status == http.StatusNotFound:
+		return "ClientError.NotFound"
+	case status == http.StatusForbidden:
+		return "PermissionDenied"
+	case status == http.StatusConflict:
+		return "Conflict"
+	default:
+		return "ClientError.BadRequest"
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	writeJSON(w, status, globus.Result{
+		DataType:  "result",
+		Code:      code,
+		Message:   message,
+		RequestId: "fake-request",
+		Resource:  "",
+	})
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05+00:00")
+}
@@ -0,0 +1,624 @@
+package globustest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+)
+
+// number of entries per page of the marker-paged task resources
+const markerPageSize = 100
+
+type filePair struct {
+	source      string
+	destination string
+	size        int
+}
+
+type task struct {
+	globus.Task
+	transfer *globus.Transfer
+	delete   *globus.Delete
+
+	elapsed    time.Duration
+	files      []filePair // files affected by the task, in processing order
+	events     []globus.Event
+	skipped    []globus.SkippedError
+	pauseRules []globus.PauseRuleLimited
+}
+
+func (t *task) isDone() bool {
+	return t.Status == "SUCCEEDED" || t.Status == "FAILED"
+}
+
+func (t *task) addEvent(s *Server, code string, description string, isError bool) {
+	t.events = append(t.events, globus.Event{
+		DataType:    "event",
+		Code:        code,
+		Description: description,
+		IsError:     isError,
+		Time:        formatTime(s.now),
+	})
+}
+
+// progresses an active task on the fake clock, completing it once the task duration is reached
+func (t *task) advance(s *Server, d time.Duration) {
+	if t.Status != "ACTIVE" || t.IsPaused {
+		return
+	}
+
+	t.elapsed += d
+	if t.elapsed >= s.TaskDuration || s.TaskDuration <= 0 {
+		t.complete(s)
+		return
+	}
+
+	done := int(int64(len(t.files)) * int64(t.elapsed) / int64(s.TaskDuration))
+	t.setProgress(done)
+}
+
+func (t *task) setProgress(done int) {
+	t.FilesTransferred = 0
+	t.BytesTransferred = 0
+	for _, f := range t.files[:done] {
+		t.FilesTransferred++
+		t.BytesTransferred += f.size
+	}
+	if t.Type == "TRANSFER" {
+		t.SubtasksSucceeded = done
+		t.SubtasksPending = len(t.files) - done
+		if t.VerifyChecksum {
+			t.BytesChecksummed = t.BytesTransferred
+		}
+	}
+}
+
+func (t *task) complete(s *Server) {
+	t.setProgress(len(t.files))
+	t.Status = "SUCCEEDED"
+	t.NiceStatus = nil
+	completion := formatTime(s.now)
+	t.CompletionTime = &completion
+	t.addEvent(s, "SUCCEEDED", "succeeded", false)
+
+	if t.transfer != nil {
+		source := s.fileSystem(t.transfer.SourceEndpoint)
+		destination := s.fileSystem(t.transfer.DestinationEndpoint)
+		for _, f := range t.files {
+			destination.copyFrom(source, f.source, f.destination, s.now)
+		}
+	} else {
+		fs := s.fileSystem(t.delete.Endpoint)
+		for _, item := range t.delete.Data {
+			fs.remove(item.Path)
+		}
+	}
+}
+
+func (t *task) fail(s *Server, code string, description string) {
+	t.Status = "FAILED"
+	t.FatalError = &globus.FatalError{Code: code, Description: description}
+	completion := formatTime(s.now)
+	t.CompletionTime = &completion
+	t.SubtasksFailed = t.SubtasksPending
+	t.SubtasksPending = 0
+	t.addEvent(s, code, description, true)
+}
+
+// looks up a task for one of the server's control methods
+func (s *Server) task(taskId string) (*task, error) {
+	t, ok := s.tasks[taskId]
+	if !ok {
+		return nil, fmt.Errorf("task '%s' does not exist", taskId)
+	}
+	return t, nil
+}
+
+// completes a task right away, independent of the fake clock
+func (s *Server) CompleteTask(taskId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.task(taskId)
+	if err != nil {
+		return err
+	}
+	if t.isDone() {
+		return fmt.Errorf("task '%s' is already done", taskId)
+	}
+	t.complete(s)
+	return nil
+}
+
+// makes a task fail with a fatal error
+func (s *Server) FailTask(taskId string, code string, description string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.task(taskId)
+	if err != nil {
+		return err
+	}
+	if t.isDone() {
+		return fmt.Errorf("task '%s' is already done", taskId)
+	}
+	t.fail(s, code, description)
+	return nil
+}
+
+// Records a fault (e.g. "CONNECTION_FAILED") on an active task. The task keeps running,
+// but its nice status reports the fault until the next call of Advance clears it.
+func (s *Server) InjectTaskFault(taskId string, code string, description string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.task(taskId)
+	if err != nil {
+		return err
+	}
+	t.Faults++
+	t.NiceStatus = &code
+	t.NiceStatusShortDescription = description
+	t.addEvent(s, code, description, true)
+	return nil
+}
+
+// pauses an active task as a collection's activity manager would, with a pause rule on its source endpoint
+func (s *Server) PauseTask(taskId string, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.task(taskId)
+	if err != nil {
+		return err
+	}
+	if t.isDone() {
+		return fmt.Errorf("task '%s' is already done", taskId)
+	}
+
+	t.IsPaused = true
+	status := "PAUSED_BY_ADMIN"
+	t.NiceStatus = &status
+	t.pauseRules = append(t.pauseRules, globus.PauseRuleLimited{
+		DataType:               "pause_rule_limited",
+		Id:                     s.nextId(),
+		Message:                message,
+		StartTime:              formatTime(s.now),
+		EndpointId:             t.SourceEndpointId,
+		EndpointDisplayName:    t.SourceEndpointDisplayName,
+		ModifiedTime:           formatTime(s.now),
+		PauseLs:                false,
+		PauseMkdir:             false,
+		PauseSymlink:           false,
+		PauseRename:            false,
+		PauseTaskDelete:        true,
+		PauseTaskTransferWrite: true,
+		PauseTaskTransferRead:  true,
+	})
+	t.addEvent(s, "PAUSED", message, false)
+	return nil
+}
+
+func (s *Server) ResumeTask(taskId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.task(taskId)
+	if err != nil {
+		return err
+	}
+	t.IsPaused = false
+	t.NiceStatus = nil
+	t.pauseRules = nil
+	t.addEvent(s, "RESUMED", "resumed", false)
+	return nil
+}
+
+// Records a path of a task that was skipped because of an error. The task's
+// skip_source_errors option isn't checked, so any task can report skipped errors.
+func (s *Server) AddSkippedError(taskId string, sourcePath string, destinationPath string, code string, details string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.task(taskId)
+	if err != nil {
+		return err
+	}
+	t.skipped = append(t.skipped, globus.SkippedError{
+		TransferItem: globus.TransferItem{
+			DataType:        "skipped_error",
+			SourcePath:      sourcePath,
+			DestinationPath: destinationPath,
+		},
+		ErrorCode:    code,
+		ErrorDetails: details,
+		ErrorTime:    formatTime(s.now),
+	})
+	skipped := len(t.skipped)
+	t.FilesSkipped = &skipped
+	t.SubtasksSkippedErrors = skipped
+	return nil
+}
+
+func (s *Server) handleSubmissionId(w http.ResponseWriter) {
+	id := s.nextId()
+	s.submissions[id] = ""
+	writeJSON(w, http.StatusOK, globus.SubmissionId{DataType: "submission_id", Value: id})
+}
+
+// checks the submission id of a document, returns the id of the original task for duplicates
+func (s *Server) checkSubmissionId(w http.ResponseWriter, submissionId string) (duplicateOf string, ok bool) {
+	taskId, known := s.submissions[submissionId]
+	if !known {
+		writeError(w, http.StatusBadRequest, "ClientError.BadRequest", "Invalid submission id '"+submissionId+"'")
+		return "", false
+	}
+	return taskId, true
+}
+
+func (s *Server) handleTransfer(w http.ResponseWriter, r *http.Request) {
+	var transfer globus.Transfer
+	if !decodeBody(w, r, &transfer) {
+		return
+	}
+	duplicateOf, ok := s.checkSubmissionId(w, transfer.SubmissionId)
+	if !ok {
+		return
+	}
+	if duplicateOf != "" {
+		writeDuplicate(w, transfer.SubmissionId, duplicateOf)
+		return
+	}
+	if err := globus.ValidateTransfer(transfer); err != nil {
+		writeError(w, http.StatusBadRequest, "ClientError.BadRequest", err.Error())
+		return
+	}
+
+	t := s.newTask("TRANSFER", transfer.CommonTransfer, transfer.SourceEndpoint)
+	t.transfer = &transfer
+	destination := transfer.DestinationEndpoint
+	t.DestinationEndpointId = &destination
+	t.DestinationEndpointDisplayName = &destination
+	t.SyncLevel = transfer.SyncLevel
+	t.EncryptData = deref(transfer.EncryptData)
+	t.VerifyChecksum = deref(transfer.VerifyChecksum)
+	t.DeleteDestinationExtra = deref(transfer.DeleteDestinationExtra)
+	t.PreserveTimestamp = deref(transfer.PreserveTimestamp)
+	t.SkipSourceErrors = deref(transfer.SkipSourceErrors)
+	t.FailOnQuotaErrors = deref(transfer.FailOnQuotaErrors)
+	t.FilterRules = transfer.FilterRules
+	t.SourceLocalUser = transfer.SourceLocalUser
+	t.DestinationLocalUser = transfer.DestinationLocalUser
+
+	source := s.fileSystem(transfer.SourceEndpoint)
+	for _, item := range transfer.Data {
+		if item.Recursive != nil && *item.Recursive {
+			t.Directories++
+			t.files = append(t.files, source.expand(item.SourcePath, item.DestinationPath)...)
+		} else if item.DataType == "transfer_symlink_item" {
+			t.Symlinks++
+			t.files = append(t.files, filePair{source: item.SourcePath, destination: item.DestinationPath})
+		} else {
+			t.files = append(t.files, filePair{source: item.SourcePath, destination: item.DestinationPath, size: source.size(item.SourcePath)})
+		}
+	}
+	t.Files = len(t.files)
+	t.SubtasksTotal = len(t.files)
+	t.SubtasksPending = len(t.files)
+	s.addTask(w, t)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	var del globus.Delete
+	if !decodeBody(w, r, &del) {
+		return
+	}
+	duplicateOf, ok := s.checkSubmissionId(w, del.SubmissionId)
+	if !ok {
+		return
+	}
+	if duplicateOf != "" {
+		writeDuplicate(w, del.SubmissionId, duplicateOf)
+		return
+	}
+	if err := globus.ValidateDelete(del); err != nil {
+		writeError(w, http.StatusBadRequest, "ClientError.BadRequest", err.Error())
+		return
+	}
+
+	t := s.newTask("DELETE", del.CommonTransfer, del.Endpoint)
+	t.delete = &del
+	for _, item := range del.Data {
+		t.files = append(t.files, filePair{source: item.Path})
+	}
+	t.Files = len(t.files)
+	s.addTask(w, t)
+}
+
+func (s *Server) newTask(taskType string, common globus.CommonTransfer, sourceEndpoint string) *task {
+	t := &task{}
+	t.DataType = "task"
+	t.TaskId = s.nextId()
+	t.Type = taskType
+	t.Status = "ACTIVE"
+	t.OwnerId = OwnerId
+	t.RequestTime = formatTime(s.now)
+	t.Deadline = formatTime(s.now.Add(24 * time.Hour))
+	if common.Deadline != nil {
+		t.Deadline = *common.Deadline
+	}
+	if common.Label != nil {
+		t.Label = *common.Label
+	}
+	t.SourceEndpointId = sourceEndpoint
+	t.SourceEndpointDisplayName = sourceEndpoint
+	t.Command = "API 0.10"
+	t.SourceLocalUserStatus = "OK"
+	t.DestinationLocalUserStatus = "OK"
+	return t
+}
+
+func (s *Server) addTask(w http.ResponseWriter, t *task) {
+	submissionId := ""
+	if t.transfer != nil {
+		submissionId = t.transfer.SubmissionId
+	} else {
+		submissionId = t.delete.SubmissionId
+	}
+	s.submissions[submissionId] = t.TaskId
+	s.tasks[t.TaskId] = t
+	s.taskOrder = append(s.taskOrder, t.TaskId)
+	t.addEvent(s, "STARTED", "started", false)
+
+	writeJSON(w, http.StatusAccepted, globus.TransferResult{
+		DataType:     strings.ToLower(t.Type) + "_result",
+		TaskId:       t.TaskId,
+		SubmissionId: submissionId,
+		Code:         "Accepted",
+		Message:      "The " + strings.ToLower(t.Type) + " has been accepted and a task has been created and queued for execution",
+		Resource:     "/" + strings.ToLower(t.Type),
+	})
+}
+
+func writeDuplicate(w http.ResponseWriter, submissionId string, taskId string) {
+	writeJSON(w, http.StatusOK, globus.TransferResult{
+		DataType:     "transfer_result",
+		TaskId:       taskId,
+		SubmissionId: submissionId,
+		Code:         "Duplicate",
+		Message:      "A transfer with id '" + submissionId + "' was already submitted",
+	})
+}
+
+func (s *Server) handleTaskList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	filters, err := parseTaskFilter(q.Get("filter"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "ClientError.BadRequest", err.Error())
+		return
+	}
+
+	// newest first
+	var matching []globus.Task
+	for i := len(s.taskOrder) - 1; i >= 0; i-- {
+		t := s.tasks[s.taskOrder[i]]
+		if !t.HistoryDeleted && filters.match(t.Task) {
+			matching = append(matching, t.Task)
+		}
+	}
+
+	list := globus.TaskList{
+		DataType: "task_list",
+		Limit:    limit,
+		Offset:   offset,
+		Total:    len(matching),
+		Data:     []globus.Task{},
+	}
+	if offset < len(matching) {
+		end := min(offset+limit, len(matching))
+		list.Data = matching[offset:end]
+	}
+	list.Length = len(list.Data)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// filters of the task list, in the "field:value1,value2/field2:value" format of the Transfer API
+type taskFilter map[string][]string
+
+func parseTaskFilter(filter string) (taskFilter, error) {
+	filters := taskFilter{}
+	if filter == "" {
+		return filters, nil
+	}
+	for _, part := range strings.Split(filter, "/") {
+		field, values, found := strings.Cut(part, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid filter '%s'", part)
+		}
+		switch field {
+		case "task_id", "type", "status", "label":
+			filters[field] = strings.Split(values, ",")
+		default:
+			return nil, fmt.Errorf("unsupported filter field '%s'", field)
+		}
+	}
+	return filters, nil
+}
+
+func (f taskFilter) match(t globus.Task) bool {
+	fields := map[string]string{
+		"task_id": t.TaskId,
+		"type":    t.Type,
+		"status":  t.Status,
+		"label":   t.Label,
+	}
+	for field, values := range f {
+		matched := false
+		for _, value := range values {
+			if field == "label" && strings.HasPrefix(value, "~") {
+				matched = matched || strings.Contains(fields[field], value[1:])
+			} else {
+				matched = matched || fields[field] == value
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request, taskId string, sub []string) {
+	t, ok := s.tasks[taskId]
+	if !ok || t.HistoryDeleted {
+		writeError(w, http.StatusNotFound, "TaskNotFound", "Task '"+taskId+"' not found")
+		return
+	}
+
+	resource := ""
+	if len(sub) == 1 {
+		resource = sub[0]
+	} else if len(sub) > 1 {
+		writeError(w, http.StatusNotFound, "ClientError.NotFound", "No such resource: "+r.URL.Path)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && resource == "":
+		writeJSON(w, http.StatusOK, t.Task)
+	case r.Method == http.MethodPost && resource == "cancel":
+		if t.isDone() {
+			writeJSON(w, http.StatusOK, globus.Result{DataType: "result", Code: "TaskComplete", Message: "The task completed before it could be cancelled", Resource: r.URL.Path})
+			return
+		}
+		t.fail(s, "CANCELED", "The task was canceled by the user")
+		writeJSON(w, http.StatusOK, globus.Result{DataType: "result", Code: "Canceled", Message: "The task has been cancelled successfully.", Resource: r.URL.Path})
+	case r.Method == http.MethodPost && resource == "remove":
+		if !t.isDone() {
+			writeError(w, http.StatusConflict, "Conflict", "Only completed tasks can be removed")
+			return
+		}
+		t.HistoryDeleted = true
+		writeJSON(w, http.StatusOK, globus.Result{DataType: "result", Code: "Removed", Message: "The task has been removed", Resource: r.URL.Path})
+	case r.Method == http.MethodGet && resource == "event_list":
+		s.handleEventList(w, r, t)
+	case r.Method == http.MethodGet && resource == "successful_transfers":
+		handleSuccessfulTransfers(w, r, t)
+	case r.Method == http.MethodGet && resource == "skipped_errors":
+		handleSkippedErrors(w, r, t)
+	case r.Method == http.MethodGet && resource == "pause_info":
+		info := globus.PauseInfoLimited{
+			DataType:   "pause_info_limited",
+			PauseRules: t.pauseRules,
+		}
+		if info.PauseRules == nil {
+			info.PauseRules = []globus.PauseRuleLimited{}
+		}
+		if len(t.pauseRules) > 0 {
+			info.SourcePauseMessage = &t.pauseRules[len(t.pauseRules)-1].Message
+		}
+		writeJSON(w, http.StatusOK, info)
+	default:
+		writeError(w, http.StatusNotFound, "ClientError.NotFound", "No such resource: "+r.URL.Path)
+	}
+}
+
+func (s *Server) handleEventList(w http.ResponseWriter, r *http.Request, t *task) {
+	q := r.URL.Query()
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+
+	// newest first
+	events := make([]globus.Event, 0, len(t.events))
+	for i := len(t.events) - 1; i >= 0; i-- {
+		events = append(events, t.events[i])
+	}
+	list := globus.EventList{
+		DataType: "event_list",
+		Limit:    uint(limit),
+		Offset:   uint(offset),
+		Total:    uint(len(events)),
+		Data:     []globus.Event{},
+	}
+	if offset < len(events) {
+		list.Data = events[offset:min(offset+limit, len(events))]
+	}
+	list.Length = uint(len(list.Data))
+	writeJSON(w, http.StatusOK, list)
+}
+
+func handleSuccessfulTransfers(w http.ResponseWriter, r *http.Request, t *task) {
+	var transfers []globus.SuccessfulTransfer
+	if t.Type == "TRANSFER" {
+		for _, f := range t.files[:t.FilesTransferred] {
+			transfers = append(transfers, globus.SuccessfulTransfer{
+				DataType:        "successful_transfer",
+				SourcePath:      f.source,
+				DestinationPath: f.destination,
+			})
+		}
+	}
+
+	marker, paging := markerPage(r, len(transfers))
+	page := globus.SuccessfulTransfers{
+		DataType:     "successful_transfers",
+		MarkerPaging: paging,
+		Data:         []globus.SuccessfulTransfer{},
+	}
+	if marker < len(transfers) {
+		page.Data = transfers[marker:min(marker+markerPageSize, len(transfers))]
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func handleSkippedErrors(w http.ResponseWriter, r *http.Request, t *task) {
+	marker, paging := markerPage(r, len(t.skipped))
+	page := globus.SkippedErrors{
+		DataType:     "skipped_errors",
+		MarkerPaging: paging,
+		Data:         []globus.SkippedError{},
+	}
+	if marker < len(t.skipped) {
+		page.Data = t.skipped[marker:min(marker+markerPageSize, len(t.skipped))]
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// reads the marker of a request and computes the paging info for a list of the given length
+func markerPage(r *http.Request, length int) (int, globus.MarkerPaging) {
+	marker, _ := strconv.Atoi(r.URL.Query().Get("marker"))
+	if marker < 0 {
+		marker = 0
+	}
+	paging := globus.MarkerPaging{Marker: uint(marker)}
+	if marker+markerPageSize < length {
+		next := uint(marker + markerPageSize)
+		paging.NextMarker = &next
+	}
+	return marker, paging
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "ClientError.BadRequest", err.Error())
+		return false
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		writeError(w, http.StatusBadRequest, "ClientError.BadRequest", "Invalid JSON: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func deref(b *bool) bool {
+	return b != nil && *b
+}
@@ -0,0 +1,145 @@
+package manifest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Parses transfer items in the batch input format of the official globus-cli ("globus transfer --batch").
+// Every line contains a source and a destination path followed by optional flags:
+//
+//	SOURCE_PATH DEST_PATH [--recursive|-r|--no-recursive] [--external-checksum SUM] [--checksum-algorithm ALGO]
+//
+// Arguments are split like in a POSIX shell, so paths containing spaces can be quoted or escaped.
+// Arguments starting with "-" are options, even if quoted; paths starting with "-" have to follow
+// a "--", which ends the options of the line.
+// Empty lines and everything following a "#" at the beginning of a word are ignored.
+// Relative paths are meant to be resolved against the base paths of a Manifest.
+func ParseBatch(r io.Reader) (items []Item, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		args, err := splitShellWords(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("batch line %d: %v", lineNo, err)
+		}
+		if len(args) == 0 {
+			continue
+		}
+		item, err := parseBatchLine(args)
+		if err != nil {
+			return nil, fmt.Errorf("batch line %d: %v", lineNo, err)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func parseBatchLine(args []string) (item Item, err error) {
+	var paths []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			paths = append(paths, args[i+1:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			paths = append(paths, arg)
+			continue
+		}
+
+		switch name {
+		case "--recursive", "-r":
+			item.Recursive = true
+			continue
+		case "--no-recursive":
+			item.Recursive = false
+			continue
+		case "--external-checksum", "--checksum-algorithm":
+		default:
+			return Item{}, fmt.Errorf("unknown option '%s' (use '--' before paths starting with '-')", arg)
+		}
+
+		// options with a value
+		if !hasValue {
+			if i+1 >= len(args) {
+				return Item{}, fmt.Errorf("option '%s' requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "--external-checksum" {
+			item.ExternalChecksum = value
+		} else {
+			item.ChecksumAlgorithm = value
+		}
+	}
+
+	if len(paths) != 2 {
+		return Item{}, fmt.Errorf("expected a source and a destination path, got %d paths", len(paths))
+	}
+	item.Source = paths[0]
+	item.Destination = paths[1]
+	return item, nil
+}
+
+// splits a line into words following POSIX shell quoting rules (without any expansion)
+func splitShellWords(line string) (words []string, err error) {
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '#' && !inWord:
+			return words, nil
+		case c == '\\':
+			if i+1 >= len(line) {
+				return nil, fmt.Errorf("no character to escape at the end of the line")
+			}
+			i++
+			word.WriteByte(line[i])
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\\\"$`", line[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
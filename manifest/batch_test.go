@@ -0,0 +1,130 @@
+package manifest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBatch(t *testing.T) {
+	// in the style of the examples of "globus transfer --help"
+	input := `# transfer a file
+~/file1.txt ~/file1.txt
+
+# transfer a directory, with a trailing comment
+~/mydir/ ~/mydir/ --recursive  # the whole directory
+/projects/run17/raw /archive/run17/raw -r
+"/data/path with spaces.txt" '/archive/path with spaces.txt'
+/data/escaped\ space.txt /archive/escaped\ space.txt
+/data/file2.dat /archive/file2.dat --external-checksum d41d8cd98f00b204e9800998ecf8427e --checksum-algorithm MD5
+/data/file3.dat /archive/file3.dat --external-checksum=9e107d9d372bb6826bd81d3542a419d6 --checksum-algorithm=MD5
+	relative/a.txt	relative/b.txt	--no-recursive
+`
+	items, err := ParseBatch(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Item{
+		{Source: "~/file1.txt", Destination: "~/file1.txt"},
+		{Source: "~/mydir/", Destination: "~/mydir/", Recursive: true},
+		{Source: "/projects/run17/raw", Destination: "/archive/run17/raw", Recursive: true},
+		{Source: "/data/path with spaces.txt", Destination: "/archive/path with spaces.txt"},
+		{Source: "/data/escaped space.txt", Destination: "/archive/escaped space.txt"},
+		{Source: "/data/file2.dat", Destination: "/archive/file2.dat", ExternalChecksum: "d41d8cd98f00b204e9800998ecf8427e", ChecksumAlgorithm: "MD5"},
+		{Source: "/data/file3.dat", Destination: "/archive/file3.dat", ExternalChecksum: "9e107d9d372bb6826bd81d3542a419d6", ChecksumAlgorithm: "MD5"},
+		{Source: "relative/a.txt", Destination: "relative/b.txt"},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("got %+v, want %+v", items, want)
+	}
+}
+
+func TestParseBatchLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want Item
+		err  bool
+	}{
+		{line: "a b", want: Item{Source: "a", Destination: "b"}},
+		{line: "-r a b", want: Item{Source: "a", Destination: "b", Recursive: true}},
+		{line: "a b --recursive --no-recursive", want: Item{Source: "a", Destination: "b"}},
+		{line: "-- -a -b", want: Item{Source: "-a", Destination: "-b"}},
+		{line: "--recursive -- -dir/ b/", want: Item{Source: "-dir/", Destination: "b/", Recursive: true}},
+		{line: "a -- -b", want: Item{Source: "a", Destination: "-b"}},
+		{line: "- b", want: Item{Source: "-", Destination: "b"}},
+		{line: "-a b", err: true},
+		{line: "'-a' b", err: true}, // quoting doesn't make an option a path, like in the shell
+		{line: "a b c", err: true},
+		{line: "a", err: true},
+		{line: "a b --external-checksum", err: true},
+		{line: "a b --sync-level 3", err: true},
+		{line: "-- a b --recursive", err: true},
+	}
+	for _, test := range tests {
+		args, err := splitShellWords(test.line)
+		if err != nil {
+			t.Fatalf("%s: %v", test.line, err)
+		}
+		item, err := parseBatchLine(args)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: no error, got %+v", test.line, item)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.line, err)
+		} else if item != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.line, item, test.want)
+		}
+	}
+}
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+		err  bool
+	}{
+		{line: "", want: nil},
+		{line: "   \t ", want: nil},
+		{line: "# only a comment", want: nil},
+		{line: "a  b\tc\r", want: []string{"a", "b", "c"}},
+		{line: `"x y" z`, want: []string{"x y", "z"}},
+		{line: `a\ b c`, want: []string{"a b", "c"}},
+		{line: `'it'\''s' d`, want: []string{"it's", "d"}},
+		{line: `'a\b' c`, want: []string{`a\b`, "c"}},
+		{line: `"a\\b\"c" d`, want: []string{`a\b"c`, "d"}},
+		{line: `"a\b" c`, want: []string{`a\b`, "c"}},
+		{line: `"" b`, want: []string{"", "b"}},
+		{line: `pre"mid dle"'post' x`, want: []string{"premid dlepost", "x"}},
+		{line: "a #b c", want: []string{"a"}},
+		{line: "a#b c", want: []string{"a#b", "c"}},
+		{line: `\#a b`, want: []string{"#a", "b"}},
+		{line: `"#a" b`, want: []string{"#a", "b"}},
+		{line: `"unterminated b`, err: true},
+		{line: `'unterminated b`, err: true},
+		{line: `a b \`, err: true},
+	}
+	for _, test := range tests {
+		words, err := splitShellWords(test.line)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: no error, got %q", test.line, words)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.line, err)
+		} else if !reflect.DeepEqual(words, test.want) {
+			t.Errorf("%q: got %q, want %q", test.line, words, test.want)
+		}
+	}
+}
+
+func TestParseBatchErrorLine(t *testing.T) {
+	_, err := ParseBatch(strings.NewReader("a b\n\n-x y\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("got %v, want an error on line 3", err)
+	}
+}
@@ -0,0 +1,334 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// item columns of the CSV format, only source and destination are mandatory
+var csvColumns = []string{"source", "destination", "recursive", "symlink", "external_checksum", "checksum_algorithm"}
+
+// reads and parses a manifest file, the format is determined by the extension
+func ReadFile(path string) (Manifest, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer file.Close()
+	return Parse(file, format)
+}
+
+// Parses a manifest in the given format. Unknown fields are rejected.
+func Parse(r io.Reader, format Format) (m Manifest, err error) {
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&m)
+	case FormatYAML:
+		decoder := yaml.NewDecoder(r)
+		decoder.KnownFields(true)
+		err = decoder.Decode(&m)
+	case FormatCSV:
+		m, err = parseCSV(r)
+	default:
+		return Manifest{}, fmt.Errorf("unknown manifest format '%s'", format)
+	}
+	if err != nil {
+		return Manifest{}, fmt.Errorf("could not parse %s manifest: %v", format, err)
+	}
+	return m, nil
+}
+
+// writes a manifest to a file, the format is determined by the extension
+func WriteFile(path string, m Manifest) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, m, format); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// serializes a manifest in the given format
+func Write(w io.Writer, m Manifest, format Format) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(m)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(m); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case FormatCSV:
+		return writeCSV(w, m)
+	default:
+		return fmt.Errorf("unknown manifest format '%s'", format)
+	}
+}
+
+// The CSV format starts with comment lines of the form "# key: value" setting the transfer-wide
+// fields (endpoints, base paths, label and options, using their JSON names; filter rules as a JSON
+// list), followed by a header row naming the item columns and one row per item. Values starting
+// with a double quote are JSON strings, for values with line breaks or surrounding spaces. Lines
+// starting with '#' after the header row are items, not directives.
+func parseCSV(r io.Reader) (m Manifest, err error) {
+	reader := bufio.NewReader(r)
+
+	// directives, up to the header row (blank lines in between are fine)
+	var directives []string
+	var rest io.Reader = reader
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return Manifest{}, err
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			// the header row, handed on to the csv reader
+			rest = io.MultiReader(strings.NewReader(line), reader)
+			break
+		}
+		if trimmed != "" {
+			directives = append(directives, strings.TrimSpace(strings.TrimPrefix(trimmed, "#")))
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	for _, directive := range directives {
+		key, value, found := strings.Cut(directive, ":")
+		if !found {
+			if directive == "" {
+				continue
+			}
+			return Manifest{}, fmt.Errorf("invalid directive '%s', expected 'key: value'", directive)
+		}
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			if err := json.Unmarshal([]byte(value), &value); err != nil {
+				return Manifest{}, fmt.Errorf("invalid quoted value in directive '%s': %v", directive, err)
+			}
+		}
+		if err := m.setField(strings.TrimSpace(key), value); err != nil {
+			return Manifest{}, err
+		}
+	}
+
+	// items
+	csvReader := csv.NewReader(rest)
+	csvReader.TrimLeadingSpace = true
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return Manifest{}, err
+	}
+	if len(records) == 0 {
+		return m, nil
+	}
+
+	header := records[0]
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(csvColumns, name) {
+			return Manifest{}, fmt.Errorf("unknown column '%s'", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["source"]; !ok {
+		return Manifest{}, fmt.Errorf("missing column 'source'")
+	}
+	if _, ok := columns["destination"]; !ok {
+		return Manifest{}, fmt.Errorf("missing column 'destination'")
+	}
+
+	for line, record := range records[1:] {
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		item := Item{
+			Source:            get("source"),
+			Destination:       get("destination"),
+			ExternalChecksum:  get("external_checksum"),
+			ChecksumAlgorithm: get("checksum_algorithm"),
+		}
+		if item.Recursive, err = parseCSVBool(get("recursive")); err != nil {
+			return Manifest{}, fmt.Errorf("row %d: %v", line+1, err)
+		}
+		if item.Symlink, err = parseCSVBool(get("symlink")); err != nil {
+			return Manifest{}, fmt.Errorf("row %d: %v", line+1, err)
+		}
+		m.Items = append(m.Items, item)
+	}
+	return m, nil
+}
+
+func writeCSV(w io.Writer, m Manifest) error {
+	var directives [][2]string
+	for _, directive := range [][2]string{
+		{"source_endpoint", m.SourceEndpoint},
+		{"destination_endpoint", m.DestinationEndpoint},
+		{"source_base_path", m.SourceBasePath},
+		{"destination_base_path", m.DestinationBasePath},
+	} {
+		if directive[1] != "" {
+			directives = append(directives, directive)
+		}
+	}
+	if m.Label != nil {
+		directives = append(directives, [2]string{"label", *m.Label})
+	}
+	options := reflect.ValueOf(m.Options)
+	for i := 0; i < options.NumField(); i++ {
+		field := options.Field(i)
+		if field.IsNil() {
+			continue
+		}
+		if field.Kind() == reflect.Slice {
+			// lists (the filter rules) are written as JSON
+			value, err := json.Marshal(field.Interface())
+			if err != nil {
+				return err
+			}
+			directives = append(directives, [2]string{jsonName(options.Type().Field(i)), string(value)})
+			continue
+		}
+		directives = append(directives, [2]string{jsonName(options.Type().Field(i)), fmt.Sprint(field.Elem().Interface())})
+	}
+	for _, directive := range directives {
+		if _, err := fmt.Fprintf(w, "# %s: %s\n", directive[0], quoteDirectiveValue(directive[1])); err != nil {
+			return err
+		}
+	}
+
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, item := range m.Items {
+		record := []string{
+			item.Source,
+			item.Destination,
+			strconv.FormatBool(item.Recursive),
+			strconv.FormatBool(item.Symlink),
+			item.ExternalChecksum,
+			item.ChecksumAlgorithm,
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// Quotes a directive value as a JSON string if it wouldn't be read back as-is otherwise, i.e. if it
+// is empty, contains control characters (e.g. line breaks), has surrounding spaces or starts with
+// a double quote. Lists are already JSON and start with '['.
+func quoteDirectiveValue(value string) string {
+	needsQuotes := value == "" || strings.TrimSpace(value) != value || strings.HasPrefix(value, `"`) ||
+		strings.ContainsFunc(value, unicode.IsControl)
+	if !needsQuotes {
+		return value
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value) // can't fail for a string
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// sets a transfer-wide field of the manifest by its JSON name
+func (m *Manifest) setField(key string, value string) error {
+	switch key {
+	case "source_endpoint":
+		m.SourceEndpoint = value
+	case "destination_endpoint":
+		m.DestinationEndpoint = value
+	case "source_base_path":
+		m.SourceBasePath = value
+	case "destination_base_path":
+		m.DestinationBasePath = value
+	case "label":
+		m.Label = &value
+	default:
+		return m.Options.set(key, value)
+	}
+	return nil
+}
+
+func (o *Options) set(key string, value string) error {
+	options := reflect.ValueOf(o).Elem()
+	for i := 0; i < options.NumField(); i++ {
+		if jsonName(options.Type().Field(i)) != key {
+			continue
+		}
+
+		field := options.Field(i)
+		if field.Kind() == reflect.Slice {
+			if err := json.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
+				return fmt.Errorf("invalid value for '%s': %v", key, err)
+			}
+			return nil
+		}
+		ptr := reflect.New(field.Type().Elem())
+		switch ptr.Elem().Kind() {
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid value for '%s': %v", key, err)
+			}
+			ptr.Elem().SetBool(b)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid value for '%s': %v", key, err)
+			}
+			ptr.Elem().SetInt(int64(n))
+		default:
+			ptr.Elem().SetString(value)
+		}
+		field.Set(ptr)
+		return nil
+	}
+	return fmt.Errorf("unknown field '%s'", key)
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
+
+func parseCSVBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
@@ -0,0 +1,218 @@
+package manifest
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testManifest() Manifest {
+	label := "run 17: \"first\" pass\nsecond line"
+	deadline := "2024-09-01T00:00:00+00:00"
+	syncLevel := 3
+	verify := true
+	encrypt := false
+	return Manifest{
+		SourceEndpoint:      "6f1b0c3e-0000-4000-8000-00000000000a",
+		DestinationEndpoint: "6f1b0c3e-0000-4000-8000-00000000000b",
+		SourceBasePath:      "/data/run17/",
+		DestinationBasePath: "~/archive/run17/",
+		Label:               &label,
+		Options: Options{
+			Deadline:       &deadline,
+			SyncLevel:      &syncLevel,
+			VerifyChecksum: &verify,
+			EncryptData:    &encrypt,
+			FilterRules: []FilterRule{
+				{Method: "exclude", Type: "file", Name: "*.tmp"},
+				{Method: "include", Name: "frames, \"raw\""},
+			},
+		},
+		Items: []Item{
+			{Source: "a.txt", Destination: "a.txt"},
+			{Source: "#x", Destination: "/y"},
+			{Source: "with, comma", Destination: "with \"quotes\""},
+			{Source: "sub/", Destination: "sub/", Recursive: true},
+			{Source: "link", Destination: "link", Symlink: true},
+			{Source: "b.bin", Destination: "b.bin", ExternalChecksum: "d41d8cd98f00b204e9800998ecf8427e", ChecksumAlgorithm: "MD5"},
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatYAML, FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			want := testManifest()
+			var buf bytes.Buffer
+			if err := Write(&buf, want, format); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Parse(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestWriteReadFile(t *testing.T) {
+	want := testManifest()
+	for _, name := range []string{"m.json", "m.yml", "m.yaml", "m.CSV"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := WriteFile(path, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, want %+v", name, got, want)
+		}
+	}
+	if err := WriteFile(filepath.Join(t.TempDir(), "m.txt"), want); err == nil {
+		t.Error("a manifest was written in an unknown format")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	label := " padded "
+	m := Manifest{
+		SourceEndpoint: "src",
+		Label:          &label,
+		Items:          []Item{{Source: "#x", Destination: "/y"}},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, m, FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	want := `# source_endpoint: src
+# label: " padded "
+source,destination,recursive,symlink,external_checksum,checksum_algorithm
+#x,/y,false,false,,
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	input := `
+# source_endpoint: src
+#destination_endpoint:dst
+# label: "two\nlines"
+# store_base_path_info: true
+# sync_level: 2
+# filter_rules: [{"method": "exclude", "name": "*.tmp"}]
+
+source, destination, recursive
+#x, /y
+"quoted, path", /z, true
+`
+	m, err := Parse(strings.NewReader(input), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.SourceEndpoint != "src" || m.DestinationEndpoint != "dst" {
+		t.Errorf("got endpoints '%s' and '%s'", m.SourceEndpoint, m.DestinationEndpoint)
+	}
+	if m.Label == nil || *m.Label != "two\nlines" {
+		t.Errorf("got label %v, want the unquoted label", m.Label)
+	}
+	if m.Options.StoreBasePathInfo == nil || !*m.Options.StoreBasePathInfo {
+		t.Error("store_base_path_info isn't set")
+	}
+	if m.Options.SyncLevel == nil || *m.Options.SyncLevel != 2 {
+		t.Error("sync_level isn't 2")
+	}
+	if len(m.Options.FilterRules) != 1 || m.Options.FilterRules[0].Name != "*.tmp" {
+		t.Errorf("got filter rules %+v", m.Options.FilterRules)
+	}
+	wantItems := []Item{
+		{Source: "#x", Destination: "/y"},
+		{Source: "quoted, path", Destination: "/z", Recursive: true},
+	}
+	if !reflect.DeepEqual(m.Items, wantItems) {
+		t.Errorf("got items %+v, want %+v", m.Items, wantItems)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+	}{
+		{"unknown JSON field", FormatJSON, `{"source_endpoint": "src", "sorce": "x"}`},
+		{"unknown YAML field", FormatYAML, "source_endpoint: src\nsorce: x\n"},
+		{"unknown directive", FormatCSV, "# sorce_endpoint: src\nsource,destination\n"},
+		{"directive without value", FormatCSV, "# source_endpoint\nsource,destination\n"},
+		{"invalid bool option", FormatCSV, "# encrypt_data: maybe\nsource,destination\n"},
+		{"invalid int option", FormatCSV, "# sync_level: high\nsource,destination\n"},
+		{"invalid filter rules", FormatCSV, "# filter_rules: exclude *.tmp\nsource,destination\n"},
+		{"invalid quoted value", FormatCSV, "# label: \"unterminated\nsource,destination\n"},
+		{"unknown column", FormatCSV, "source,destination,size\na,b,1\n"},
+		{"missing destination column", FormatCSV, "source\na\n"},
+		{"invalid recursive", FormatCSV, "source,destination,recursive\na,b,yes\n"},
+		{"unknown format", Format("xml"), "<manifest/>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(test.input), test.format); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
+
+func TestOptionsSet(t *testing.T) {
+	var o Options
+	for key, value := range map[string]string{
+		"notify_on_failed":  "false",
+		"sync_level":        "1",
+		"deadline":          "2024-09-01",
+		"source_local_user": "alice",
+		"filter_rules":      `[{"method": "include", "type": "dir", "name": "raw"}]`,
+	} {
+		if err := o.set(key, value); err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+	}
+	if o.NotifyOnFailed == nil || *o.NotifyOnFailed || o.SyncLevel == nil || *o.SyncLevel != 1 ||
+		o.Deadline == nil || *o.Deadline != "2024-09-01" || o.SourceLocalUser == nil || *o.SourceLocalUser != "alice" {
+		t.Errorf("got options %+v", o)
+	}
+	if len(o.FilterRules) != 1 || o.FilterRules[0] != (FilterRule{Method: "include", Type: "dir", Name: "raw"}) {
+		t.Errorf("got filter rules %+v", o.FilterRules)
+	}
+	if o.NotifyOnSucceeded != nil || o.EncryptData != nil {
+		t.Error("options that weren't set aren't nil")
+	}
+	if err := o.set("label", "x"); err == nil {
+		t.Error("a manifest field was set as an option")
+	}
+}
+
+func TestQuoteDirectiveValue(t *testing.T) {
+	tests := map[string]string{
+		"plain value":   "plain value",
+		"":              `""`,
+		" padded":       `" padded"`,
+		"two\nlines":    `"two\nlines"`,
+		`"quoted"`:      `"\"quoted\""`,
+		"a <b> & c":     "a <b> & c",
+		"tab\tinside":   `"tab\tinside"`,
+		`[{"a": "b"}]`:  `[{"a": "b"}]`,
+		"colon: inside": "colon: inside",
+	}
+	for value, want := range tests {
+		if got := quoteDirectiveValue(value); got != want {
+			t.Errorf("quoteDirectiveValue(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
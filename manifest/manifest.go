@@ -0,0 +1,226 @@
+// Package manifest describes whole Globus transfers in files.
+//
+// A manifest contains the endpoints, the transfer options and the list of items
+// to transfer with their per-item settings. It can be stored as JSON, YAML or CSV
+// and converted to and from a globus.Transfer.
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/remotepath"
+)
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatCSV  Format = "csv"
+)
+
+type Manifest struct {
+	SourceEndpoint      string  `json:"source_endpoint" yaml:"source_endpoint"`
+	DestinationEndpoint string  `json:"destination_endpoint" yaml:"destination_endpoint"`
+	SourceBasePath      string  `json:"source_base_path,omitempty" yaml:"source_base_path,omitempty"`           // prefix of relative item sources
+	DestinationBasePath string  `json:"destination_base_path,omitempty" yaml:"destination_base_path,omitempty"` // prefix of relative item destinations
+	Label               *string `json:"label,omitempty" yaml:"label,omitempty"`
+	Options             Options `json:"options,omitempty" yaml:"options,omitempty"`
+	Items               []Item  `json:"items" yaml:"items"`
+}
+
+// transfer-wide options, named like the corresponding fields of the Transfer API
+type Options struct {
+	NotifyOnSucceeded      *bool        `json:"notify_on_succeeded,omitempty" yaml:"notify_on_succeeded,omitempty"`
+	NotifyOnFailed         *bool        `json:"notify_on_failed,omitempty" yaml:"notify_on_failed,omitempty"`
+	NotifyOnInactive       *bool        `json:"notify_on_inactive,omitempty" yaml:"notify_on_inactive,omitempty"`
+	Deadline               *string      `json:"deadline,omitempty" yaml:"deadline,omitempty"`
+	StoreBasePathInfo      *bool        `json:"store_base_path_info,omitempty" yaml:"store_base_path_info,omitempty"`
+	EncryptData            *bool        `json:"encrypt_data,omitempty" yaml:"encrypt_data,omitempty"`
+	SyncLevel              *int         `json:"sync_level,omitempty" yaml:"sync_level,omitempty"`
+	VerifyChecksum         *bool        `json:"verify_checksum,omitempty" yaml:"verify_checksum,omitempty"`
+	PreserveTimestamp      *bool        `json:"preserve_timestamp,omitempty" yaml:"preserve_timestamp,omitempty"`
+	DeleteDestinationExtra *bool        `json:"delete_destination_extra,omitempty" yaml:"delete_destination_extra,omitempty"`
+	SkipSourceErrors       *bool        `json:"skip_source_errors,omitempty" yaml:"skip_source_errors,omitempty"`
+	FailOnQuotaErrors      *bool        `json:"fail_on_quota_errors,omitempty" yaml:"fail_on_quota_errors,omitempty"`
+	SourceLocalUser        *string      `json:"source_local_user,omitempty" yaml:"source_local_user,omitempty"`
+	DestinationLocalUser   *string      `json:"destination_local_user,omitempty" yaml:"destination_local_user,omitempty"`
+	SkipActivationCheck    *bool        `json:"skip_activation_check,omitempty" yaml:"skip_activation_check,omitempty"`
+	FilterRules            []FilterRule `json:"filter_rules,omitempty" yaml:"filter_rules,omitempty"`
+}
+
+// a rule including or excluding files and directories by name, see the filter_rules of the Transfer API
+type FilterRule struct {
+	Method string `json:"method" yaml:"method"`                 // include or exclude
+	Type   string `json:"type,omitempty" yaml:"type,omitempty"` // file or dir, both if empty
+	Name   string `json:"name" yaml:"name"`                     // a pattern, e.g. "*.tmp"
+}
+
+type Item struct {
+	Source            string `json:"source" yaml:"source"`
+	Destination       string `json:"destination" yaml:"destination"`
+	Recursive         bool   `json:"recursive,omitempty" yaml:"recursive,omitempty"`
+	Symlink           bool   `json:"symlink,omitempty" yaml:"symlink,omitempty"`
+	ExternalChecksum  string `json:"external_checksum,omitempty" yaml:"external_checksum,omitempty"`
+	ChecksumAlgorithm string `json:"checksum_algorithm,omitempty" yaml:"checksum_algorithm,omitempty"`
+}
+
+// guesses the format of a manifest file from its extension
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".csv":
+		return FormatCSV, nil
+	default:
+		return "", fmt.Errorf("unknown manifest format for file '%s'", path)
+	}
+}
+
+// Converts the manifest into a transfer document and validates it.
+// Relative item paths are resolved against the base paths and must stay inside of them.
+func (m Manifest) Transfer() (globus.Transfer, error) {
+	transfer := globus.Transfer{
+		CommonTransfer: globus.CommonTransfer{
+			DataType:            "transfer",
+			Label:               m.Label,
+			NotifyOnSucceeded:   m.Options.NotifyOnSucceeded,
+			NotifyOnFailed:      m.Options.NotifyOnFailed,
+			NotifyOnInactive:    m.Options.NotifyOnInactive,
+			Deadline:            m.Options.Deadline,
+			StoreBasePathInfo:   m.Options.StoreBasePathInfo,
+			SkipActivationCheck: m.Options.SkipActivationCheck,
+		},
+		SourceEndpoint:         m.SourceEndpoint,
+		DestinationEndpoint:    m.DestinationEndpoint,
+		EncryptData:            m.Options.EncryptData,
+		SyncLevel:              m.Options.SyncLevel,
+		VerifyChecksum:         m.Options.VerifyChecksum,
+		PreserveTimestamp:      m.Options.PreserveTimestamp,
+		DeleteDestinationExtra: m.Options.DeleteDestinationExtra,
+		SkipSourceErrors:       m.Options.SkipSourceErrors,
+		FailOnQuotaErrors:      m.Options.FailOnQuotaErrors,
+		SourceLocalUser:        m.Options.SourceLocalUser,
+		DestinationLocalUser:   m.Options.DestinationLocalUser,
+	}
+
+	if m.Options.FilterRules != nil {
+		rules := make([]globus.FilterRule, len(m.Options.FilterRules))
+		for i, rule := range m.Options.FilterRules {
+			rules[i] = globus.FilterRule{DataType: "filter_rule", Method: rule.Method, Type: rule.Type, Name: rule.Name}
+		}
+		transfer.FilterRules = &rules
+	}
+
+	for i, item := range m.Items {
+		tItem, err := m.transferItem(item)
+		if err != nil {
+			return globus.Transfer{}, fmt.Errorf("manifest item %d: %v", i+1, err)
+		}
+		transfer.Data = append(transfer.Data, tItem)
+	}
+
+	if err := globus.ValidateTransfer(transfer); err != nil {
+		return globus.Transfer{}, fmt.Errorf("invalid manifest: %v", err)
+	}
+	return transfer, nil
+}
+
+func (m Manifest) transferItem(item Item) (globus.TransferItem, error) {
+	source, err := resolvePath(m.SourceBasePath, item.Source)
+	if err != nil {
+		return globus.TransferItem{}, err
+	}
+	destination, err := resolvePath(m.DestinationBasePath, item.Destination)
+	if err != nil {
+		return globus.TransferItem{}, err
+	}
+
+	tItem := globus.TransferItem{
+		DataType:        "transfer_item",
+		SourcePath:      source,
+		DestinationPath: destination,
+	}
+	if item.Symlink {
+		if item.Recursive {
+			return globus.TransferItem{}, fmt.Errorf("'%s' can't be a symlink and recursive at the same time", item.Source)
+		}
+		tItem.DataType = "transfer_symlink_item"
+	}
+	if item.Recursive {
+		recursive := true
+		tItem.Recursive = &recursive
+	}
+	if item.ExternalChecksum != "" {
+		tItem.ExternalChecksum = &item.ExternalChecksum
+	}
+	if item.ChecksumAlgorithm != "" {
+		tItem.ChecksumAlgorithm = &item.ChecksumAlgorithm
+	}
+	return tItem, nil
+}
+
+func resolvePath(base string, p string) (string, error) {
+	if p == "" {
+		return "", fmt.Errorf("empty path")
+	}
+	if base == "" {
+		return remotepath.Clean(p), nil
+	}
+	return remotepath.JoinWithin(base, p)
+}
+
+// Creates a manifest describing a transfer. Item paths are kept as they are in the transfer,
+// submission-specific fields (e.g. the submission id) are dropped.
+func FromTransfer(transfer globus.Transfer) Manifest {
+	m := Manifest{
+		SourceEndpoint:      transfer.SourceEndpoint,
+		DestinationEndpoint: transfer.DestinationEndpoint,
+		Label:               transfer.Label,
+		Options: Options{
+			NotifyOnSucceeded:      transfer.NotifyOnSucceeded,
+			NotifyOnFailed:         transfer.NotifyOnFailed,
+			NotifyOnInactive:       transfer.NotifyOnInactive,
+			Deadline:               transfer.Deadline,
+			StoreBasePathInfo:      transfer.StoreBasePathInfo,
+			EncryptData:            transfer.EncryptData,
+			SyncLevel:              transfer.SyncLevel,
+			VerifyChecksum:         transfer.VerifyChecksum,
+			PreserveTimestamp:      transfer.PreserveTimestamp,
+			DeleteDestinationExtra: transfer.DeleteDestinationExtra,
+			SkipSourceErrors:       transfer.SkipSourceErrors,
+			FailOnQuotaErrors:      transfer.FailOnQuotaErrors,
+			SourceLocalUser:        transfer.SourceLocalUser,
+			DestinationLocalUser:   transfer.DestinationLocalUser,
+			SkipActivationCheck:    transfer.SkipActivationCheck,
+		},
+	}
+	if transfer.FilterRules != nil {
+		m.Options.FilterRules = []FilterRule{}
+		for _, rule := range *transfer.FilterRules {
+			m.Options.FilterRules = append(m.Options.FilterRules, FilterRule{Method: rule.Method, Type: rule.Type, Name: rule.Name})
+		}
+	}
+
+	for _, tItem := range transfer.Data {
+		item := Item{
+			Source:      tItem.SourcePath,
+			Destination: tItem.DestinationPath,
+			Recursive:   tItem.Recursive != nil && *tItem.Recursive,
+			Symlink:     tItem.DataType == "transfer_symlink_item",
+		}
+		if tItem.ExternalChecksum != nil {
+			item.ExternalChecksum = *tItem.ExternalChecksum
+		}
+		if tItem.ChecksumAlgorithm != nil {
+			item.ChecksumAlgorithm = *tItem.ChecksumAlgorithm
+		}
+		m.Items = append(m.Items, item)
+	}
+	return m
+}
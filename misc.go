@@ -1,4 +1,41 @@
 package globus
 
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
 // helper funcs.
 func boolPointer(v bool) *bool { return &v }
+
+func stringPointer(v string) *string { return &v }
+
+// generates a random (version 4) UUID string
+func randomUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// reports whether a string has the form of a UUID, e.g. an identity id
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return false
+			}
+		case !strings.ContainsRune("0123456789abcdefABCDEF", c):
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+// Package remotepath handles paths on Globus collections.
+//
+// Globus paths always use forward slashes and can either be absolute ("/data/x"),
+// relative to the home directory of the user on the collection ("~/data/x") or
+// relative to some base path. A trailing slash marks a directory and is kept by
+// all functions of this package.
+package remotepath
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// returned when a path leaves the base path it should be contained in
+var ErrEscapesBase = errors.New("path escapes its base path")
+
+// splits a path into its root ("/", "~/" or "" for relative paths) and the remainder
+func splitRoot(p string) (root string, rest string) {
+	switch {
+	case p == "~":
+		return "~/", ""
+	case strings.HasPrefix(p, "~/"):
+		return "~/", strings.TrimLeft(p[2:], "/")
+	case strings.HasPrefix(p, "/"):
+		return "/", strings.TrimLeft(p, "/")
+	default:
+		return "", p
+	}
+}
+
+// true if the path is relative to the user's home directory on the collection
+func IsHomeRelative(p string) bool {
+	return p == "~" || strings.HasPrefix(p, "~/")
+}
+
+// true if the path is absolute or home-relative, i.e. it doesn't depend on a base path
+func IsRooted(p string) bool {
+	return strings.HasPrefix(p, "/") || IsHomeRelative(p)
+}
+
+// true if the path denotes a directory (has a trailing slash)
+func IsDir(p string) bool {
+	return strings.HasSuffix(p, "/")
+}
+
+// Cleans a path lexically (see path.Clean), keeping the home prefix and the trailing slash.
+// ".." elements can't go above "/", but are kept above "~", as the home directory isn't the
+// root of the collection: "~/../x" is a sibling of the home directory.
+func Clean(p string) string {
+	if p == "" {
+		return ""
+	}
+
+	root, rest := splitRoot(p)
+	var cleaned string
+	switch root {
+	case "/":
+		cleaned = root + strings.TrimPrefix(path.Clean("/"+rest), "/")
+	case "~/":
+		rest = path.Clean(rest)
+		if rest == "." {
+			rest = ""
+		}
+		cleaned = root + rest
+	default:
+		cleaned = path.Clean(rest)
+	}
+
+	if IsDir(p) && !IsDir(cleaned) {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// Joins path elements with a single slash and cleans the result.
+// Empty elements are ignored, the result is a directory if the last element is one.
+func Join(elem ...string) string {
+	var parts []string
+	for _, e := range elem {
+		if e != "" {
+			parts = append(parts, e)
+		}
+	}
+	return Clean(strings.Join(parts, "/"))
+}
+
+// returns the path with a trailing slash
+func AsDir(p string) string {
+	p = Clean(p)
+	if p == "" || IsDir(p) {
+		return p
+	}
+	return p + "/"
+}
+
+// returns the path without a trailing slash (except for roots)
+func TrimDir(p string) string {
+	p = Clean(p)
+	if p == "/" || p == "~/" {
+		return p
+	}
+	return strings.TrimSuffix(p, "/")
+}
+
+// Returns the path of target relative to base. Both have to be of the same kind
+// (absolute, home-relative or relative) and target must be inside base.
+// The trailing slash of target is kept.
+func Rel(base string, target string) (string, error) {
+	baseRoot, baseRest := splitRoot(TrimDir(base))
+	targetRoot, targetRest := splitRoot(Clean(target))
+	if baseRoot != targetRoot {
+		return "", fmt.Errorf("%w: '%s' is not relative to '%s'", ErrEscapesBase, target, base)
+	}
+	baseRest = strings.TrimSuffix(baseRest, "/")
+	if baseRest == "." {
+		baseRest = ""
+	}
+
+	trimmedTarget := strings.TrimSuffix(targetRest, "/")
+	switch {
+	case trimmedTarget == baseRest:
+		if IsDir(targetRest) {
+			return "./", nil
+		}
+		return ".", nil
+	case baseRest == "":
+		if strings.HasPrefix(targetRest, "../") || trimmedTarget == ".." {
+			return "", fmt.Errorf("%w: '%s' is not inside '%s'", ErrEscapesBase, target, base)
+		}
+		return targetRest, nil
+	case strings.HasPrefix(targetRest, baseRest+"/"):
+		return targetRest[len(baseRest)+1:], nil
+	default:
+		return "", fmt.Errorf("%w: '%s' is not inside '%s'", ErrEscapesBase, target, base)
+	}
+}
+
+// Resolves an entry against a base path and returns the entry's path relative to base.
+// Relative entries are cleaned and must not leave base through "..", rooted entries
+// (absolute or home-relative) must point inside base.
+func RelWithin(base string, entry string) (string, error) {
+	if IsRooted(entry) {
+		return Rel(base, entry)
+	}
+
+	cleaned := Clean(entry)
+	trimmed := strings.TrimSuffix(cleaned, "/")
+	if trimmed == ".." || strings.HasPrefix(trimmed, "../") {
+		return "", fmt.Errorf("%w: '%s' is not inside '%s'", ErrEscapesBase, entry, base)
+	}
+	return cleaned, nil
+}
+
+// Joins an entry to a base path, rejecting entries that escape the base (see RelWithin).
+func JoinWithin(base string, entry string) (string, error) {
+	rel, err := RelWithin(base, entry)
+	if err != nil {
+		return "", err
+	}
+	return Join(base, rel), nil
+}
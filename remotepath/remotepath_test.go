@@ -0,0 +1,123 @@
+package remotepath
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", ""},
+		{"/", "/"},
+		{"//data//x", "/data/x"},
+		{"/data/./x/", "/data/x/"},
+		{"/data/../x", "/x"},
+		{"/../x", "/x"},
+		{"~", "~/"},
+		{"~/", "~/"},
+		{"~//data/x/", "~/data/x/"},
+		{"~/data/../x", "~/x"},
+		{"~/..", "~/.."},
+		{"~/../x", "~/../x"},
+		{"~/data/../../x/", "~/../x/"},
+		{"data/../x", "x"},
+		{"../x", "../x"},
+		{"./", "./"},
+		{".", "."},
+	}
+	for _, test := range tests {
+		if got := Clean(test.path); got != test.want {
+			t.Errorf("Clean(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		elem []string
+		want string
+	}{
+		{[]string{"/data", "x"}, "/data/x"},
+		{[]string{"/data/", "/x/"}, "/data/x/"},
+		{[]string{"~", "x"}, "~/x"},
+		{[]string{"~", "..", "x"}, "~/../x"},
+		{[]string{"/data", "", "x"}, "/data/x"},
+		{[]string{"", "x", "y/"}, "x/y/"},
+		{[]string{"/data", "../x"}, "/x"},
+		{[]string{}, ""},
+	}
+	for _, test := range tests {
+		if got := Join(test.elem...); got != test.want {
+			t.Errorf("Join(%q) = %q, want %q", test.elem, got, test.want)
+		}
+	}
+}
+
+func TestRel(t *testing.T) {
+	tests := []struct {
+		base   string
+		target string
+		want   string // empty: ErrEscapesBase
+	}{
+		{"/data", "/data/x", "x"},
+		{"/data/", "/data/x/y/", "x/y/"},
+		{"/data", "/data", "."},
+		{"/data", "/data/", "./"},
+		{"/", "/x", "x"},
+		{"~", "~/x", "x"},
+		{"~/data", "~/data/x", "x"},
+		{"data", "data/x", "x"},
+		{".", "x", "x"},
+		{"/data", "/database", ""},
+		{"/data", "/x", ""},
+		{"/data", "~/data/x", ""},
+		{"~/data", "/data/x", ""},
+		{"~/data", "~/../data/x", ""},
+		{"~", "~/../x", ""},
+		{".", "../x", ""},
+	}
+	for _, test := range tests {
+		got, err := Rel(test.base, test.target)
+		if test.want == "" {
+			if !errors.Is(err, ErrEscapesBase) {
+				t.Errorf("Rel(%q, %q) = %q, %v, want ErrEscapesBase", test.base, test.target, got, err)
+			}
+		} else if err != nil || got != test.want {
+			t.Errorf("Rel(%q, %q) = %q, %v, want %q", test.base, test.target, got, err, test.want)
+		}
+	}
+}
+
+func TestRelWithin(t *testing.T) {
+	tests := []struct {
+		base  string
+		entry string
+		want  string // empty: ErrEscapesBase
+	}{
+		{"/data", "x", "x"},
+		{"/data", "./x/../y/", "y/"},
+		{"/data", "/data/x", "x"},
+		{"~/data", "~/data/x", "x"},
+		{"~/data", "x/..", "."},
+		{"/data", "../x", ""},
+		{"/data", "x/../../y", ""},
+		{"/data", "..", ""},
+		{"/data", "/other/x", ""},
+		{"~/data", "~/../data/x", ""},
+		{"~/src", "~/../src/x", ""},
+		{"~", "~/../x", ""},
+	}
+	for _, test := range tests {
+		got, err := RelWithin(test.base, test.entry)
+		if test.want == "" {
+			if !errors.Is(err, ErrEscapesBase) {
+				t.Errorf("RelWithin(%q, %q) = %q, %v, want ErrEscapesBase", test.base, test.entry, got, err)
+			}
+		} else if err != nil || got != test.want {
+			t.Errorf("RelWithin(%q, %q) = %q, %v, want %q", test.base, test.entry, got, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package globus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// scopes of the Globus services
+const (
+	ScopeTransferAll = "urn:globus:auth:scope:transfer.api.globus.org:all"
+
+	ScopeOpenId             = "openid"
+	ScopeProfile            = "profile"
+	ScopeEmail              = "email"
+	ScopeAuthViewIdentities = "urn:globus:auth:scope:auth.globus.org:view_identities"
+
+	ScopeGroupsAll          = "urn:globus:auth:scope:groups.api.globus.org:all"
+	ScopeGroupsViewMyGroups = "urn:globus:auth:scope:groups.api.globus.org:view_my_groups_and_memberships"
+
+	ScopeTimers = "https://auth.globus.org/scopes/524230d7-ea86-4a52-8312-86065a9e0417/timer"
+
+	ScopeFlowsManage    = "https://auth.globus.org/scopes/eec9b274-0c81-4334-bdc2-54e90e689b9a/manage_flows"
+	ScopeFlowsView      = "https://auth.globus.org/scopes/eec9b274-0c81-4334-bdc2-54e90e689b9a/view_flows"
+	ScopeFlowsRun       = "https://auth.globus.org/scopes/eec9b274-0c81-4334-bdc2-54e90e689b9a/run"
+	ScopeFlowsRunStatus = "https://auth.globus.org/scopes/eec9b274-0c81-4334-bdc2-54e90e689b9a/run_status"
+	ScopeFlowsRunManage = "https://auth.globus.org/scopes/eec9b274-0c81-4334-bdc2-54e90e689b9a/run_manage"
+)
+
+// A Globus scope with its dependent scopes, e.g.
+// "urn:globus:auth:scope:transfer.api.globus.org:all[*https://auth.globus.org/scopes/<collection>/data_access]".
+// A dependent scope is requested for the service of its parent scope, so it can act on the user's
+// behalf. Optional scopes (marked with "*") don't fail the request if they can't be granted, e.g.
+// the data access of collections that don't need a consent.
+type Scope struct {
+	Value        string
+	Optional     bool
+	Dependencies []Scope
+}
+
+func NewScope(value string, dependencies ...Scope) Scope {
+	return Scope{Value: value, Dependencies: dependencies}
+}
+
+// returns a copy of the scope marked as optional
+func (s Scope) AsOptional() Scope {
+	s.Optional = true
+	return s
+}
+
+// returns a copy of the scope with additional dependencies, merged with the existing ones
+func (s Scope) WithDependencies(dependencies ...Scope) Scope {
+	s.Dependencies = MergeScopes(append(append([]Scope(nil), s.Dependencies...), dependencies...)...)
+	return s
+}
+
+// serializes the scope in the Globus scope string syntax
+func (s Scope) String() string {
+	var b strings.Builder
+	s.write(&b)
+	return b.String()
+}
+
+func (s Scope) write(b *strings.Builder) {
+	if s.Optional {
+		b.WriteString("*")
+	}
+	b.WriteString(s.Value)
+	if len(s.Dependencies) == 0 {
+		return
+	}
+	b.WriteString("[")
+	for i, dependency := range s.Dependencies {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		dependency.write(b)
+	}
+	b.WriteString("]")
+}
+
+// the scope strings of a list of scopes, e.g. for oauth2.Config.Scopes
+func ScopeStrings(scopes []Scope) []string {
+	strs := make([]string, len(scopes))
+	for i, scope := range scopes {
+		strs[i] = scope.String()
+	}
+	return strs
+}
+
+// parses a single scope string, e.g. "a[*b c[d]]"
+func ParseScope(str string) (Scope, error) {
+	scopes, err := ParseScopes(str)
+	if err != nil {
+		return Scope{}, err
+	}
+	if len(scopes) != 1 {
+		return Scope{}, fmt.Errorf("expected one scope, found %d in '%s'", len(scopes), str)
+	}
+	return scopes[0], nil
+}
+
+// parses a whitespace separated list of scope strings, as in a token response or a space joined
+// scope parameter
+func ParseScopes(str string) ([]Scope, error) {
+	p := scopeParser{input: str}
+	scopes, err := p.parseList(0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scope string '%s': %v", str, err)
+	}
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("invalid scope string '%s': unexpected ']' at position %d", str, p.pos)
+	}
+	return scopes, nil
+}
+
+type scopeParser struct {
+	input string
+	pos   int
+}
+
+// parses scopes until the end of the input or a closing bracket
+func (p *scopeParser) parseList(depth int) ([]Scope, error) {
+	var scopes []Scope
+	for {
+		p.skipSpaces()
+		if p.pos == len(p.input) {
+			if depth > 0 {
+				return nil, fmt.Errorf("missing ']'")
+			}
+			return scopes, nil
+		}
+		if p.input[p.pos] == ']' {
+			return scopes, nil // unbalanced brackets at the top level are reported by ParseScopes
+		}
+		scope, err := p.parseScope(depth)
+		if err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, scope)
+	}
+}
+
+func (p *scopeParser) parseScope(depth int) (Scope, error) {
+	var scope Scope
+	if p.input[p.pos] == '*' {
+		scope.Optional = true
+		p.pos++
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune(" \t\n[]*", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	scope.Value = p.input[start:p.pos]
+	if scope.Value == "" {
+		return Scope{}, fmt.Errorf("missing scope name at position %d", start)
+	}
+
+	if p.pos < len(p.input) && p.input[p.pos] == '[' {
+		p.pos++
+		dependencies, err := p.parseList(depth + 1)
+		if err != nil {
+			return Scope{}, err
+		}
+		p.pos++ // the closing bracket
+		scope.Dependencies = dependencies
+	}
+	// scopes are separated by whitespace, e.g. "a[b]c" and "a*b" are invalid
+	if p.pos < len(p.input) && !strings.ContainsRune(" \t\n]", rune(p.input[p.pos])) {
+		return Scope{}, fmt.Errorf("unexpected '%c' at position %d", p.input[p.pos], p.pos)
+	}
+	return scope, nil
+}
+
+func (p *scopeParser) skipSpaces() {
+	for p.pos < len(p.input) && strings.ContainsRune(" \t\n", rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// Merges scopes with the same value into one, with the union of their dependencies (merged
+// recursively). A merged scope is optional only if all of its occurrences are. The order of the
+// first occurrences is kept.
+func MergeScopes(scopes ...Scope) []Scope {
+	var merged []Scope
+	index := map[string]int{}
+	for _, scope := range scopes {
+		i, ok := index[scope.Value]
+		if !ok {
+			index[scope.Value] = len(merged)
+			scope.Dependencies = MergeScopes(scope.Dependencies...)
+			merged = append(merged, scope)
+			continue
+		}
+		merged[i].Optional = merged[i].Optional && scope.Optional
+		merged[i].Dependencies = MergeScopes(append(merged[i].Dependencies, scope.Dependencies...)...)
+	}
+	return merged
+}
+
+// the scope to access the data of a collection, as a dependency of the transfer scope
+func CollectionDataAccessScope(collectionID string) Scope {
+	return NewScope("https://auth.globus.org/scopes/" + collectionID + "/data_access")
+}
+
+// the scope to access the files of a collection through its HTTPS server
+func CollectionHTTPSScope(collectionID string) Scope {
+	return NewScope("https://auth.globus.org/scopes/" + collectionID + "/https")
+}
+
+// Returns the transfer scope with the optional data access of the given collections as
+// dependencies, so a single consent covers transfers between all of them.
+func TransferScope(collectionIDs ...string) Scope {
+	scope := NewScope(ScopeTransferAll)
+	for _, collectionID := range collectionIDs {
+		if collectionID == "" {
+			continue
+		}
+		scope = scope.WithDependencies(CollectionDataAccessScope(collectionID).AsOptional())
+	}
+	return scope
+}
@@ -0,0 +1,106 @@
+package globus_test
+
+import (
+	"testing"
+
+	"github.com/SwissOpenEM/globus"
+)
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		str  string
+		want []globus.Scope
+	}{
+		{"", nil},
+		{"a", []globus.Scope{globus.NewScope("a")}},
+		{" a \t b\n", []globus.Scope{globus.NewScope("a"), globus.NewScope("b")}},
+		{"*a", []globus.Scope{globus.NewScope("a").AsOptional()}},
+		{"a[b]", []globus.Scope{globus.NewScope("a", globus.NewScope("b"))}},
+		{"a[ *b c[d] ] e", []globus.Scope{
+			globus.NewScope("a", globus.NewScope("b").AsOptional(), globus.NewScope("c", globus.NewScope("d"))),
+			globus.NewScope("e"),
+		}},
+		{"a[]", []globus.Scope{globus.NewScope("a")}},
+		{globus.ScopeTransferAll + "[*https://auth.globus.org/scopes/c1/data_access]", []globus.Scope{
+			globus.NewScope(globus.ScopeTransferAll, globus.CollectionDataAccessScope("c1").AsOptional()),
+		}},
+	}
+	for _, test := range tests {
+		got, err := globus.ParseScopes(test.str)
+		if err != nil {
+			t.Errorf("'%s': %v", test.str, err)
+			continue
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("'%s': got %d scopes, want %d", test.str, len(got), len(test.want))
+			continue
+		}
+		for i := range got {
+			if got[i].String() != test.want[i].String() {
+				t.Errorf("'%s': got scope '%s', want '%s'", test.str, got[i], test.want[i])
+			}
+		}
+	}
+}
+
+func TestParseScopesInvalid(t *testing.T) {
+	for _, str := range []string{
+		"a[b]c",
+		"a*b",
+		"a[b]*c",
+		"a[b][c]",
+		"a[b",
+		"a]",
+		"a[b]]",
+		"[a]",
+		"*",
+		"a[*]",
+	} {
+		if scopes, err := globus.ParseScopes(str); err == nil {
+			t.Errorf("'%s' was parsed as %v", str, globus.ScopeStrings(scopes))
+		}
+	}
+
+	if _, err := globus.ParseScope("a b"); err == nil {
+		t.Error("two scopes were parsed as one")
+	}
+}
+
+func TestScopeString(t *testing.T) {
+	scope := globus.NewScope("a", globus.NewScope("b").AsOptional(), globus.NewScope("c", globus.NewScope("d")))
+	if got, want := scope.String(), "a[*b c[d]]"; got != want {
+		t.Errorf("got '%s', want '%s'", got, want)
+	}
+
+	// the string parses to the same scope
+	parsed, err := globus.ParseScope(scope.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.String() != scope.String() {
+		t.Errorf("got '%s' after parsing, want '%s'", parsed, scope)
+	}
+}
+
+func TestMergeScopes(t *testing.T) {
+	scopes, err := globus.ParseScopes("a[*b] c *d a[b c[x]] *d e a[c[y]]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := globus.ScopeStrings(globus.MergeScopes(scopes...))
+	want := []string{"a[b c[x y]]", "c", "*d", "e"}
+	if len(merged) != len(want) {
+		t.Fatalf("got %v, want %v", merged, want)
+	}
+	for i := range want {
+		if merged[i] != want[i] {
+			t.Errorf("got %v, want %v", merged, want)
+			break
+		}
+	}
+
+	scope := globus.TransferScope("c1", "", "c2", "c1")
+	if got, want := scope.String(), globus.ScopeTransferAll+"[*"+globus.CollectionDataAccessScope("c1").Value+" *"+globus.CollectionDataAccessScope("c2").Value+"]"; got != want {
+		t.Errorf("got transfer scope '%s', want '%s'", got, want)
+	}
+}
@@ -0,0 +1,166 @@
+package globus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Submits a transfer and records it in a task store under a job key. The submission id is
+// allocated and persisted before the transfer is posted, so if the submission fails or the process
+// dies midway, calling this function again with the same job key resubmits with the same id
+// and can't create a duplicate task. Jobs that were already submitted are not submitted again,
+// their record is returned with ErrAlreadySubmitted. In dry-run mode, the store is left untouched.
+func (c GlobusClient) TransferPostTaskTracked(store TaskStore, jobKey string, transfer Transfer) (record TaskRecord, result TransferResult, err error) {
+	if c.dryRun {
+		result, err = c.TransferPostTask(transfer)
+		return TaskRecord{JobKey: jobKey, Status: TaskStatusSubmitting}, result, err
+	}
+
+	record, err = store.Get(jobKey)
+	if err == nil && record.TaskId != "" {
+		return record, TransferResult{}, ErrAlreadySubmitted
+	} else if err != nil && !errors.Is(err, ErrTaskRecordNotFound) {
+		return TaskRecord{}, TransferResult{}, err
+	}
+
+	if record.SubmissionId == "" {
+		if transfer.SubmissionId == "" {
+			transfer.SubmissionId, err = c.getSubmissionId()
+			if err != nil {
+				return TaskRecord{}, TransferResult{}, err
+			}
+		}
+		now := time.Now()
+		record = TaskRecord{
+			JobKey:       jobKey,
+			SubmissionId: transfer.SubmissionId,
+			Status:       TaskStatusSubmitting,
+			SubmittedAt:  now,
+			UpdatedAt:    now,
+		}
+		if transfer.Label != nil {
+			record.Label = *transfer.Label
+		}
+		if err := store.Put(record); err != nil {
+			return TaskRecord{}, TransferResult{}, err
+		}
+	}
+	transfer.SubmissionId = record.SubmissionId
+
+	result, err = c.TransferPostTask(transfer)
+	if err != nil {
+		return record, TransferResult{}, err
+	}
+
+	record.TaskId = result.TaskId
+	record.Status = "ACTIVE"
+	record.UpdatedAt = time.Now()
+	return record, result, store.Put(record)
+}
+
+// returned by TransferPostTaskTracked, along with the job's record, if the job has a task already
+var ErrAlreadySubmitted = errors.New("job already submitted")
+
+// Watches the tasks recorded in a task store and keeps their status up to date.
+// As the monitor only works off the store, a monitor started after a restart (with the store
+// reopened, e.g. from the same file) continues watching all tasks that weren't finished yet.
+type TaskMonitor struct {
+	Client   TransferAPI
+	Store    TaskStore
+	Interval time.Duration // time between polls (default: 1 minute)
+
+	// time after which a record without task id is reported as a stale submission (default: 10 minutes)
+	StaleAfter time.Duration
+
+	// called after a task's record was updated, optional
+	OnUpdate func(record TaskRecord, task Task)
+}
+
+// Returned (wrapped) by TaskMonitor.Poll for records that stayed in the SUBMITTING status, e.g.
+// because the submitting process died. Submitting the job again with the same job key through
+// TransferPostTaskTracked reuses the recorded submission id, so it can't create a duplicate task.
+var ErrStaleSubmission = errors.New("stale submission")
+
+// Fetches the status of every non-terminal task in the store once and updates the records.
+// Returns the number of tasks that are still not finished. Records that are still being submitted
+// count as pending, until they're older than StaleAfter and reported with ErrStaleSubmission.
+func (m TaskMonitor) Poll() (pending int, err error) {
+	records, err := m.Store.List()
+	if err != nil {
+		return 0, err
+	}
+	staleAfter := m.StaleAfter
+	if staleAfter <= 0 {
+		staleAfter = 10 * time.Minute
+	}
+
+	var errs []error
+	for _, record := range records {
+		if record.IsTerminal() {
+			continue
+		}
+		if record.TaskId == "" {
+			if time.Since(record.UpdatedAt) < staleAfter {
+				pending++
+			} else {
+				errs = append(errs, fmt.Errorf("job '%s': %w '%s' since %s, submit the job again to finish it",
+					record.JobKey, ErrStaleSubmission, record.SubmissionId, record.UpdatedAt.Format(time.RFC3339)))
+			}
+			continue
+		}
+
+		task, err := m.Client.TransferGetTaskByID(record.TaskId)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("job '%s' (task '%s'): %v", record.JobKey, record.TaskId, err))
+			pending++
+			continue
+		}
+
+		record.Status = task.Status
+		record.NiceStatus = ""
+		if task.NiceStatus != nil {
+			record.NiceStatus = *task.NiceStatus
+		}
+		record.UpdatedAt = time.Now()
+		if err := m.Store.Put(record); err != nil {
+			errs = append(errs, err)
+		}
+		if !record.IsTerminal() {
+			pending++
+		}
+		if m.OnUpdate != nil {
+			m.OnUpdate(record, task)
+		}
+	}
+	return pending, errors.Join(errs...)
+}
+
+// Polls the store's tasks until all of them are finished or the context is cancelled.
+// Errors of single polls are passed to onError (if set) and don't stop the monitor. If only stale
+// submissions are left, there's nothing to wait for anymore and their error is returned.
+func (m TaskMonitor) Run(ctx context.Context, onError func(error)) error {
+	interval := m.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		pending, err := m.Poll()
+		if pending == 0 && (err == nil || errors.Is(err, ErrStaleSubmission)) {
+			return err
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
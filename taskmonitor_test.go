@@ -0,0 +1,208 @@
+package globus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+)
+
+func TestTransferPostTaskTracked(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+	client := srv.Client()
+	store := globus.NewMemoryTaskStore()
+
+	record, result, err := client.TransferPostTaskTracked(store, "job", testTransfer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.TaskId == "" || record.TaskId != result.TaskId || record.Status != "ACTIVE" {
+		t.Errorf("got record %+v, want an active one with the task id %s", record, result.TaskId)
+	}
+	if stored, err := store.Get("job"); err != nil || stored.TaskId != record.TaskId {
+		t.Errorf("got stored record %+v (%v), want %+v", stored, err, record)
+	}
+
+	// the job isn't submitted again
+	again, _, err := client.TransferPostTaskTracked(store, "job", testTransfer())
+	if !errors.Is(err, globus.ErrAlreadySubmitted) {
+		t.Errorf("got %v, want ErrAlreadySubmitted", err)
+	}
+	if again.TaskId != record.TaskId {
+		t.Errorf("got task %s for the submitted job, want %s", again.TaskId, record.TaskId)
+	}
+	if transfers, _ := srv.Submitted(); len(transfers) != 1 {
+		t.Errorf("%d tasks were submitted, want 1", len(transfers))
+	}
+}
+
+func TestTransferPostTaskTrackedRetry(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+	client := srv.Client()
+	store := globus.NewMemoryTaskStore()
+
+	// the submission fails after the submission id was recorded
+	srv.InjectFault(globustest.Fault{Method: http.MethodPost, PathPrefix: "/transfer", StatusCode: http.StatusServiceUnavailable})
+	if _, _, err := client.TransferPostTaskTracked(store, "job", testTransfer()); err == nil {
+		t.Fatal("the submission succeeded despite the fault")
+	}
+	record, err := store.Get("job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != globus.TaskStatusSubmitting || record.SubmissionId == "" || record.TaskId != "" {
+		t.Fatalf("got record %+v, want one being submitted", record)
+	}
+
+	// submitting the job again reuses the recorded submission id
+	retried, _, err := client.TransferPostTaskTracked(store, "job", testTransfer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried.SubmissionId != record.SubmissionId || retried.TaskId == "" {
+		t.Errorf("got record %+v, want a task with submission id %s", retried, record.SubmissionId)
+	}
+	transfers, _ := srv.Submitted()
+	if len(transfers) != 1 || transfers[0].SubmissionId != record.SubmissionId {
+		t.Errorf("got %d tasks, want 1 with the recorded submission id", len(transfers))
+	}
+}
+
+func TestTransferPostTaskTrackedDryRun(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	client := srv.Client().WithDryRun(true)
+	store := globus.NewMemoryTaskStore()
+
+	if _, result, err := client.TransferPostTaskTracked(store, "job", testTransfer()); err != nil || len(result.Payload) == 0 {
+		t.Fatalf("got %v, want the dry-run payload", err)
+	}
+	if records, _ := store.List(); len(records) != 0 {
+		t.Errorf("got %d records in dry-run mode", len(records))
+	}
+}
+
+func TestTaskMonitor(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+	client := srv.Client()
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	store, err := globus.NewFileTaskStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed, _, err := client.TransferPostTaskTracked(store, "failing", testTransfer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := client.TransferPostTaskTracked(store, "succeeding", testTransfer()); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.FailTask(failed.TaskId, "PERMISSION_DENIED", "permission denied"); err != nil {
+		t.Fatal(err)
+	}
+
+	updates := map[string]string{}
+	monitor := globus.TaskMonitor{Client: client, Store: store, OnUpdate: func(record globus.TaskRecord, task globus.Task) {
+		updates[record.JobKey] = record.Status
+	}}
+	pending, err := monitor.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending != 1 || updates["failing"] != "FAILED" || updates["succeeding"] != "ACTIVE" {
+		t.Errorf("got %d pending tasks and updates %v, want 1 and a failed and an active task", pending, updates)
+	}
+
+	// a monitor on the reopened store continues with the unfinished task only
+	srv.Advance(srv.TaskDuration)
+	store, err = globus.NewFileTaskStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates = map[string]string{}
+	monitor.Store = store
+	if pending, err := monitor.Poll(); pending != 0 || err != nil {
+		t.Errorf("got %d pending tasks (%v), want 0", pending, err)
+	}
+	if len(updates) != 1 || updates["succeeding"] != "SUCCEEDED" {
+		t.Errorf("got updates %v, want the succeeded task only", updates)
+	}
+	if record, err := store.Get("succeeding"); err != nil || record.Status != "SUCCEEDED" {
+		t.Errorf("got record %+v (%v), want a succeeded one", record, err)
+	}
+}
+
+func TestTaskMonitorStaleSubmission(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	store := globus.NewMemoryTaskStore()
+	monitor := globus.TaskMonitor{Client: srv.Client(), Store: store, StaleAfter: time.Minute, Interval: 10 * time.Millisecond}
+
+	// a recent submission might still succeed
+	if err := store.Put(taskRecord("job", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if pending, err := monitor.Poll(); pending != 1 || err != nil {
+		t.Errorf("got %d pending tasks (%v), want 1", pending, err)
+	}
+
+	if err := store.Put(taskRecord("job", time.Now().Add(-time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := monitor.Poll()
+	if pending != 0 || !errors.Is(err, globus.ErrStaleSubmission) {
+		t.Errorf("got %d pending tasks (%v), want 0 and a stale submission", pending, err)
+	}
+	// there's nothing to wait for
+	if err := monitor.Run(context.Background(), nil); !errors.Is(err, globus.ErrStaleSubmission) {
+		t.Errorf("got %v from Run, want a stale submission", err)
+	}
+}
+
+func TestTaskMonitorRun(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+	client := srv.Client()
+	store := globus.NewMemoryTaskStore()
+	record, _, err := client.TransferPostTaskTracked(store, "job", testTransfer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	monitor := globus.TaskMonitor{Client: client, Store: store, Interval: 10 * time.Millisecond}
+
+	// the monitor keeps polling through errors until the context ends
+	srv.InjectFault(globustest.Fault{Method: http.MethodGet, PathPrefix: "/task/", StatusCode: http.StatusServiceUnavailable, Times: 2})
+	var errs []error
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := monitor.Run(ctx, func(err error) { errs = append(errs, err) }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want the deadline of the context", err)
+	}
+	if len(errs) != 2 {
+		t.Errorf("got %d poll errors, want 2", len(errs))
+	}
+
+	// and ends once all tasks are finished
+	if err := srv.CompleteTask(record.TaskId); err != nil {
+		t.Fatal(err)
+	}
+	if err := monitor.Run(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if record, _ := store.Get("job"); record.Status != "SUCCEEDED" {
+		t.Errorf("got status %s, want SUCCEEDED", record.Status)
+	}
+}
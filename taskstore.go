@@ -0,0 +1,282 @@
+package globus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// the local record of a submitted task, identified by a user-defined job key
+type TaskRecord struct {
+	JobKey       string    `json:"job_key"`
+	TaskId       string    `json:"task_id,omitempty"` // empty until the submission succeeded
+	SubmissionId string    `json:"submission_id"`
+	Label        string    `json:"label,omitempty"`
+	Status       string    `json:"status"` // last known status: SUBMITTING, ACTIVE, INACTIVE, SUCCEEDED or FAILED
+	NiceStatus   string    `json:"nice_status,omitempty"`
+	SubmittedAt  time.Time `json:"submitted_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// status of a record whose task might not have been created yet
+const TaskStatusSubmitting = "SUBMITTING"
+
+// true if the task can't change anymore
+func (r TaskRecord) IsTerminal() bool {
+	return isTerminalStatus(r.Status)
+}
+
+func isTerminalStatus(status string) bool {
+	return status == "SUCCEEDED" || status == "FAILED"
+}
+
+// returned by TaskStore.Get if no record exists for a job key
+var ErrTaskRecordNotFound = errors.New("task record not found")
+
+// persistent storage of task records, implementations must be safe for concurrent use
+type TaskStore interface {
+	Put(record TaskRecord) error // inserts or replaces the record with the same job key
+	Get(jobKey string) (TaskRecord, error)
+	List() ([]TaskRecord, error) // ordered by submission time
+	Delete(jobKey string) error
+}
+
+// a task store that only lives as long as the process
+type MemoryTaskStore struct {
+	mu      sync.Mutex
+	records map[string]TaskRecord
+}
+
+func NewMemoryTaskStore() *MemoryTaskStore {
+	return &MemoryTaskStore{records: map[string]TaskRecord{}}
+}
+
+func (s *MemoryTaskStore) Put(record TaskRecord) error {
+	if record.JobKey == "" {
+		return fmt.Errorf("task record has no job key")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.JobKey] = record
+	return nil
+}
+
+func (s *MemoryTaskStore) Get(jobKey string) (TaskRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[jobKey]
+	if !ok {
+		return TaskRecord{}, ErrTaskRecordNotFound
+	}
+	return record, nil
+}
+
+func (s *MemoryTaskStore) List() ([]TaskRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedRecords(s.records), nil
+}
+
+func (s *MemoryTaskStore) Delete(jobKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, jobKey)
+	return nil
+}
+
+// A task store persisted as a JSON file. Every change rewrites the file atomically
+// (write to a temporary file, then rename), so it survives crashes and restarts. Changes are
+// made under a lock file and merged into the current content of the file, so several processes
+// (e.g. a submission and a task monitor) can share a store without overwriting each other's records.
+type FileTaskStore struct {
+	mu      sync.Mutex
+	path    string
+	records map[string]TaskRecord // as of the last read or write of the file
+}
+
+const (
+	// how long a change waits for the lock of another process
+	fileLockTimeout = 30 * time.Second
+	// age after which a lock file is considered left behind by a crashed process
+	fileLockStaleAge = 5 * time.Minute
+)
+
+// opens a file task store, the file is created on the first change if it doesn't exist
+func NewFileTaskStore(path string) (*FileTaskStore, error) {
+	s := &FileTaskStore{path: path}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileTaskStore) Put(record TaskRecord) error {
+	if record.JobKey == "" {
+		return fmt.Errorf("task record has no job key")
+	}
+	return s.update(func(records map[string]TaskRecord) bool {
+		records[record.JobKey] = record
+		return true
+	})
+}
+
+func (s *FileTaskStore) Get(jobKey string) (TaskRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return TaskRecord{}, err
+	}
+	record, ok := s.records[jobKey]
+	if !ok {
+		return TaskRecord{}, ErrTaskRecordNotFound
+	}
+	return record, nil
+}
+
+func (s *FileTaskStore) List() ([]TaskRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return sortedRecords(s.records), nil
+}
+
+func (s *FileTaskStore) Delete(jobKey string) error {
+	return s.update(func(records map[string]TaskRecord) bool {
+		if _, ok := records[jobKey]; !ok {
+			return false
+		}
+		delete(records, jobKey)
+		return true
+	})
+}
+
+// Applies a change to the current content of the store file and writes it back, holding the lock
+// file meanwhile. change reports whether it modified the records, if not nothing is written.
+func (s *FileTaskStore) update(change func(records map[string]TaskRecord) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	records := make(map[string]TaskRecord, len(s.records))
+	for key, record := range s.records {
+		records[key] = record
+	}
+	if !change(records) {
+		return nil
+	}
+	if err := s.save(records); err != nil {
+		return err
+	}
+	s.records = records
+	return nil
+}
+
+// reads the records from the store file, must be called with the mutex held
+func (s *FileTaskStore) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.records = map[string]TaskRecord{}
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var list []TaskRecord
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("could not parse task store '%s': %v", s.path, err)
+	}
+	records := make(map[string]TaskRecord, len(list))
+	for _, record := range list {
+		records[record.JobKey] = record
+	}
+	s.records = records
+	return nil
+}
+
+// writes the records to the store file, must be called with the lock file held
+func (s *FileTaskStore) save(records map[string]TaskRecord) error {
+	data, err := json.MarshalIndent(sortedRecords(records), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data, 0600)
+}
+
+// Locks a file against other processes by exclusively creating "<path>.lock", waiting for up to
+// fileLockTimeout. Lock files older than fileLockStaleAge are removed, as their owner has crashed.
+func lockFile(path string) (unlock func(), err error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(fileLockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		} else if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("could not lock '%s': %v", path, err)
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > fileLockStaleAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("could not lock '%s': '%s' is held by another process, remove it if that process is gone", path, lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// writes a file by renaming a completely written temporary file in the same directory
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func sortedRecords(records map[string]TaskRecord) []TaskRecord {
+	list := make([]TaskRecord, 0, len(records))
+	for _, record := range records {
+		list = append(list, record)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].SubmittedAt.Equal(list[j].SubmittedAt) {
+			return list[i].JobKey < list[j].JobKey
+		}
+		return list[i].SubmittedAt.Before(list[j].SubmittedAt)
+	})
+	return list
+}
@@ -0,0 +1,164 @@
+package globus_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+)
+
+func taskRecord(jobKey string, submittedAt time.Time) globus.TaskRecord {
+	return globus.TaskRecord{
+		JobKey:       jobKey,
+		SubmissionId: "submission-" + jobKey,
+		Status:       globus.TaskStatusSubmitting,
+		SubmittedAt:  submittedAt,
+		UpdatedAt:    submittedAt,
+	}
+}
+
+func recordKeys(records []globus.TaskRecord) (keys []string) {
+	for _, record := range records {
+		keys = append(keys, record.JobKey)
+	}
+	return keys
+}
+
+func TestFileTaskStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	store, err := globus.NewFileTaskStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Round(0)
+	for _, record := range []globus.TaskRecord{taskRecord("b", now), taskRecord("c", now.Add(-time.Hour)), taskRecord("a", now)} {
+		if err := store.Put(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Put(globus.TaskRecord{}); err == nil {
+		t.Error("a record without job key was stored")
+	}
+	checkFileMode(t, path)
+
+	// the records survive a restart, ordered by submission time and job key
+	store, err = globus.NewFileTaskStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := recordKeys(records); len(keys) != 3 || keys[0] != "c" || keys[1] != "a" || keys[2] != "b" {
+		t.Errorf("got records %v, want [c a b]", keys)
+	}
+	record, err := store.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.SubmissionId != "submission-a" || !record.SubmittedAt.Equal(now) {
+		t.Errorf("got record %+v, want the one of job a", record)
+	}
+
+	if err := store.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("a"); !errors.Is(err, globus.ErrTaskRecordNotFound) {
+		t.Errorf("got %v for a deleted record, want ErrTaskRecordNotFound", err)
+	}
+	if _, err := os.Stat(path + ".lock"); !errors.Is(err, os.ErrNotExist) {
+		t.Error("the lock file was left behind")
+	}
+}
+
+func TestFileTaskStoreSharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	first, err := globus.NewFileTaskStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := globus.NewFileTaskStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// changes of one store are merged with the ones of the other, not overwritten
+	now := time.Now()
+	if err := first.Put(taskRecord("a", now)); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Put(taskRecord("b", now)); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Put(taskRecord("c", now)); err != nil {
+		t.Fatal(err)
+	}
+	for _, store := range []*globus.FileTaskStore{first, second} {
+		records, err := store.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keys := recordKeys(records); len(keys) != 2 || keys[0] != "a" || keys[1] != "c" {
+			t.Errorf("got records %v, want [a c]", keys)
+		}
+	}
+}
+
+func TestFileTaskStoreLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	store, err := globus.NewFileTaskStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another process holds the lock
+	if err := os.WriteFile(path+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- store.Put(taskRecord("a", time.Now()))
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("the record was stored while the lock was held: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if err := os.Remove(path + ".lock"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the record wasn't stored after the lock was released")
+	}
+
+	// the lock file of a crashed process is removed
+	if err := os.WriteFile(path+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := store.Put(taskRecord("b", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("waited %s for a stale lock", waited)
+	}
+	if _, err := os.Stat(path + ".lock"); !errors.Is(err, os.ErrNotExist) {
+		t.Error("the lock file was left behind")
+	}
+}
@@ -0,0 +1,453 @@
+package globus
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/oauth2"
+)
+
+// identifies a stored token. Globus Auth issues one token per resource server, so a client can
+// have several tokens per identity.
+type TokenKey struct {
+	ClientId       string `json:"client_id"`
+	IdentityId     string `json:"identity_id,omitempty"` // empty if unknown
+	ResourceServer string `json:"resource_server"`
+}
+
+func (k TokenKey) String() string {
+	return k.ClientId + "/" + k.IdentityId + "/" + k.ResourceServer
+}
+
+type StoredToken struct {
+	Key       TokenKey      `json:"key"`
+	Token     *oauth2.Token `json:"token"`
+	Scopes    []string      `json:"scopes,omitempty"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// returned by TokenStore.Get if no token is stored under a key
+var ErrTokenNotFound = errors.New("token not found")
+
+// persistent storage of OAuth2 tokens, implementations must be safe for concurrent use
+type TokenStore interface {
+	Put(token StoredToken) error // inserts or replaces the token with the same key
+	Get(key TokenKey) (StoredToken, error)
+	List() ([]StoredToken, error) // ordered by key
+	Delete(key TokenKey) error
+}
+
+// a token store that only lives as long as the process
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[TokenKey]StoredToken
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: map[TokenKey]StoredToken{}}
+}
+
+func (s *MemoryTokenStore) Put(token StoredToken) error {
+	if token.Key.ClientId == "" || token.Token == nil {
+		return fmt.Errorf("stored token needs a client id and a token")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token.Key] = token
+	return nil
+}
+
+func (s *MemoryTokenStore) Get(key TokenKey) (StoredToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[key]
+	if !ok {
+		return StoredToken{}, ErrTokenNotFound
+	}
+	return token, nil
+}
+
+func (s *MemoryTokenStore) List() ([]StoredToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedTokens(s.tokens), nil
+}
+
+func (s *MemoryTokenStore) Delete(key TokenKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, key)
+	return nil
+}
+
+// Encryption of a file token store. Either a passphrase or a key file can be set, the key is
+// derived from the passphrase with PBKDF2 or from the contents of the key file with SHA-256.
+// Without either, tokens are stored in plain text (the file is still only readable by its owner).
+type TokenEncryption struct {
+	Passphrase string
+	KeyFile    string
+}
+
+func (e TokenEncryption) enabled() bool {
+	return e.Passphrase != "" || e.KeyFile != ""
+}
+
+// iterations of PBKDF2-HMAC-SHA256 for passphrase derived keys
+const tokenStoreKdfIterations = 600000
+
+// the file format of an encrypted token store
+type encryptedTokenFile struct {
+	Version    int    `json:"version"`
+	Kdf        string `json:"kdf"` // "pbkdf2-sha256" or "keyfile-sha256"
+	Salt       []byte `json:"salt,omitempty"`
+	Iterations int    `json:"iterations,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// A token store persisted as a JSON file with 0600 permissions, optionally encrypted with
+// AES-256-GCM. Every change rewrites the file atomically.
+type FileTokenStore struct {
+	mu         sync.Mutex
+	path       string
+	encryption TokenEncryption
+	tokens     map[TokenKey]StoredToken
+
+	// the salt and key derived from the passphrase, kept as the derivation is slow on purpose
+	salt       []byte
+	iterations int
+	key        []byte
+}
+
+// opens a file token store, the file is created on the first change if it doesn't exist
+func NewFileTokenStore(path string, encryption TokenEncryption) (*FileTokenStore, error) {
+	if encryption.Passphrase != "" && encryption.KeyFile != "" {
+		return nil, fmt.Errorf("either a passphrase or a key file can be used for the token store, not both")
+	}
+	s := &FileTokenStore{path: path, encryption: encryption, tokens: map[TokenKey]StoredToken{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var envelope encryptedTokenFile
+	if json.Unmarshal(data, &envelope) == nil && envelope.Ciphertext != nil {
+		data, err = s.decrypt(envelope)
+		if err != nil {
+			return nil, fmt.Errorf("could not decrypt token store '%s': %v", path, err)
+		}
+	} else if encryption.enabled() {
+		return nil, fmt.Errorf("token store '%s' is not encrypted", path)
+	}
+
+	var tokens []StoredToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("could not parse token store '%s': %v", path, err)
+	}
+	for _, token := range tokens {
+		s.tokens[token.Key] = token
+	}
+	return s, nil
+}
+
+func (s *FileTokenStore) Put(token StoredToken) error {
+	if token.Key.ClientId == "" || token.Token == nil {
+		return fmt.Errorf("stored token needs a client id and a token")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.tokens[token.Key]
+	s.tokens[token.Key] = token
+	if err := s.save(); err != nil {
+		if existed {
+			s.tokens[token.Key] = previous
+		} else {
+			delete(s.tokens, token.Key)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileTokenStore) Get(key TokenKey) (StoredToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[key]
+	if !ok {
+		return StoredToken{}, ErrTokenNotFound
+	}
+	return token, nil
+}
+
+func (s *FileTokenStore) List() ([]StoredToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedTokens(s.tokens), nil
+}
+
+func (s *FileTokenStore) Delete(key TokenKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.tokens[key]
+	if !existed {
+		return nil
+	}
+	delete(s.tokens, key)
+	if err := s.save(); err != nil {
+		s.tokens[key] = previous
+		return err
+	}
+	return nil
+}
+
+// writes all tokens to the store file, must be called with the lock held
+func (s *FileTokenStore) save() error {
+	data, err := json.MarshalIndent(sortedTokens(s.tokens), "", "  ")
+	if err != nil {
+		return err
+	}
+	if s.encryption.enabled() {
+		envelope, err := s.encrypt(data)
+		if err != nil {
+			return err
+		}
+		data, err = json.MarshalIndent(envelope, "", "  ")
+		if err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(s.path, data, 0600)
+}
+
+func (s *FileTokenStore) encrypt(plaintext []byte) (encryptedTokenFile, error) {
+	envelope := encryptedTokenFile{Version: 1}
+	if s.encryption.Passphrase != "" {
+		envelope.Kdf = "pbkdf2-sha256"
+		envelope.Iterations = tokenStoreKdfIterations
+		envelope.Salt = s.salt
+		if envelope.Salt == nil {
+			envelope.Salt = make([]byte, 16)
+			if _, err := rand.Read(envelope.Salt); err != nil {
+				return encryptedTokenFile{}, err
+			}
+		}
+	} else {
+		envelope.Kdf = "keyfile-sha256"
+	}
+
+	gcm, err := s.cipher(envelope)
+	if err != nil {
+		return encryptedTokenFile{}, err
+	}
+	envelope.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return encryptedTokenFile{}, err
+	}
+	envelope.Ciphertext = gcm.Seal(nil, envelope.Nonce, plaintext, nil)
+	return envelope, nil
+}
+
+func (s *FileTokenStore) decrypt(envelope encryptedTokenFile) ([]byte, error) {
+	if !s.encryption.enabled() {
+		return nil, fmt.Errorf("the store is encrypted, but no passphrase or key file was given")
+	}
+	gcm, err := s.cipher(envelope)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or key file")
+	}
+	return plaintext, nil
+}
+
+// derives the AES-256-GCM cipher of an encrypted file from the passphrase or key file
+func (s *FileTokenStore) cipher(envelope encryptedTokenFile) (cipher.AEAD, error) {
+	var key []byte
+	switch envelope.Kdf {
+	case "pbkdf2-sha256":
+		if s.encryption.Passphrase == "" {
+			return nil, fmt.Errorf("the store is encrypted with a passphrase")
+		}
+		if s.key == nil || !bytes.Equal(s.salt, envelope.Salt) || s.iterations != envelope.Iterations {
+			s.key = pbkdf2.Key([]byte(s.encryption.Passphrase), envelope.Salt, envelope.Iterations, 32, sha256.New)
+			s.salt, s.iterations = envelope.Salt, envelope.Iterations
+		}
+		key = s.key
+	case "keyfile-sha256":
+		if s.encryption.KeyFile == "" {
+			return nil, fmt.Errorf("the store is encrypted with a key file")
+		}
+		data, err := os.ReadFile(s.encryption.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		key = sum[:]
+	default:
+		return nil, fmt.Errorf("unknown key derivation '%s'", envelope.Kdf)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sortedTokens(tokens map[TokenKey]StoredToken) []StoredToken {
+	list := make([]StoredToken, 0, len(tokens))
+	for _, token := range tokens {
+		list = append(list, token)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key.String() < list[j].Key.String()
+	})
+	return list
+}
+
+// Returns a token source that passes on the tokens of src and stores every new token under key,
+// so refreshed (and rotated) tokens survive restarts. Errors while storing a token are passed to
+// onError (if set), the token is returned anyway.
+func AuthPersistingTokenSource(store TokenStore, key TokenKey, src oauth2.TokenSource, onError func(error)) oauth2.TokenSource {
+	return &persistingTokenSource{store: store, key: key, source: src, onError: onError}
+}
+
+type persistingTokenSource struct {
+	store   TokenStore
+	key     TokenKey
+	source  oauth2.TokenSource
+	onError func(error)
+
+	mu   sync.Mutex
+	last string // access token of the last stored token
+}
+
+func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
+	tok, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tok.AccessToken == s.last {
+		return tok, nil
+	}
+
+	stored := StoredToken{Key: s.key, Token: tok, UpdatedAt: time.Now()}
+	if previous, err := s.store.Get(s.key); err == nil {
+		stored.Scopes = previous.Scopes
+		if tok.RefreshToken == "" {
+			// keep the refresh token if the refresh response didn't include one
+			copied := *tok
+			copied.RefreshToken = previous.Token.RefreshToken
+			stored.Token = &copied
+		}
+	}
+	if scope, ok := tok.Extra("scope").(string); ok && scope != "" {
+		stored.Scopes = strings.Fields(scope)
+	}
+	if err := s.store.Put(stored); err != nil {
+		if s.onError != nil {
+			s.onError(fmt.Errorf("could not store token: %v", err))
+		}
+		return tok, nil
+	}
+	s.last = tok.AccessToken
+	return tok, nil
+}
+
+// Stores a freshly obtained token (e.g. from oauth2.Config.Exchange) for a client and returns its
+// key. The resource server is taken from the token response, the identity from its id_token if
+// one was issued (the id_token isn't verified, it's only used to tell tokens apart).
+func AuthStoreToken(store TokenStore, clientID string, tok *oauth2.Token) (TokenKey, error) {
+	key := TokenKey{ClientId: clientID, IdentityId: tokenIdentity(tok)}
+	key.ResourceServer, _ = tok.Extra("resource_server").(string)
+
+	stored := StoredToken{Key: key, Token: tok, UpdatedAt: time.Now()}
+	if scope, ok := tok.Extra("scope").(string); ok {
+		stored.Scopes = strings.Fields(scope)
+	}
+	return key, store.Put(stored)
+}
+
+// Stores all tokens of a Globus token response, one per resource server (see AuthParseTokenResponse),
+// and returns their keys.
+func AuthStoreTokens(store TokenStore, clientID string, tok *oauth2.Token) ([]TokenKey, error) {
+	tokens, err := AuthParseTokenResponse(tok)
+	if err != nil {
+		return nil, err
+	}
+	identityId := tokenIdentity(tok)
+
+	var keys []TokenKey
+	for resourceServer, token := range tokens {
+		key := TokenKey{ClientId: clientID, IdentityId: identityId, ResourceServer: resourceServer}
+		stored := StoredToken{Key: key, Token: token, UpdatedAt: time.Now()}
+		if scope, ok := token.Extra("scope").(string); ok {
+			stored.Scopes = strings.Fields(scope)
+		}
+		if err := store.Put(stored); err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys, nil
+}
+
+// Returns an auto-refreshing, persisting token source for a stored token. The conf must belong to
+// the client that obtained the token.
+func AuthStoredTokenSource(ctx context.Context, conf oauth2.Config, store TokenStore, key TokenKey, onError func(error)) (oauth2.TokenSource, error) {
+	stored, err := store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return AuthPersistingTokenSource(store, key, conf.TokenSource(ctx, stored.Token), onError), nil
+}
+
+// the subject of the token's id_token, empty if there's none
+func tokenIdentity(tok *oauth2.Token) string {
+	idToken, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return ""
+	}
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		Sub string `json:"sub"`
+	}
+	if json.Unmarshal(payload, &claims) != nil {
+		return ""
+	}
+	return claims.Sub
+}
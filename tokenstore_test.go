@@ -0,0 +1,250 @@
+package globus_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+	"golang.org/x/oauth2"
+)
+
+func storedToken(resourceServer string, accessToken string) globus.StoredToken {
+	return globus.StoredToken{
+		Key:       globus.TokenKey{ClientId: appClientId, ResourceServer: resourceServer},
+		Token:     &oauth2.Token{AccessToken: accessToken, RefreshToken: "refresh-" + accessToken, Expiry: time.Now().Add(time.Hour).Round(0)},
+		Scopes:    []string{transferScope},
+		UpdatedAt: time.Now().Round(0),
+	}
+}
+
+func checkFileMode(t *testing.T, path string) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("the token store has mode %o, want 600", mode)
+	}
+}
+
+// stores tokens, opens the store again with the same encryption and checks that they're all there
+func checkFileTokenStoreRoundTrip(t *testing.T, path string, encryption globus.TokenEncryption) {
+	t.Helper()
+	store, err := globus.NewFileTokenStore(path, encryption)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer := storedToken(globus.ResourceServerTransfer, "transfer-access-token")
+	groups := storedToken(globus.ResourceServerGroups, "groups-access-token")
+	for _, token := range []globus.StoredToken{transfer, groups} {
+		if err := store.Put(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkFileMode(t, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encrypted := !strings.Contains(string(data), "transfer-access-token"); encrypted != (encryption != globus.TokenEncryption{}) {
+		t.Errorf("the tokens are stored encrypted: %t, want %t", encrypted, !encrypted)
+	}
+
+	reopened, err := globus.NewFileTokenStore(path, encryption)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := reopened.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0].Key != groups.Key || tokens[1].Key != transfer.Key {
+		t.Fatalf("got %d tokens, want the groups and the transfer token in this order", len(tokens))
+	}
+	got, err := reopened.Get(transfer.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Token.AccessToken != transfer.Token.AccessToken || got.Token.RefreshToken != transfer.Token.RefreshToken || !got.Token.Expiry.Equal(transfer.Token.Expiry) {
+		t.Errorf("got token %+v, want %+v", *got.Token, *transfer.Token)
+	}
+	if !got.UpdatedAt.Equal(transfer.UpdatedAt) || len(got.Scopes) != 1 || got.Scopes[0] != transferScope {
+		t.Errorf("got update time %v and scopes %v, want %v and %v", got.UpdatedAt, got.Scopes, transfer.UpdatedAt, transfer.Scopes)
+	}
+
+	if err := reopened.Delete(transfer.Key); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err = globus.NewFileTokenStore(path, encryption)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.Get(transfer.Key); !errors.Is(err, globus.ErrTokenNotFound) {
+		t.Errorf("got %v for a deleted token, want ErrTokenNotFound", err)
+	}
+}
+
+func TestFileTokenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	checkFileTokenStoreRoundTrip(t, path, globus.TokenEncryption{})
+
+	if _, err := globus.NewFileTokenStore(path, globus.TokenEncryption{Passphrase: "secret"}); err == nil {
+		t.Error("a plain text store was opened with a passphrase")
+	}
+	if _, err := globus.NewFileTokenStore(path, globus.TokenEncryption{Passphrase: "secret", KeyFile: "key"}); err == nil {
+		t.Error("a store was opened with both a passphrase and a key file")
+	}
+
+	store, err := globus.NewFileTokenStore(path, globus.TokenEncryption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(globus.StoredToken{Key: globus.TokenKey{ResourceServer: globus.ResourceServerTransfer}}); err == nil {
+		t.Error("a token without client id and token was stored")
+	}
+}
+
+func TestFileTokenStorePassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	checkFileTokenStoreRoundTrip(t, path, globus.TokenEncryption{Passphrase: "correct horse"})
+
+	_, err := globus.NewFileTokenStore(path, globus.TokenEncryption{Passphrase: "wrong horse"})
+	if err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("got %v, want a wrong passphrase error", err)
+	}
+	if _, err := globus.NewFileTokenStore(path, globus.TokenEncryption{}); err == nil {
+		t.Error("an encrypted store was opened without passphrase")
+	}
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := globus.NewFileTokenStore(path, globus.TokenEncryption{KeyFile: keyFile}); err == nil {
+		t.Error("a passphrase encrypted store was opened with a key file")
+	}
+}
+
+func TestFileTokenStoreKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tokens.json")
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte("0123456789abcdef0123456789abcdef"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	checkFileTokenStoreRoundTrip(t, path, globus.TokenEncryption{KeyFile: keyFile})
+
+	otherKeyFile := filepath.Join(dir, "other-key")
+	if err := os.WriteFile(otherKeyFile, []byte("another key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := globus.NewFileTokenStore(path, globus.TokenEncryption{KeyFile: otherKeyFile})
+	if err == nil || !strings.Contains(err.Error(), "wrong passphrase or key file") {
+		t.Errorf("got %v, want a wrong key file error", err)
+	}
+	if _, err := globus.NewFileTokenStore(path, globus.TokenEncryption{KeyFile: filepath.Join(dir, "missing")}); err == nil {
+		t.Error("the store was opened with a missing key file")
+	}
+}
+
+// a token store whose writes fail
+type failingTokenStore struct {
+	*globus.MemoryTokenStore
+}
+
+func (s failingTokenStore) Put(token globus.StoredToken) error {
+	return errors.New("disk full")
+}
+
+func TestAuthPersistingTokenSource(t *testing.T) {
+	srv := globustest.NewAuthServer()
+	defer srv.Close()
+	srv.AddClient(appClientId, appClientSecret)
+	srv.RotateRefreshTokens = true
+	ctx := srv.Context(context.Background())
+
+	conf, tok := loginUser(t, ctx, srv, transferScope)
+	store := globus.NewMemoryTokenStore()
+	key, err := globus.AuthStoreToken(store, appClientId, tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// make the stored token expire, so it's refreshed on the next use
+	stored, err := store.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := *stored.Token
+	expired.Expiry = time.Now().Add(-time.Minute)
+	stored.Token = &expired
+	if err := store.Put(stored); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := globus.AuthStoredTokenSource(ctx, conf, store, key, func(err error) {
+		t.Errorf("error while storing the token: %v", err)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err := src.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.AccessToken == tok.AccessToken || refreshed.RefreshToken == tok.RefreshToken {
+		t.Fatal("the token wasn't refreshed with a rotated refresh token")
+	}
+
+	stored, err = store.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Token.AccessToken != refreshed.AccessToken || stored.Token.RefreshToken != refreshed.RefreshToken {
+		t.Error("the refreshed token wasn't stored")
+	}
+	if len(stored.Scopes) != 1 || stored.Scopes[0] != transferScope {
+		t.Errorf("got stored scopes %v, want %s", stored.Scopes, transferScope)
+	}
+
+	// the stored token works after a restart, while the rotated refresh token doesn't
+	src, err = globus.AuthStoredTokenSource(ctx, conf, store, key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current, err := src.Token(); err != nil || current.AccessToken != refreshed.AccessToken {
+		t.Errorf("got %v, want the refreshed token from the store", err)
+	}
+	if _, err := conf.TokenSource(ctx, &expired).Token(); err == nil {
+		t.Error("the rotated refresh token can still be used")
+	}
+}
+
+func TestAuthPersistingTokenSourceStoreError(t *testing.T) {
+	token := &oauth2.Token{AccessToken: "access-token", Expiry: time.Now().Add(time.Hour)}
+	var storeErrs []error
+	src := globus.AuthPersistingTokenSource(failingTokenStore{globus.NewMemoryTokenStore()}, globus.TokenKey{ClientId: appClientId}, oauth2.StaticTokenSource(token), func(err error) {
+		storeErrs = append(storeErrs, err)
+	})
+
+	for i := 0; i < 2; i++ {
+		got, err := src.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.AccessToken != token.AccessToken {
+			t.Errorf("got access token %s, want %s", got.AccessToken, token.AccessToken)
+		}
+	}
+	// the token isn't marked as stored, so it's tried again
+	if len(storeErrs) != 2 {
+		t.Errorf("got %d store errors, want 2", len(storeErrs))
+	}
+}
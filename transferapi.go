@@ -0,0 +1,41 @@
+package globus
+
+import "net/http"
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6@v6.11.2 -o globusmock/fake_transfer_api.go . TransferAPI
+
+// TransferAPI is implemented by GlobusClient and covers all of its Transfer API operations.
+// Code that depends on this interface instead of GlobusClient can be unit tested with the
+// fake implementation in the globusmock package.
+type TransferAPI interface {
+	// submission
+	TransferGetSubmissionId() (submissionId string, err error)
+	TransferPostTask(transfer Transfer) (result TransferResult, err error)
+	TransferDeletePostTask(del Delete) (result TransferResult, err error)
+	TransferCopyFile(client *http.Client, sourceEndpoint string, sourceFile string, destEndpoint string, destFile string) (TransferResult, error)
+	TransferFolderSync(sourceEndpoint string, sourcePath string, destEndpoint string, destPath string, storeBasePath bool) (TransferResult, error)
+	TransferFileList(sourceEndpoint string, sourcePath string, destEndpoint string, destPath string, fileList []string, isSymlink []bool, storeBasePath bool) (TransferResult, error)
+	TransferDeletePaths(endpoint string, paths []string, recursive bool) (TransferResult, error)
+	TransferPostTaskBatched(transfer Transfer, opts BatchOptions) (group TaskGroup, err error)
+	TransferPostTaskTracked(store TaskStore, jobKey string, transfer Transfer) (record TaskRecord, result TransferResult, err error)
+
+	// monitoring
+	TransferGetTaskList(offset uint, limit uint) (taskList TaskList, err error)
+	TransferGetTaskByID(taskID string) (task Task, err error)
+	TransferGetTaskGroupStatus(group TaskGroup) (status TaskGroupStatus, err error)
+	TransferCancelTaskByID(taskID string) (result Result, err error)
+	TransferRemoveTaskByID(taskID string) (result Result, err error)
+	TransferGetTaskEventList(taskID string, offset uint, limit uint) (eventList EventList, err error)
+	TransferGetTaskSuccessfulTransfers(taskID string, marker uint) (transfers SuccessfulTransfers, err error)
+	TransferGetTaskSkippedErrors(taskID string, marker uint) (skips SkippedErrors, err error)
+	TransferGetTaskPauseInfo(taskID string) (info PauseInfoLimited, err error)
+}
+
+var _ TransferAPI = GlobusClient{}
+
+// True if the client is in dry-run mode. Checks for the IsDryRun method, so a GlobusClient,
+// a *GlobusClient and wrappers that pass the method on are recognized alike.
+func isDryRun(api TransferAPI) bool {
+	client, ok := api.(interface{ IsDryRun() bool })
+	return ok && client.IsDryRun()
+}
@@ -0,0 +1,391 @@
+package globus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// states of a job in a transfer queue
+const (
+	JobQueued       = "QUEUED"
+	JobActive       = "ACTIVE"
+	JobSucceeded    = "SUCCEEDED"
+	JobFailed       = "FAILED"
+	JobCanceled     = "CANCELED"
+	JobSubmitFailed = "SUBMIT_FAILED"
+	JobDryRun       = "DRY_RUN" // submitted with a dry-run client, no task was created
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+type QueuedJob struct {
+	Id         string
+	Transfer   Transfer
+	Priority   int // jobs with higher priority are submitted first
+	State      string
+	TaskId     string    // set once the job was submitted
+	Payload    []byte    // the document that would have been submitted, for jobs in dry-run mode
+	Error      string    // reason of a failed submission, or of the last failed attempt of a queued job
+	Attempts   int       // failed submission attempts, which are retried if the error was temporary
+	RetryAt    time.Time // a queued job isn't submitted before this time
+	EnqueuedAt time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// true if the job can't change anymore
+func (j QueuedJob) IsDone() bool {
+	return j.State != JobQueued && j.State != JobActive
+}
+
+type QueueOptions struct {
+	MaxActive                int           // max. number of active tasks submitted by the queue (0: no limit)
+	MaxActivePerEndpointPair int           // max. number of active tasks between the same two endpoints (0: no limit)
+	PollInterval             time.Duration // time between status checks of the active tasks (default: 1 minute)
+	MinBackoff               time.Duration // delay before a submission is retried after a temporary error, doubled on every failure (default: 30 seconds)
+	MaxBackoff               time.Duration // max. delay between submission attempts (default: 30 minutes)
+
+	// called whenever the state of a job changes, optional
+	OnChange func(job QueuedJob)
+}
+
+// snapshot of a transfer queue
+type QueueState struct {
+	Queued   []QueuedJob // in submission order
+	Active   []QueuedJob
+	Finished []QueuedJob
+}
+
+// A transfer queue accepts transfers and submits them as tasks while keeping the number of
+// concurrently active tasks within limits, so bursts of transfers don't run into the Globus
+// limits on active tasks per identity. All jobs of a queue are submitted with the same client,
+// so a queue should be created per identity. Submissions failing with a temporary error (see
+// IsTemporaryError) stay queued and are retried with exponential backoff, under the same
+// submission id, so a retry can't create a duplicate task.
+type TransferQueue struct {
+	client TransferAPI
+	opts   QueueOptions
+
+	mu     sync.Mutex
+	jobs   map[string]*QueuedJob
+	order  []*QueuedJob // all jobs in enqueue order
+	wakeup chan struct{}
+}
+
+func NewTransferQueue(client TransferAPI, opts QueueOptions) *TransferQueue {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Minute
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 30 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Minute
+	}
+	return &TransferQueue{
+		client: client,
+		opts:   opts,
+		jobs:   map[string]*QueuedJob{},
+		wakeup: make(chan struct{}, 1),
+	}
+}
+
+// adds a transfer to the queue and returns the id of the job
+func (q *TransferQueue) Enqueue(transfer Transfer, priority int) (jobId string, err error) {
+	jobId, err = randomUUID()
+	if err != nil {
+		return "", err
+	}
+
+	q.mu.Lock()
+	job := &QueuedJob{
+		Id:         jobId,
+		Transfer:   transfer,
+		Priority:   priority,
+		State:      JobQueued,
+		EnqueuedAt: time.Now(),
+	}
+	q.jobs[jobId] = job
+	q.order = append(q.order, job)
+	q.mu.Unlock()
+
+	q.changed(*job)
+	q.wake()
+	return jobId, nil
+}
+
+// Removes a job that wasn't submitted yet from the queue.
+// Active jobs have to be cancelled through their task (see TransferCancelTaskByID).
+func (q *TransferQueue) Cancel(jobId string) error {
+	q.mu.Lock()
+	job, ok := q.jobs[jobId]
+	if !ok {
+		q.mu.Unlock()
+		return ErrJobNotFound
+	}
+	if job.State != JobQueued {
+		q.mu.Unlock()
+		return fmt.Errorf("job '%s' can't be cancelled, its state is %s", jobId, job.State)
+	}
+	job.State = JobCanceled
+	job.FinishedAt = time.Now()
+	snapshot := *job
+	q.mu.Unlock()
+
+	q.changed(snapshot)
+	return nil
+}
+
+func (q *TransferQueue) Job(jobId string) (QueuedJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[jobId]
+	if !ok {
+		return QueuedJob{}, ErrJobNotFound
+	}
+	return *job, nil
+}
+
+// returns a snapshot of all jobs of the queue
+func (q *TransferQueue) State() (state QueueState) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, job := range q.pending() {
+		state.Queued = append(state.Queued, *job)
+	}
+	for _, job := range q.order {
+		switch {
+		case job.State == JobActive:
+			state.Active = append(state.Active, *job)
+		case job.IsDone():
+			state.Finished = append(state.Finished, *job)
+		}
+	}
+	return state
+}
+
+// Submits queued jobs and watches the active ones until the context is cancelled.
+// Errors while polling a task's status are passed to onError (if set), the task is
+// checked again on the next poll.
+func (q *TransferQueue) Run(ctx context.Context, onError func(error)) error {
+	return q.run(ctx, onError, false)
+}
+
+// like Run, but returns as soon as no job is queued or active anymore
+func (q *TransferQueue) Drain(ctx context.Context, onError func(error)) error {
+	return q.run(ctx, onError, true)
+}
+
+func (q *TransferQueue) run(ctx context.Context, onError func(error), untilIdle bool) error {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := q.poll(); err != nil && onError != nil {
+			onError(err)
+		}
+		q.dispatch()
+		if untilIdle && q.IsIdle() {
+			return nil
+		}
+
+		// wake up when the next submission is due for a retry
+		var retry <-chan time.Time
+		var timer *time.Timer
+		if retryAt, ok := q.nextRetry(); ok {
+			timer = time.NewTimer(time.Until(retryAt))
+			retry = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		case <-q.wakeup:
+		case <-retry:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// true if no job of the queue is queued or active
+func (q *TransferQueue) IsIdle() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.order {
+		if !job.IsDone() {
+			return false
+		}
+	}
+	return true
+}
+
+// updates the state of the active jobs from their tasks' status
+func (q *TransferQueue) poll() error {
+	q.mu.Lock()
+	var active []QueuedJob
+	for _, job := range q.order {
+		if job.State == JobActive {
+			active = append(active, *job)
+		}
+	}
+	q.mu.Unlock()
+
+	var errs []error
+	for _, job := range active {
+		task, err := q.client.TransferGetTaskByID(job.TaskId)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("job '%s' (task '%s'): %v", job.Id, job.TaskId, err))
+			continue
+		}
+		if !isTerminalStatus(task.Status) {
+			continue
+		}
+
+		q.mu.Lock()
+		stored := q.jobs[job.Id]
+		stored.State = task.Status
+		stored.FinishedAt = time.Now()
+		snapshot := *stored
+		q.mu.Unlock()
+		q.changed(snapshot)
+	}
+	return errors.Join(errs...)
+}
+
+// submits as many queued jobs as the limits allow
+func (q *TransferQueue) dispatch() {
+	for {
+		job, ok := q.next()
+		if !ok {
+			return
+		}
+
+		var err error
+		if job.Transfer.SubmissionId == "" && !isDryRun(q.client) {
+			// pre-allocate, so that a timed out submission isn't duplicated when retried
+			job.Transfer.SubmissionId, err = q.client.TransferGetSubmissionId()
+		}
+		var result TransferResult
+		if err == nil {
+			result, err = q.client.TransferPostTask(job.Transfer)
+		}
+
+		q.mu.Lock()
+		stored := q.jobs[job.Id]
+		stored.Transfer.SubmissionId = job.Transfer.SubmissionId
+		switch {
+		case err == nil && isDryRun(q.client):
+			// there's no task to watch
+			stored.State = JobDryRun
+			stored.Payload = result.Payload
+			stored.Error = ""
+			stored.FinishedAt = time.Now()
+		case err == nil && result.TaskId == "":
+			stored.State = JobSubmitFailed
+			stored.Attempts++
+			stored.Error = "the submission didn't return a task id"
+			stored.FinishedAt = time.Now()
+		case err == nil:
+			stored.TaskId = result.TaskId
+			stored.Error = ""
+		case IsTemporaryError(err):
+			stored.State = JobQueued
+			stored.StartedAt = time.Time{}
+			stored.Attempts++
+			stored.Error = err.Error()
+			stored.RetryAt = time.Now().Add(backoffDelay(stored.Attempts, q.opts.MinBackoff, q.opts.MaxBackoff))
+		default:
+			stored.State = JobSubmitFailed
+			stored.Attempts++
+			stored.Error = err.Error()
+			stored.FinishedAt = time.Now()
+		}
+		snapshot := *stored
+		q.mu.Unlock()
+		q.changed(snapshot)
+	}
+}
+
+// picks the next queued job that fits within the limits and marks it as active
+func (q *TransferQueue) next() (QueuedJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	active := 0
+	activePerPair := map[[2]string]int{}
+	for _, job := range q.order {
+		if job.State == JobActive {
+			active++
+			activePerPair[endpointPair(job.Transfer)]++
+		}
+	}
+	if q.opts.MaxActive > 0 && active >= q.opts.MaxActive {
+		return QueuedJob{}, false
+	}
+
+	now := time.Now()
+	for _, job := range q.pending() {
+		if now.Before(job.RetryAt) {
+			continue
+		}
+		if q.opts.MaxActivePerEndpointPair > 0 && activePerPair[endpointPair(job.Transfer)] >= q.opts.MaxActivePerEndpointPair {
+			continue
+		}
+		job.State = JobActive
+		job.StartedAt = time.Now()
+		return *job, true
+	}
+	return QueuedJob{}, false
+}
+
+// returns the earliest time a queued job waits for to be retried
+func (q *TransferQueue) nextRetry() (retryAt time.Time, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.order {
+		if job.State == JobQueued && time.Now().Before(job.RetryAt) && (!ok || job.RetryAt.Before(retryAt)) {
+			retryAt, ok = job.RetryAt, true
+		}
+	}
+	return retryAt, ok
+}
+
+// the queued jobs by descending priority, then in enqueue order. Must be called with the lock held.
+func (q *TransferQueue) pending() []*QueuedJob {
+	var pending []*QueuedJob
+	for _, job := range q.order {
+		if job.State == JobQueued {
+			pending = append(pending, job)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].Priority > pending[j].Priority
+	})
+	return pending
+}
+
+func (q *TransferQueue) changed(job QueuedJob) {
+	if q.opts.OnChange != nil {
+		q.opts.OnChange(job)
+	}
+}
+
+func (q *TransferQueue) wake() {
+	select {
+	case q.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func endpointPair(transfer Transfer) [2]string {
+	return [2]string{transfer.SourceEndpoint, transfer.DestinationEndpoint}
+}
@@ -0,0 +1,305 @@
+package globus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+)
+
+// starts draining the queue in the background, the returned channel receives Drain's result
+func drainQueue(t *testing.T, queue *globus.TransferQueue) <-chan error {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	done := make(chan error, 1)
+	go func() {
+		done <- queue.Drain(ctx, func(err error) {
+			t.Errorf("error while polling: %v", err)
+		})
+	}()
+	return done
+}
+
+// the number of active jobs whose task was created
+func submittedJobs(state globus.QueueState) (n int) {
+	for _, job := range state.Active {
+		if job.TaskId != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// waits until the queue state satisfies cond
+func waitForQueue(t *testing.T, queue *globus.TransferQueue, cond func(globus.QueueState) bool) globus.QueueState {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		state := queue.State()
+		if cond(state) {
+			return state
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out, queue state: %d queued, %d active, %d finished", len(state.Queued), len(state.Active), len(state.Finished))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func queueTransfer(label string, dstEndpoint string) globus.Transfer {
+	transfer := testTransfer()
+	transfer.DestinationEndpoint = dstEndpoint
+	transfer.Label = &label
+	return transfer
+}
+
+func TestTransferQueueMaxActive(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+
+	queue := globus.NewTransferQueue(srv.Client(), globus.QueueOptions{MaxActive: 2, PollInterval: 10 * time.Millisecond})
+	var jobIds []string
+	for _, label := range []string{"first", "second", "third"} {
+		jobId, err := queue.Enqueue(queueTransfer(label, dstEndpoint), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobIds = append(jobIds, jobId)
+	}
+	done := drainQueue(t, queue)
+
+	state := waitForQueue(t, queue, func(s globus.QueueState) bool { return submittedJobs(s) == 2 })
+	if len(state.Queued) != 1 || *state.Queued[0].Transfer.Label != "third" {
+		t.Errorf("got queued jobs %+v, want the third one", state.Queued)
+	}
+	if transfers, _ := srv.Submitted(); len(transfers) != 2 {
+		t.Errorf("%d tasks were submitted, want 2", len(transfers))
+	}
+
+	// the third job is submitted once the first two finished
+	srv.Advance(srv.TaskDuration)
+	waitForQueue(t, queue, func(s globus.QueueState) bool { return submittedJobs(s) == 1 && len(s.Queued) == 0 })
+	srv.Advance(srv.TaskDuration)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, jobId := range jobIds {
+		job, err := queue.Job(jobId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.State != globus.JobSucceeded || job.TaskId == "" {
+			t.Errorf("job %s is %s with task '%s', want SUCCEEDED", *job.Transfer.Label, job.State, job.TaskId)
+		}
+	}
+}
+
+func TestTransferQueueMaxActivePerEndpointPair(t *testing.T) {
+	const otherEndpoint = "6f1b0c3e-0000-4000-8000-00000000000c"
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+
+	queue := globus.NewTransferQueue(srv.Client(), globus.QueueOptions{MaxActivePerEndpointPair: 1, PollInterval: 10 * time.Millisecond})
+	for _, transfer := range []globus.Transfer{
+		queueTransfer("first", dstEndpoint),
+		queueTransfer("second", dstEndpoint),
+		queueTransfer("other", otherEndpoint),
+	} {
+		if _, err := queue.Enqueue(transfer, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done := drainQueue(t, queue)
+
+	state := waitForQueue(t, queue, func(s globus.QueueState) bool { return submittedJobs(s) == 2 })
+	if len(state.Queued) != 1 || *state.Queued[0].Transfer.Label != "second" {
+		t.Errorf("got queued jobs %+v, want the second one", state.Queued)
+	}
+
+	srv.Advance(srv.TaskDuration)
+	waitForQueue(t, queue, func(s globus.QueueState) bool { return submittedJobs(s) == 1 && len(s.Queued) == 0 })
+	srv.Advance(srv.TaskDuration)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if state := queue.State(); len(state.Finished) != 3 {
+		t.Errorf("%d jobs finished, want 3", len(state.Finished))
+	}
+}
+
+func TestTransferQueuePriority(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+
+	queue := globus.NewTransferQueue(srv.Client(), globus.QueueOptions{MaxActive: 1, PollInterval: 10 * time.Millisecond})
+	for label, priority := range map[string]int{"low": 1, "high": 5} {
+		if _, err := queue.Enqueue(queueTransfer(label, dstEndpoint), priority); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done := drainQueue(t, queue)
+
+	state := waitForQueue(t, queue, func(s globus.QueueState) bool { return submittedJobs(s) == 1 })
+	if label := *state.Active[0].Transfer.Label; label != "high" {
+		t.Errorf("the %s priority job was submitted first", label)
+	}
+	srv.Advance(srv.TaskDuration)
+	waitForQueue(t, queue, func(s globus.QueueState) bool { return submittedJobs(s) == 1 && len(s.Queued) == 0 })
+	srv.Advance(srv.TaskDuration)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransferQueueRetry(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+	srv.InjectFault(globustest.Fault{Method: "POST", PathPrefix: "/transfer", StatusCode: 503, Times: 2})
+
+	queue := globus.NewTransferQueue(srv.Client(), globus.QueueOptions{
+		PollInterval: 10 * time.Millisecond,
+		MinBackoff:   time.Millisecond,
+		MaxBackoff:   5 * time.Millisecond,
+	})
+	jobId, err := queue.Enqueue(testTransfer(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := drainQueue(t, queue)
+
+	waitForQueue(t, queue, func(s globus.QueueState) bool { return submittedJobs(s) == 1 })
+	srv.Advance(srv.TaskDuration)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := queue.Job(jobId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != globus.JobSucceeded || job.Attempts != 2 || job.Error != "" {
+		t.Errorf("got job %s after %d failed attempts (error '%s'), want SUCCEEDED after 2", job.State, job.Attempts, job.Error)
+	}
+	// the retries reuse the submission id
+	transfers, _ := srv.Submitted()
+	if len(transfers) != 1 || transfers[0].SubmissionId != job.Transfer.SubmissionId {
+		t.Errorf("got %d submitted tasks, want 1 with submission id %s", len(transfers), job.Transfer.SubmissionId)
+	}
+}
+
+func TestTransferQueueSubmitFailed(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(globustest.Fault{Method: "POST", PathPrefix: "/transfer", StatusCode: 400})
+
+	queue := globus.NewTransferQueue(srv.Client(), globus.QueueOptions{PollInterval: 10 * time.Millisecond})
+	jobId, err := queue.Enqueue(testTransfer(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-drainQueue(t, queue); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := queue.Job(jobId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != globus.JobSubmitFailed || job.Attempts != 1 || job.Error == "" {
+		t.Errorf("got job %s after %d attempts (error '%s'), want SUBMIT_FAILED after 1", job.State, job.Attempts, job.Error)
+	}
+}
+
+func TestTransferQueueDryRun(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+
+	queue := globus.NewTransferQueue(srv.Client().WithDryRun(true), globus.QueueOptions{PollInterval: time.Hour})
+	jobId, err := queue.Enqueue(testTransfer(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-drainQueue(t, queue); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := queue.Job(jobId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != globus.JobDryRun || len(job.Payload) == 0 {
+		t.Errorf("got job %s with a %d byte payload, want DRY_RUN with the document", job.State, len(job.Payload))
+	}
+	if transfers, _ := srv.Submitted(); len(transfers) != 0 {
+		t.Errorf("%d tasks were submitted in dry-run mode", len(transfers))
+	}
+}
+
+func TestTransferQueueCancel(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+
+	var changes []string
+	queue := globus.NewTransferQueue(srv.Client(), globus.QueueOptions{
+		PollInterval: 10 * time.Millisecond,
+		OnChange: func(job globus.QueuedJob) {
+			changes = append(changes, job.State)
+		},
+	})
+	jobId, err := queue.Enqueue(testTransfer(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Cancel(jobId); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Cancel(jobId); err == nil {
+		t.Error("a cancelled job was cancelled again")
+	}
+	if err := queue.Cancel("unknown"); !errors.Is(err, globus.ErrJobNotFound) {
+		t.Errorf("got %v for an unknown job, want ErrJobNotFound", err)
+	}
+
+	if !queue.IsIdle() {
+		t.Error("the queue isn't idle with only a cancelled job")
+	}
+	if err := <-drainQueue(t, queue); err != nil {
+		t.Fatal(err)
+	}
+	if transfers, _ := srv.Submitted(); len(transfers) != 0 {
+		t.Errorf("%d tasks were submitted for a cancelled job", len(transfers))
+	}
+	if len(changes) != 2 || changes[0] != globus.JobQueued || changes[1] != globus.JobCanceled {
+		t.Errorf("got state changes %v, want QUEUED and CANCELED", changes)
+	}
+}
+
+func TestTransferQueueDrainCancelled(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+
+	queue := globus.NewTransferQueue(srv.Client(), globus.QueueOptions{PollInterval: 10 * time.Millisecond})
+	if _, err := queue.Enqueue(testTransfer(), 0); err != nil {
+		t.Fatal(err)
+	}
+	// the task never finishes, as the fake clock doesn't move
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := queue.Drain(ctx, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want the context's error", err)
+	}
+	if state := queue.State(); len(state.Active) != 1 {
+		t.Errorf("%d jobs are active, want 1", len(state.Active))
+	}
+}
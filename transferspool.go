@@ -0,0 +1,267 @@
+package globus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// a transfer waiting in the spool for the Transfer API to become reachable again
+type SpoolEntry struct {
+	Id          string    `json:"id"`
+	Transfer    Transfer  `json:"transfer"` // its submission id is kept between attempts once allocated
+	SpooledAt   time.Time `json:"spooled_at"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+type SpoolOptions struct {
+	MinBackoff    time.Duration // delay before the first retry, doubled on every failure (default: 30 seconds)
+	MaxBackoff    time.Duration // max. delay between retries (default: 30 minutes)
+	CheckInterval time.Duration // how often Run checks for entries that are due (default: 10 seconds)
+
+	// called when a spooled transfer was submitted, optional
+	OnSubmitted func(entry SpoolEntry, result TransferResult)
+	// called when a spooled transfer was rejected for good and moved out of the spool, optional
+	OnRejected func(entry SpoolEntry, err error)
+}
+
+// A transfer spool makes submissions independent of the availability of the Transfer API.
+// Transfers that can't be submitted because of temporary errors (see IsTemporaryError) are
+// persisted in a directory, one file per transfer, and retried in the background with
+// exponential backoff. The submission id of a spooled transfer is allocated once and kept,
+// so retries can't create duplicate tasks. Transfers that are rejected by the API are moved
+// to files with the ".rejected" extension for inspection, entry files that can't be parsed
+// to files with the ".corrupt" extension.
+type TransferSpool struct {
+	client TransferAPI
+	dir    string
+	opts   SpoolOptions
+
+	mu sync.Mutex // serializes the processing of entries
+}
+
+const spoolEntryExt = ".json"
+const spoolRejectedExt = ".rejected"
+const spoolCorruptExt = ".corrupt"
+
+// opens a spool directory, creating it if needed
+func NewTransferSpool(client TransferAPI, dir string, opts SpoolOptions) (*TransferSpool, error) {
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 30 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Minute
+	}
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = 10 * time.Second
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &TransferSpool{client: client, dir: dir, opts: opts}, nil
+}
+
+// Submits a transfer right away if possible. If the submission fails with a temporary error,
+// the transfer is spooled for later and spooled is true. Other errors are returned as-is.
+func (s *TransferSpool) Submit(transfer Transfer) (result TransferResult, spooled bool, err error) {
+	if transfer.SubmissionId == "" && !isDryRun(s.client) {
+		// pre-allocate, so that a timed out submission isn't duplicated when retried
+		transfer.SubmissionId, err = s.client.TransferGetSubmissionId()
+		if err != nil && !IsTemporaryError(err) {
+			return TransferResult{}, false, err
+		}
+	}
+
+	if err == nil {
+		result, err = s.client.TransferPostTask(transfer)
+		if err == nil || !IsTemporaryError(err) {
+			return result, false, err
+		}
+	}
+
+	entry := SpoolEntry{
+		Transfer:    transfer,
+		SpooledAt:   time.Now(),
+		Attempts:    1,
+		LastError:   err.Error(),
+		NextAttempt: time.Now().Add(s.opts.MinBackoff),
+	}
+	entry.Id, err = randomUUID()
+	if err != nil {
+		return TransferResult{}, false, err
+	}
+	if err := s.save(entry); err != nil {
+		return TransferResult{}, false, fmt.Errorf("could not spool transfer: %v", err)
+	}
+	return TransferResult{}, true, nil
+}
+
+// returns the number of transfers waiting in the spool
+func (s *TransferSpool) Depth() (int, error) {
+	entries, err := s.Entries()
+	return len(entries), err
+}
+
+// Returns the transfers waiting in the spool, oldest first. Entry files that can't be read are
+// skipped and reported in the error, along with the readable entries. Files that can't be parsed
+// are moved out of the way to files with the ".corrupt" extension.
+func (s *TransferSpool) Entries() ([]SpoolEntry, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*"+spoolEntryExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []SpoolEntry
+	var errs []error
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue // submitted in the meantime
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("could not read spool entry '%s': %v", file, err))
+			continue
+		}
+		var entry SpoolEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			corrupt := strings.TrimSuffix(file, spoolEntryExt) + spoolCorruptExt
+			if renameErr := os.Rename(file, corrupt); renameErr != nil {
+				errs = append(errs, fmt.Errorf("could not parse spool entry '%s': %v", file, err))
+			} else {
+				errs = append(errs, fmt.Errorf("could not parse spool entry '%s', moved it to '%s': %v", file, corrupt, err))
+			}
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].SpooledAt.Before(entries[j].SpooledAt)
+	})
+	return entries, errors.Join(errs...)
+}
+
+// Retries all entries that are due (or all entries, if force is set) once.
+// Returns the number of entries left in the spool.
+func (s *TransferSpool) Flush(force bool) (depth int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// unreadable entries don't keep the others from being retried
+	entries, err := s.Entries()
+	if entries == nil && err != nil {
+		return 0, err
+	}
+
+	errs := []error{err}
+	for _, entry := range entries {
+		if !force && time.Now().Before(entry.NextAttempt) {
+			depth++
+			continue
+		}
+		if err := s.retry(entry); err != nil {
+			errs = append(errs, err)
+		}
+		if _, err := os.Stat(s.entryPath(entry.Id)); err == nil {
+			depth++
+		}
+	}
+	return depth, errors.Join(errs...)
+}
+
+// Retries the spooled transfers in the background until the context is cancelled.
+// Errors accessing the spool directory are passed to onError (if set).
+func (s *TransferSpool) Run(ctx context.Context, onError func(error)) error {
+	ticker := time.NewTicker(s.opts.CheckInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Flush(false); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// tries to submit an entry, updating or removing its file depending on the outcome
+func (s *TransferSpool) retry(entry SpoolEntry) error {
+	var err error
+	if entry.Transfer.SubmissionId == "" && !isDryRun(s.client) {
+		entry.Transfer.SubmissionId, err = s.client.TransferGetSubmissionId()
+		if err == nil {
+			// persist the id before posting, so a crash can't lead to a duplicate task
+			if err := s.save(entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	var result TransferResult
+	if err == nil {
+		result, err = s.client.TransferPostTask(entry.Transfer)
+	}
+
+	switch {
+	case err == nil:
+		if err := os.Remove(s.entryPath(entry.Id)); err != nil {
+			return err
+		}
+		if s.opts.OnSubmitted != nil {
+			s.opts.OnSubmitted(entry, result)
+		}
+		return nil
+	case !IsTemporaryError(err):
+		entry.LastError = err.Error()
+		if err := s.save(entry); err != nil {
+			return err
+		}
+		if err := os.Rename(s.entryPath(entry.Id), strings.TrimSuffix(s.entryPath(entry.Id), spoolEntryExt)+spoolRejectedExt); err != nil {
+			return err
+		}
+		if s.opts.OnRejected != nil {
+			s.opts.OnRejected(entry, err)
+		}
+		return nil
+	default:
+		entry.Attempts++
+		entry.LastError = err.Error()
+		entry.NextAttempt = time.Now().Add(s.backoff(entry.Attempts))
+		return s.save(entry)
+	}
+}
+
+// the delay after the given number of failed attempts
+func (s *TransferSpool) backoff(attempts int) time.Duration {
+	return backoffDelay(attempts, s.opts.MinBackoff, s.opts.MaxBackoff)
+}
+
+// exponential backoff: minDelay after the first failed attempt, doubled after every further one up to maxDelay
+func backoffDelay(attempts int, minDelay, maxDelay time.Duration) time.Duration {
+	delay := minDelay
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+func (s *TransferSpool) entryPath(id string) string {
+	return filepath.Join(s.dir, id+spoolEntryExt)
+}
+
+func (s *TransferSpool) save(entry SpoolEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.entryPath(entry.Id), data, 0600)
+}
@@ -0,0 +1,308 @@
+package globus_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+)
+
+// spools a transfer by making its submission fail with a temporary error
+func spoolTransfer(t *testing.T, srv *globustest.Server, spool *globus.TransferSpool) globus.SpoolEntry {
+	t.Helper()
+	srv.InjectFault(globustest.Fault{Method: http.MethodPost, PathPrefix: "/transfer", StatusCode: http.StatusServiceUnavailable})
+	_, spooled, err := spool.Submit(testTransfer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !spooled {
+		t.Fatal("the transfer wasn't spooled")
+	}
+	entries, err := spool.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("the spool is empty")
+	}
+	return entries[len(entries)-1]
+}
+
+func checkDepth(t *testing.T, spool *globus.TransferSpool, want int) {
+	t.Helper()
+	if depth, err := spool.Depth(); err != nil || depth != want {
+		t.Errorf("got depth %d (%v), want %d", depth, err, want)
+	}
+}
+
+func TestTransferSpoolSubmit(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+	spool, err := globus.NewTransferSpool(srv.Client(), t.TempDir(), globus.SpoolOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, spooled, err := spool.Submit(testTransfer())
+	if err != nil || spooled || result.TaskId == "" {
+		t.Fatalf("got task '%s', spooled %t (%v), want a submitted task", result.TaskId, spooled, err)
+	}
+	checkDepth(t, spool, 0)
+
+	// errors that won't go away by retrying aren't spooled
+	srv.InjectFault(globustest.Fault{Method: http.MethodPost, PathPrefix: "/transfer", StatusCode: http.StatusBadRequest})
+	if _, spooled, err := spool.Submit(testTransfer()); err == nil || spooled {
+		t.Errorf("got spooled %t (%v), want the error", spooled, err)
+	}
+	checkDepth(t, spool, 0)
+}
+
+func TestTransferSpoolFlush(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+	var submitted []globus.SpoolEntry
+	spool, err := globus.NewTransferSpool(srv.Client(), t.TempDir(), globus.SpoolOptions{
+		OnSubmitted: func(entry globus.SpoolEntry, result globus.TransferResult) {
+			submitted = append(submitted, entry)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := spoolTransfer(t, srv, spool)
+	if entry.Transfer.SubmissionId == "" || entry.Attempts != 1 || entry.LastError == "" {
+		t.Errorf("got entry %+v, want one attempt with a submission id", entry)
+	}
+	checkDepth(t, spool, 1)
+
+	// the entry isn't due yet
+	if depth, err := spool.Flush(false); err != nil || depth != 1 {
+		t.Errorf("got depth %d (%v), want 1", depth, err)
+	}
+	if transfers, _ := srv.Submitted(); len(transfers) != 0 {
+		t.Fatalf("%d tasks were submitted before the entry was due", len(transfers))
+	}
+
+	if depth, err := spool.Flush(true); err != nil || depth != 0 {
+		t.Errorf("got depth %d (%v), want 0", depth, err)
+	}
+	transfers, _ := srv.Submitted()
+	if len(transfers) != 1 || transfers[0].SubmissionId != entry.Transfer.SubmissionId {
+		t.Errorf("got %d tasks, want 1 with the spooled submission id", len(transfers))
+	}
+	if len(submitted) != 1 || submitted[0].Id != entry.Id {
+		t.Errorf("OnSubmitted was called for %d entries, want the spooled one", len(submitted))
+	}
+}
+
+func TestTransferSpoolSubmissionIdUnavailable(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+	spool, err := globus.NewTransferSpool(srv.Client(), t.TempDir(), globus.SpoolOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the transfer is spooled without submission id, which is allocated on the retry
+	srv.InjectFault(globustest.Fault{Method: http.MethodGet, PathPrefix: "/submission_id", StatusCode: http.StatusServiceUnavailable})
+	if _, spooled, err := spool.Submit(testTransfer()); err != nil || !spooled {
+		t.Fatalf("got spooled %t (%v), want the transfer spooled", spooled, err)
+	}
+	entries, err := spool.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Transfer.SubmissionId != "" {
+		t.Fatalf("got %d entries, want one without submission id", len(entries))
+	}
+
+	if depth, err := spool.Flush(true); err != nil || depth != 0 {
+		t.Errorf("got depth %d (%v), want 0", depth, err)
+	}
+	if transfers, _ := srv.Submitted(); len(transfers) != 1 || transfers[0].SubmissionId == "" {
+		t.Errorf("got %d tasks, want 1 with a submission id", len(transfers))
+	}
+}
+
+func TestTransferSpoolReplay(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+	dir := t.TempDir()
+	spool, err := globus.NewTransferSpool(srv.Client(), dir, globus.SpoolOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := spoolTransfer(t, srv, spool)
+	second := spoolTransfer(t, srv, spool)
+
+	// a spool opened on the directory after a restart submits the entries
+	spool, err = globus.NewTransferSpool(srv.Client(), dir, globus.SpoolOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := spool.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Id != first.Id || entries[1].Id != second.Id {
+		t.Fatalf("got %d entries, want the spooled ones, oldest first", len(entries))
+	}
+	if depth, err := spool.Flush(true); err != nil || depth != 0 {
+		t.Errorf("got depth %d (%v), want 0", depth, err)
+	}
+	if transfers, _ := srv.Submitted(); len(transfers) != 2 {
+		t.Errorf("got %d tasks, want 2", len(transfers))
+	}
+}
+
+func TestTransferSpoolRejected(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	dir := t.TempDir()
+	var rejected []globus.SpoolEntry
+	spool, err := globus.NewTransferSpool(srv.Client(), dir, globus.SpoolOptions{
+		OnRejected: func(entry globus.SpoolEntry, err error) {
+			rejected = append(rejected, entry)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := spoolTransfer(t, srv, spool)
+
+	srv.InjectFault(globustest.Fault{Method: http.MethodPost, PathPrefix: "/transfer", StatusCode: http.StatusBadRequest})
+	if depth, err := spool.Flush(true); err != nil || depth != 0 {
+		t.Errorf("got depth %d (%v), want 0", depth, err)
+	}
+	if len(rejected) != 1 || rejected[0].Id != entry.Id {
+		t.Errorf("OnRejected was called for %d entries, want the spooled one", len(rejected))
+	}
+	data, err := os.ReadFile(filepath.Join(dir, entry.Id+".rejected"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "injected fault") {
+		t.Error("the rejected entry lacks the error")
+	}
+}
+
+func TestTransferSpoolCorruptEntry(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+	dir := t.TempDir()
+	spool, err := globus.NewTransferSpool(srv.Client(), dir, globus.SpoolOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spoolTransfer(t, srv, spool)
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the corrupt entry is moved out of the way and doesn't keep the other one from being submitted
+	depth, err := spool.Flush(true)
+	if err == nil || !strings.Contains(err.Error(), "corrupt.corrupt") {
+		t.Errorf("got %v, want an error naming the quarantined file", err)
+	}
+	if depth != 0 {
+		t.Errorf("got depth %d, want 0", depth)
+	}
+	if transfers, _ := srv.Submitted(); len(transfers) != 1 {
+		t.Errorf("got %d tasks, want 1", len(transfers))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "corrupt.corrupt")); err != nil {
+		t.Errorf("the corrupt entry wasn't quarantined: %v", err)
+	}
+	if _, err := spool.Entries(); err != nil {
+		t.Errorf("got %v after the quarantine", err)
+	}
+}
+
+func TestTransferSpoolBackoff(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	spool, err := globus.NewTransferSpool(srv.Client(), t.TempDir(), globus.SpoolOptions{MinBackoff: time.Second, MaxBackoff: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now()
+	entry := spoolTransfer(t, srv, spool)
+	if entry.NextAttempt.Before(before.Add(time.Second)) || entry.NextAttempt.After(time.Now().Add(time.Second)) {
+		t.Errorf("the first retry is due at %s, want after the min. backoff", entry.NextAttempt)
+	}
+
+	// the delay doubles after every failed retry, up to the max. backoff
+	for _, want := range []struct {
+		attempts int
+		delay    time.Duration
+	}{{2, 2 * time.Second}, {3, 4 * time.Second}, {4, 5 * time.Second}, {5, 5 * time.Second}} {
+		srv.InjectFault(globustest.Fault{Method: http.MethodPost, PathPrefix: "/transfer", StatusCode: http.StatusServiceUnavailable})
+		before := time.Now()
+		if depth, err := spool.Flush(true); err != nil || depth != 1 {
+			t.Fatalf("got depth %d (%v), want 1", depth, err)
+		}
+		after := time.Now()
+		entries, err := spool.Entries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry := entries[0]
+		if entry.Attempts != want.attempts {
+			t.Errorf("got %d attempts, want %d", entry.Attempts, want.attempts)
+		}
+		if entry.NextAttempt.Before(before.Add(want.delay)) || entry.NextAttempt.After(after.Add(want.delay)) {
+			t.Errorf("after %d attempts, the next one is due in %s, want %s", entry.Attempts, entry.NextAttempt.Sub(before), want.delay)
+		}
+	}
+}
+
+func TestTransferSpoolRun(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+	submitted := make(chan globus.SpoolEntry, 1)
+	spool, err := globus.NewTransferSpool(srv.Client(), t.TempDir(), globus.SpoolOptions{
+		MinBackoff:    20 * time.Millisecond,
+		CheckInterval: 5 * time.Millisecond,
+		OnSubmitted: func(entry globus.SpoolEntry, result globus.TransferResult) {
+			submitted <- entry
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := spoolTransfer(t, srv, spool)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- spool.Run(ctx, func(err error) { t.Errorf("error while flushing: %v", err) })
+	}()
+	select {
+	case got := <-submitted:
+		if got.Id != entry.Id {
+			t.Errorf("got entry %s, want %s", got.Id, entry.Id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the spooled transfer wasn't submitted in the background")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got %v, want the cancellation", err)
+	}
+	checkDepth(t, spool, 0)
+}
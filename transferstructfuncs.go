@@ -19,3 +19,8 @@ func (pauseRule *PauseRuleLimited) UnmarshalJSON(data []byte) error {
 	*pauseRule = PauseRuleLimited(*inner)
 	return nil
 }
+
+// true if the submission id had already been used and the result refers to the original task
+func (r TransferResult) IsDuplicate() bool {
+	return r.Code == "Duplicate"
+}
@@ -59,8 +59,8 @@ type Transfer struct {
 
 type Delete struct {
 	CommonTransfer
-	Endpoint string `json:"endpoint"`
-	Data     []DeleteItem
+	Endpoint string       `json:"endpoint"`
+	Data     []DeleteItem `json:"DATA"`
 	// optionals
 	Recursive      *bool   `json:"recursive,omitempty"`       // default: false, required if any item is a directory
 	IgnoreMissing  *bool   `json:"ignore_missing,omitempty"`  // default: false
@@ -82,7 +82,9 @@ type TransferResult struct {
 	Code         string `json:"code"`
 	Message      string `json:"message"`
 	Resource     string `json:"resource"`
-	RequestId    string `json:"requst_id"`
+	RequestId    string `json:"request_id"`
+
+	Payload []byte `json:"-"` // the document that would have been submitted (dry-run only)
 }
 
 type ConsentRequired struct {
@@ -167,7 +169,7 @@ type TaskList struct {
 	Limit    int    `json:"limit"`
 	Offset   int    `json:"offset"`
 	Total    int    `json:"total"`
-	Data     []Task `json:"Data"`
+	Data     []Task `json:"DATA"`
 }
 
 type Result struct {
@@ -190,6 +192,7 @@ type Event struct {
 type EventList struct {
 	Data     []Event `json:"DATA"`
 	DataType string  `json:"DATA_TYPE"`
+	Length   uint    `json:"length"`
 	Limit    uint    `json:"limit"`
 	Offset   uint    `json:"offset"`
 	Total    uint    `json:"total"`
@@ -217,6 +220,7 @@ type SkippedError struct {
 	TransferItem                    // Recursive will always be null
 	ErrorCode                string `json:"error_code"`
 	ErrorDetails             string `json:"error_details"`
+	ErrorTime                string `json:"error_time"` // ISO8601
 	IsDirectory              bool   `json:"is_directory"`
 	IsSymlink                bool   `json:"is_symlink"`
 	IsDeleteDestinationExtra *bool  `json:"is_delete_destination_extra,omitempty"`
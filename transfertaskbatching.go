@@ -0,0 +1,236 @@
+package globus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+// default limits used for splitting a transfer into multiple tasks
+const (
+	defaultBatchMaxItems        = 10000
+	defaultBatchMaxPayloadBytes = 8 * 1024 * 1024
+	maxLabelLength              = 128
+)
+
+// options for splitting a transfer into several tasks
+type BatchOptions struct {
+	MaxItems        int    // max. number of transfer items per task (default: 10000)
+	MaxPayloadBytes int    // max. size of the marshalled request body per task (default: 8MiB)
+	Parallelism     int    // number of tasks submitted at the same time (default: 1)
+	Label           string // label shared by the tasks, suffixed by group id and part number
+}
+
+// a handle to a set of tasks that were submitted as parts of the same transfer
+type TaskGroup struct {
+	GroupId string
+	Label   string
+	Results []TransferResult // in the order of the parts, empty entries for failed submissions
+}
+
+// aggregated status and progress of the tasks in a task group
+type TaskGroupStatus struct {
+	Status            string // ACTIVE, INACTIVE, SUCCEEDED or FAILED, derived from the tasks' statuses
+	Tasks             []Task
+	Files             int
+	FilesTransferred  int
+	FilesSkipped      int
+	Directories       int
+	BytesTransferred  int
+	Faults            int
+	SubtasksTotal     int
+	SubtasksSucceeded int
+	SubtasksFailed    int
+	SubtasksPending   int
+}
+
+// returns the ids of the tasks that were successfully submitted
+func (g TaskGroup) TaskIds() (taskIds []string) {
+	for _, result := range g.Results {
+		if result.TaskId != "" {
+			taskIds = append(taskIds, result.TaskId)
+		}
+	}
+	return taskIds
+}
+
+// splits the items of a transfer into chunks, respecting both the item count
+// and the payload size limits. The other fields of the transfer are kept as-is, except for the
+// submission id, which identifies a single task: it's cleared so every chunk gets its own.
+func SplitTransfer(transfer Transfer, maxItems int, maxPayloadBytes int) ([]Transfer, error) {
+	if maxItems <= 0 {
+		maxItems = defaultBatchMaxItems
+	}
+	if maxPayloadBytes <= 0 {
+		maxPayloadBytes = defaultBatchMaxPayloadBytes
+	}
+
+	// size of the request without any items
+	skeleton := transfer
+	skeleton.Data = []TransferItem{}
+	skeletonJSON, err := json.Marshal(skeleton)
+	if err != nil {
+		return nil, err
+	}
+	baseSize := len(skeletonJSON)
+
+	var chunks []Transfer
+	var current []TransferItem
+	currentSize := baseSize
+	for _, item := range transfer.Data {
+		itemJSON, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		itemSize := len(itemJSON) + 1 // separating comma
+		if baseSize+itemSize > maxPayloadBytes {
+			return nil, fmt.Errorf("transfer item '%s' alone exceeds the payload size limit of %d bytes", item.SourcePath, maxPayloadBytes)
+		}
+
+		if len(current) >= maxItems || currentSize+itemSize > maxPayloadBytes {
+			chunk := transfer
+			chunk.SubmissionId = ""
+			chunk.Data = current
+			chunks = append(chunks, chunk)
+			current = nil
+			currentSize = baseSize
+		}
+		current = append(current, item)
+		currentSize += itemSize
+	}
+	if len(current) > 0 || len(chunks) == 0 {
+		chunk := transfer
+		chunk.SubmissionId = ""
+		chunk.Data = current
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, nil
+}
+
+// Submits a transfer as one or more tasks, splitting its items by count and payload size.
+// Every task gets a label containing the shared group id and its part number.
+// If some parts fail to be submitted, the returned group contains the parts that succeeded
+// and the error lists the failed ones. A pre-allocated submission id can only be used if the
+// transfer fits into a single task, the parts of larger ones get their own ids.
+func (c GlobusClient) TransferPostTaskBatched(transfer Transfer, opts BatchOptions) (group TaskGroup, err error) {
+	chunks, err := SplitTransfer(transfer, opts.MaxItems, opts.MaxPayloadBytes)
+	if err != nil {
+		return TaskGroup{}, err
+	}
+	if transfer.SubmissionId != "" {
+		if len(chunks) > 1 {
+			return TaskGroup{}, fmt.Errorf("the transfer is split into %d tasks, but a submission id identifies a single task", len(chunks))
+		}
+		chunks[0].SubmissionId = transfer.SubmissionId
+	}
+
+	group.GroupId, err = randomUUID()
+	if err != nil {
+		return TaskGroup{}, err
+	}
+	group.Label = opts.Label
+	if group.Label == "" && transfer.Label != nil {
+		group.Label = *transfer.Label
+	}
+	group.Results = make([]TransferResult, len(chunks))
+
+	parallelism := opts.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := range chunks {
+		chunks[i].Label = stringPointer(batchLabel(group.Label, group.GroupId, i+1, len(chunks)))
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result, err := c.TransferPostTask(chunks[i])
+			if err != nil {
+				errs[i] = fmt.Errorf("part %d of %d: %w", i+1, len(chunks), err)
+				return
+			}
+			group.Results[i] = result
+		}(i)
+	}
+	wg.Wait()
+
+	return group, errors.Join(errs...)
+}
+
+// Fetches the tasks of a task group and aggregates their status and progress.
+func (c GlobusClient) TransferGetTaskGroupStatus(group TaskGroup) (status TaskGroupStatus, err error) {
+	taskIds := group.TaskIds()
+	if len(taskIds) == 0 {
+		return TaskGroupStatus{}, fmt.Errorf("task group '%s' has no submitted tasks", group.GroupId)
+	}
+
+	for _, taskId := range taskIds {
+		task, err := c.TransferGetTaskByID(taskId)
+		if err != nil {
+			return TaskGroupStatus{}, fmt.Errorf("task '%s': %v", taskId, err)
+		}
+		status.Tasks = append(status.Tasks, task)
+	}
+
+	status.Status = "SUCCEEDED"
+	for _, task := range status.Tasks {
+		status.Files += task.Files
+		status.FilesTransferred += task.FilesTransferred
+		if task.FilesSkipped != nil {
+			status.FilesSkipped += *task.FilesSkipped
+		}
+		status.Directories += task.Directories
+		status.BytesTransferred += task.BytesTransferred
+		status.Faults += task.Faults
+		status.SubtasksTotal += task.SubtasksTotal
+		status.SubtasksSucceeded += task.SubtasksSucceeded
+		status.SubtasksFailed += task.SubtasksFailed
+		status.SubtasksPending += task.SubtasksPending
+
+		switch task.Status {
+		case "ACTIVE":
+			if status.Status != "INACTIVE" {
+				status.Status = "ACTIVE"
+			}
+		case "INACTIVE":
+			status.Status = "INACTIVE"
+		case "FAILED":
+			if status.Status == "SUCCEEDED" {
+				status.Status = "FAILED"
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// true if no task of the group is still running
+func (s TaskGroupStatus) IsDone() bool {
+	return s.Status == "SUCCEEDED" || s.Status == "FAILED"
+}
+
+// builds the label of a task group's part, keeping it within Globus' length limit
+func batchLabel(label string, groupId string, part int, total int) string {
+	suffix := fmt.Sprintf("group %s part %d of %d", groupId, part, total)
+	if label == "" {
+		return suffix
+	}
+	maxBase := maxLabelLength - len(suffix) - 3
+	if len(label) > maxBase {
+		// cut on a rune boundary, a split multi-byte character would make the label invalid
+		for maxBase > 0 && !utf8.RuneStart(label[maxBase]) {
+			maxBase--
+		}
+		label = label[:maxBase]
+	}
+	return label + " - " + suffix
+}
@@ -0,0 +1,271 @@
+package globus_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+)
+
+func batchTransfer(n int) globus.Transfer {
+	transfer := testTransfer()
+	transfer.SubmissionId = "6f1b0c3e-0000-4000-8000-0000000000ff"
+	transfer.Data = nil
+	for i := 0; i < n; i++ {
+		transfer.Data = append(transfer.Data, globus.TransferItem{
+			DataType:        "transfer_item",
+			SourcePath:      fmt.Sprintf("/data/file%03d.txt", i),
+			DestinationPath: fmt.Sprintf("/archive/file%03d.txt", i),
+		})
+	}
+	return transfer
+}
+
+// checks that the chunks contain all items of the transfer in order
+func checkChunks(t *testing.T, transfer globus.Transfer, chunks []globus.Transfer) {
+	t.Helper()
+	var items []globus.TransferItem
+	for _, chunk := range chunks {
+		if chunk.SubmissionId != "" {
+			t.Error("a chunk kept the submission id of the transfer")
+		}
+		if chunk.SourceEndpoint != transfer.SourceEndpoint || chunk.DestinationEndpoint != transfer.DestinationEndpoint {
+			t.Error("a chunk has other endpoints than the transfer")
+		}
+		items = append(items, chunk.Data...)
+	}
+	if len(items) != len(transfer.Data) {
+		t.Fatalf("the chunks have %d items, want %d", len(items), len(transfer.Data))
+	}
+	for i := range items {
+		if items[i] != transfer.Data[i] {
+			t.Errorf("item %d is %+v, want %+v", i, items[i], transfer.Data[i])
+		}
+	}
+}
+
+func TestSplitTransferMaxItems(t *testing.T) {
+	transfer := batchTransfer(25)
+	chunks, err := globus.SplitTransfer(transfer, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkChunks(t, transfer, chunks)
+	var sizes []int
+	for _, chunk := range chunks {
+		sizes = append(sizes, len(chunk.Data))
+	}
+	if fmt.Sprint(sizes) != "[10 10 5]" {
+		t.Errorf("got chunks of %v items, want [10 10 5]", sizes)
+	}
+}
+
+func TestSplitTransferMaxPayloadBytes(t *testing.T) {
+	transfer := batchTransfer(50)
+	const maxPayloadBytes = 2000
+	chunks, err := globus.SplitTransfer(transfer, 0, maxPayloadBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkChunks(t, transfer, chunks)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunk, want the transfer to be split", len(chunks))
+	}
+	for i, chunk := range chunks {
+		payload, err := json.Marshal(chunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(payload) > maxPayloadBytes {
+			t.Errorf("chunk %d has %d bytes, more than %d", i, len(payload), maxPayloadBytes)
+		}
+	}
+}
+
+func TestSplitTransferItemTooLarge(t *testing.T) {
+	transfer := batchTransfer(3)
+	transfer.Data[1].SourcePath = "/data/" + strings.Repeat("x", 1000)
+	_, err := globus.SplitTransfer(transfer, 0, 800)
+	if err == nil || !strings.Contains(err.Error(), transfer.Data[1].SourcePath) {
+		t.Errorf("got %v, want an error naming the large item", err)
+	}
+}
+
+func TestSplitTransferEmpty(t *testing.T) {
+	chunks, err := globus.SplitTransfer(batchTransfer(0), 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || len(chunks[0].Data) != 0 {
+		t.Errorf("got %d chunks, want a single empty one", len(chunks))
+	}
+}
+
+func TestTransferPostTaskBatched(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	transfer := batchTransfer(5)
+	transfer.SubmissionId = ""
+	for _, item := range transfer.Data {
+		srv.AddFile(srcEndpoint, item.SourcePath, 10)
+	}
+	client := srv.Client()
+
+	group, err := client.TransferPostTaskBatched(transfer, globus.BatchOptions{MaxItems: 2, Parallelism: 2, Label: "nightly"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(group.TaskIds()) != 3 {
+		t.Fatalf("got %d tasks, want 3", len(group.TaskIds()))
+	}
+
+	submitted, _ := srv.Submitted()
+	if len(submitted) != 3 {
+		t.Fatalf("%d tasks were submitted, want 3", len(submitted))
+	}
+	labels := map[string]bool{}
+	for _, task := range submitted {
+		labels[*task.Label] = true
+	}
+	for part := 1; part <= 3; part++ {
+		label := fmt.Sprintf("nightly - group %s part %d of 3", group.GroupId, part)
+		if !labels[label] {
+			t.Errorf("no task is labelled '%s', got %v", label, labels)
+		}
+	}
+
+	status, err := client.TransferGetTaskGroupStatus(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != "ACTIVE" || status.IsDone() {
+		t.Errorf("got group status %s, want ACTIVE", status.Status)
+	}
+
+	srv.Advance(srv.TaskDuration)
+	status, err = client.TransferGetTaskGroupStatus(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != "SUCCEEDED" || !status.IsDone() || len(status.Tasks) != 3 {
+		t.Errorf("got group status %s with %d tasks, want SUCCEEDED with 3", status.Status, len(status.Tasks))
+	}
+	if status.Files != 5 || status.FilesTransferred != 5 || status.BytesTransferred != 50 {
+		t.Errorf("got %d of %d files and %d bytes transferred, want 5 files and 50 bytes", status.FilesTransferred, status.Files, status.BytesTransferred)
+	}
+}
+
+func TestTransferPostTaskBatchedSubmissionId(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+
+	// a submission id identifies a single task
+	if _, err := client.TransferPostTaskBatched(batchTransfer(5), globus.BatchOptions{MaxItems: 2}); err == nil {
+		t.Error("a transfer with a submission id was split")
+	}
+	transfers, _ := srv.Submitted()
+	if len(transfers) != 0 {
+		t.Errorf("%d tasks were submitted", len(transfers))
+	}
+
+	transfer := batchTransfer(1)
+	var err error
+	if transfer.SubmissionId, err = client.TransferGetSubmissionId(); err != nil {
+		t.Fatal(err)
+	}
+	srv.AddFile(srcEndpoint, transfer.Data[0].SourcePath, 10)
+	if _, err := client.TransferPostTaskBatched(transfer, globus.BatchOptions{MaxItems: 2}); err != nil {
+		t.Fatal(err)
+	}
+	transfers, _ = srv.Submitted()
+	if len(transfers) != 1 || transfers[0].SubmissionId != transfer.SubmissionId {
+		t.Errorf("got %d tasks, want 1 with the given submission id", len(transfers))
+	}
+}
+
+func TestTransferPostTaskBatchedLabel(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+
+	// with the suffix, 71 bytes are left for the label, which ends in the middle of the 6th "ü"
+	label := strings.Repeat("a", 60) + strings.Repeat("ü", 20)
+	transfer := batchTransfer(2)
+	transfer.SubmissionId = ""
+	transfer.Label = &label
+	group, err := client.TransferPostTaskBatched(transfer, globus.BatchOptions{MaxItems: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.Label != label {
+		t.Errorf("got group label '%s', want the transfer's label", group.Label)
+	}
+
+	submitted, _ := srv.Submitted()
+	for i, task := range submitted {
+		if len(*task.Label) > 128 || !utf8.ValidString(*task.Label) {
+			t.Errorf("got the invalid label '%s' (%d bytes)", *task.Label, len(*task.Label))
+		}
+		want := fmt.Sprintf("%s%s - group %s part %d of 2", strings.Repeat("a", 60), strings.Repeat("ü", 5), group.GroupId, i+1)
+		if *task.Label != want {
+			t.Errorf("got label '%s', want '%s'", *task.Label, want)
+		}
+	}
+
+	// without a label, the tasks are named after the group
+	transfer.Label = nil
+	group, err = client.TransferPostTaskBatched(transfer, globus.BatchOptions{MaxItems: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	submitted, _ = srv.Submitted()
+	if got, want := *submitted[len(submitted)-1].Label, fmt.Sprintf("group %s part 2 of 2", group.GroupId); got != want {
+		t.Errorf("got label '%s', want '%s'", got, want)
+	}
+}
+
+func TestTransferGetTaskGroupStatusFailed(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	transfer := batchTransfer(2)
+	transfer.SubmissionId = ""
+	for _, item := range transfer.Data {
+		srv.AddFile(srcEndpoint, item.SourcePath, 10)
+	}
+	client := srv.Client()
+
+	group, err := client.TransferPostTaskBatched(transfer, globus.BatchOptions{MaxItems: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.FailTask(group.Results[0].TaskId, "PERMISSION_DENIED", "permission denied"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed task doesn't end the group while others are active
+	status, err := client.TransferGetTaskGroupStatus(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != "ACTIVE" {
+		t.Errorf("got group status %s, want ACTIVE", status.Status)
+	}
+
+	srv.Advance(srv.TaskDuration)
+	status, err = client.TransferGetTaskGroupStatus(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != "FAILED" || !status.IsDone() {
+		t.Errorf("got group status %s, want FAILED", status.Status)
+	}
+
+	if _, err := client.TransferGetTaskGroupStatus(globus.TaskGroup{GroupId: "empty"}); err == nil {
+		t.Error("got the status of a group without tasks")
+	}
+}
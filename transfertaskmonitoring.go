@@ -10,7 +10,7 @@ import (
 // fetches a list of transfer tasks from Globus Transfer API
 // NOTE: the results are paginated using "offset" and "limit"
 func (g GlobusClient) TransferGetTaskList(offset uint, limit uint) (taskList TaskList, err error) {
-	req, err := http.NewRequest(http.MethodGet, transferBaseUrl+"/task_list", nil)
+	req, err := http.NewRequest(http.MethodGet, g.transferURL()+"/task_list", nil)
 	if err != nil {
 		return TaskList{}, err
 	}
@@ -41,7 +41,7 @@ func (g GlobusClient) TransferGetTaskList(offset uint, limit uint) (taskList Tas
 
 // fetches a specific transfer task from Globus Transfer API by its ID
 func (g GlobusClient) TransferGetTaskByID(taskID string) (task Task, err error) {
-	resp, err := g.client.Get(transferBaseUrl + "/task/" + taskID)
+	resp, err := g.client.Get(g.transferURL() + "/task/" + taskID)
 	if err != nil {
 		return Task{}, err
 	}
@@ -63,7 +63,7 @@ func (g GlobusClient) TransferGetTaskByID(taskID string) (task Task, err error)
 
 // cancels a task using its id
 func (g GlobusClient) TransferCancelTaskByID(taskID string) (result Result, err error) {
-	resp, err := g.client.Post(transferBaseUrl+"/task/"+taskID+"/cancel", "", nil)
+	resp, err := g.client.Post(g.transferURL()+"/task/"+taskID+"/cancel", "", nil)
 	if err != nil {
 		return Result{}, err
 	}
@@ -86,7 +86,7 @@ func (g GlobusClient) TransferCancelTaskByID(taskID string) (result Result, err
 // NOTE: this can be only used under specific conditions: task must be associated with a
 // a high assurance collection, must be either SUCCEEDED or FAILED.
 func (g GlobusClient) TransferRemoveTaskByID(taskID string) (result Result, err error) {
-	resp, err := g.client.Post(transferBaseUrl+"/task/"+taskID+"/remove", "", nil)
+	resp, err := g.client.Post(g.transferURL()+"/task/"+taskID+"/remove", "", nil)
 	if err != nil {
 		return Result{}, err
 	}
@@ -112,7 +112,7 @@ func (g GlobusClient) TransferRemoveTaskByID(taskID string) (result Result, err
 // lists task's events
 // NOTE: the history gets deleted after 30 days
 func (g GlobusClient) TransferGetTaskEventList(taskID string, offset uint, limit uint) (eventList EventList, err error) {
-	resp, err := g.client.Get(transferBaseUrl + "/task/" + taskID + "/event_list")
+	resp, err := g.client.Get(g.transferURL() + "/task/" + taskID + "/event_list")
 	if err != nil {
 		return EventList{}, err
 	}
@@ -133,7 +133,7 @@ func (g GlobusClient) TransferGetTaskEventList(taskID string, offset uint, limit
 
 // retrieve the list of successfully transfered files of a task
 func (g GlobusClient) TransferGetTaskSuccessfulTransfers(taskID string, marker uint) (transfers SuccessfulTransfers, err error) {
-	req, err := http.NewRequest(http.MethodGet, transferBaseUrl+"/task/"+taskID+"/successful_transfers", nil)
+	req, err := http.NewRequest(http.MethodGet, g.transferURL()+"/task/"+taskID+"/successful_transfers", nil)
 	if err != nil {
 		return SuccessfulTransfers{}, err
 	}
@@ -163,7 +163,7 @@ func (g GlobusClient) TransferGetTaskSuccessfulTransfers(taskID string, marker u
 
 // retrieve the list of paths that were skipped because of the skip_source_errors flag being set to true
 func (g GlobusClient) TransferGetTaskSkippedErrors(taskID string, marker uint) (skips SkippedErrors, err error) {
-	req, err := http.NewRequest(http.MethodGet, transferBaseUrl+"/task/"+taskID+"/skipped_errors", nil)
+	req, err := http.NewRequest(http.MethodGet, g.transferURL()+"/task/"+taskID+"/skipped_errors", nil)
 	if err != nil {
 		return SkippedErrors{}, err
 	}
@@ -194,7 +194,7 @@ func (g GlobusClient) TransferGetTaskSkippedErrors(taskID string, marker uint) (
 // provides details about why a task is paused - includes pause rules on source and destination collections
 // and per-task pause flags set by collection activity managers
 func (g GlobusClient) TransferGetTaskPauseInfo(taskID string) (info PauseInfoLimited, err error) {
-	resp, err := g.client.Get(transferBaseUrl + "/task/" + taskID + "/pause_info")
+	resp, err := g.client.Get(g.transferURL() + "/task/" + taskID + "/pause_info")
 	if err != nil {
 		return PauseInfoLimited{}, err
 	}
@@ -213,14 +213,13 @@ func (g GlobusClient) TransferGetTaskPauseInfo(taskID string) (info PauseInfoLim
 	return info, err
 }
 
-// creates a list of scopes to access data on the specified Globus endpoints
+// Creates the list of scopes to access data on the specified Globus endpoints: the transfer scope
+// with the data access of all endpoints as dependencies (see TransferScope).
+// Returns no scopes if no endpoint is given.
 func TransferDataAccessScopeCreator(collectionIDs []string) (scopes []string) {
-	for _, collectionID := range collectionIDs {
-		if collectionID == "" {
-			continue
-		}
-		scopes = append(scopes, "urn:globus:auth:scope:transfer.api.globus.org:all[*https://auth.globus.org/scopes/"+collectionID+"/data_access]")
+	scope := TransferScope(collectionIDs...)
+	if len(scope.Dependencies) == 0 {
+		return nil
 	}
-
-	return scopes
+	return []string{scope.String()}
 }
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/SwissOpenEM/globus/remotepath"
 )
 
 func (c GlobusClient) getSubmissionId() (submissionId string, err error) {
@@ -14,7 +16,7 @@ func (c GlobusClient) getSubmissionId() (submissionId string, err error) {
 		return "", fmt.Errorf("client is nil")
 	}
 
-	resp, err := c.client.Get(transferBaseUrl + "/submission_id")
+	resp, err := c.client.Get(c.transferURL() + "/submission_id")
 	if err != nil {
 		return "", err
 	}
@@ -25,7 +27,7 @@ func (c GlobusClient) getSubmissionId() (submissionId string, err error) {
 	}
 
 	if resp.StatusCode != 200 || resp.Status != "200 OK" {
-		return "", fmt.Errorf("unexpected status for submission id request: %d '%s' - %s", resp.StatusCode, resp.Status, string(body))
+		return "", &APIError{StatusCode: resp.StatusCode, Body: string(body), Message: fmt.Sprintf("unexpected status for submission id request: %d '%s' - %s", resp.StatusCode, resp.Status, string(body))}
 	}
 
 	var result SubmissionId
@@ -39,29 +41,75 @@ func (c GlobusClient) getSubmissionId() (submissionId string, err error) {
 	return result.Value, nil
 }
 
+// Pre-allocates a submission id. A task submitted with this id is only created once: resubmitting
+// the same document with the same id (e.g. after a timeout) returns the task id of the original
+// submission instead of creating a duplicate task. Submission ids expire after some time.
+func (c GlobusClient) TransferGetSubmissionId() (submissionId string, err error) {
+	return c.getSubmissionId()
+}
+
 // Submits a generic transfer request using a Transfer struct.
-// This function doesn't check whether the transfer struct is valid.
-// You don't need to set the submission id of the transfer, this function does that for you.
+// This function doesn't check whether the transfer struct is valid, except in dry-run mode.
+// You don't need to set the submission id of the transfer, this function does that for you. If it's
+// set, e.g. to a pre-allocated id, it's used as-is and the submission is idempotent (see TransferGetSubmissionId).
 func (c GlobusClient) TransferPostTask(transfer Transfer) (result TransferResult, err error) {
-	// get submission id for submission
-	submission_id, err := c.getSubmissionId()
-	if err != nil {
-		return TransferResult{}, err
+	if c.dryRun {
+		if err := ValidateTransfer(transfer); err != nil {
+			return TransferResult{}, err
+		}
 	}
+	return c.submitTask("/transfer", &transfer.CommonTransfer, &transfer)
+}
 
-	// formulate request
-	transfer.CommonTransfer.SubmissionId = submission_id
+// Submits a generic delete request using a Delete struct.
+// This function doesn't check whether the delete struct is valid, except in dry-run mode.
+// You don't need to set the submission id of the delete, this function does that for you. If it's
+// set, e.g. to a pre-allocated id, it's used as-is and the submission is idempotent (see TransferGetSubmissionId).
+func (c GlobusClient) TransferDeletePostTask(del Delete) (result TransferResult, err error) {
+	if c.dryRun {
+		if err := ValidateDelete(del); err != nil {
+			return TransferResult{}, err
+		}
+	}
+	return c.submitTask("/delete", &del.CommonTransfer, &del)
+}
 
-	transferJSON, err := json.Marshal(transfer)
+// sets the submission id of a task document (if it's not pre-allocated) and posts it to the given
+// Transfer API path. task must point to the document that embeds common, otherwise the submission
+// id doesn't end up in the request. A resubmission of a known submission id returns the original
+// task's result. In dry-run mode, only the marshalled document is returned.
+func (c GlobusClient) submitTask(path string, common *CommonTransfer, task any) (result TransferResult, err error) {
+	if c.dryRun {
+		taskJSON, err := json.MarshalIndent(task, "", "  ")
+		if err != nil {
+			return TransferResult{}, err
+		}
+		return TransferResult{
+			DataType:     "dry_run",
+			SubmissionId: common.SubmissionId,
+			Payload:      taskJSON,
+		}, nil
+	}
+
+	// get submission id for submission, unless one was pre-allocated
+	if common.SubmissionId == "" {
+		submission_id, err := c.getSubmissionId()
+		if err != nil {
+			return TransferResult{}, err
+		}
+		common.SubmissionId = submission_id
+	}
+
+	taskJSON, err := json.Marshal(task)
 	if err != nil {
 		return TransferResult{}, err
 	}
 
 	// send request
 	resp, err := c.client.Post(
-		transferBaseUrl+"/transfer",
+		c.transferURL()+path,
 		"application/json",
-		bytes.NewBuffer(transferJSON),
+		bytes.NewBuffer(taskJSON),
 	)
 	if err != nil {
 		return TransferResult{}, err
@@ -77,12 +125,18 @@ func (c GlobusClient) TransferPostTask(transfer Transfer) (result TransferResult
 	if resp.StatusCode == 403 {
 		var consent ConsentRequired
 		err = json.Unmarshal(body, &consent)
-		if err != nil {
-			return TransferResult{}, fmt.Errorf("unknown 403 forbidden error - status: %s, body: \"%s\"", resp.Status, string(body))
+		if err != nil || consent.Code != "ConsentRequired" {
+			return TransferResult{}, &APIError{StatusCode: resp.StatusCode, Body: string(body), Message: fmt.Sprintf("unknown 403 forbidden error - status: %s, body: \"%s\"", resp.Status, string(body))}
 		}
-		return TransferResult{}, fmt.Errorf("consent is required: %+v", consent)
-	} else if resp.StatusCode != 200 {
-		return TransferResult{}, fmt.Errorf("unknown http code %d, body: \"%s\"", resp.StatusCode, string(body))
+		return TransferResult{}, &ConsentRequiredError{ConsentRequired: consent}
+	} else if resp.StatusCode == 409 {
+		// the submission id was already used: return the task created by the original submission
+		if err := json.Unmarshal(body, &result); err == nil && result.Code == "Duplicate" && result.TaskId != "" {
+			return result, nil
+		}
+		return TransferResult{}, &APIError{StatusCode: resp.StatusCode, Body: string(body), Message: fmt.Sprintf("conflicting submission - status: %s, body: \"%s\"", resp.Status, string(body))}
+	} else if resp.StatusCode != 200 && resp.StatusCode != 202 {
+		return TransferResult{}, &APIError{StatusCode: resp.StatusCode, Body: string(body), Message: fmt.Sprintf("unknown http code %d, body: \"%s\"", resp.StatusCode, string(body))}
 	}
 
 	err = json.Unmarshal(body, &result)
@@ -101,8 +155,8 @@ func (c GlobusClient) TransferCopyFile(client *http.Client, sourceEndpoint strin
 		Data: []TransferItem{
 			{
 				DataType:        "transfer_item",
-				SourcePath:      sourceFile,
-				DestinationPath: destFile,
+				SourcePath:      remotepath.Clean(sourceFile),
+				DestinationPath: remotepath.Clean(destFile),
 			},
 		},
 	}
@@ -126,8 +180,8 @@ func (c GlobusClient) TransferFolderSync(sourceEndpoint string, sourcePath strin
 		Data: []TransferItem{
 			{
 				DataType:        "transfer_item",
-				SourcePath:      sourcePath,
-				DestinationPath: destPath,
+				SourcePath:      remotepath.AsDir(sourcePath),
+				DestinationPath: remotepath.AsDir(destPath),
 				Recursive:       boolPointer(true),
 			},
 		},
@@ -137,21 +191,42 @@ func (c GlobusClient) TransferFolderSync(sourceEndpoint string, sourcePath strin
 	return c.TransferPostTask(transfer)
 }
 
-func (c GlobusClient) TransferFileList(sourceEndpoint string, sourcePath string, destEndpoint string, destPath string, fileList []string, isSymlink []bool, storeBasePath bool) (TransferResult, error) {
+// Creates the transfer items for a list of files and directories relative to sourcePath and destPath.
+// Entries can also be absolute or home-relative ("~/...") paths, as long as they are inside sourcePath.
+// Entries escaping sourcePath (e.g. through "..") are rejected. Entries ending with a slash are
+// treated as directories and are transferred recursively (unless they're symlinks).
+func TransferFileListItems(sourcePath string, destPath string, fileList []string, isSymlink []bool) ([]TransferItem, error) {
 	if len(isSymlink) > 0 && len(fileList) != len(isSymlink) {
-		return TransferResult{}, errors.New("isSymlink list is defined and is not the same length as fileList")
+		return nil, errors.New("isSymlink list is defined and is not the same length as fileList")
 	}
 	var tItems []TransferItem
 	for i, file := range fileList {
-		itemType := "transfer_item"
+		rel, err := remotepath.RelWithin(sourcePath, file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file list entry '%s': %v", file, err)
+		}
+
+		item := TransferItem{
+			DataType:        "transfer_item",
+			SourcePath:      remotepath.Join(sourcePath, rel),
+			DestinationPath: remotepath.Join(destPath, rel),
+		}
 		if len(isSymlink) > 0 && isSymlink[i] {
-			itemType = "transfer_symlink_item"
+			item.DataType = "transfer_symlink_item"
+			item.SourcePath = remotepath.TrimDir(item.SourcePath)
+			item.DestinationPath = remotepath.TrimDir(item.DestinationPath)
+		} else if remotepath.IsDir(rel) {
+			item.Recursive = boolPointer(true)
 		}
-		tItems = append(tItems, TransferItem{
-			DataType:        itemType,
-			SourcePath:      sourcePath + "/" + file,
-			DestinationPath: destPath + "/" + file,
-		})
+		tItems = append(tItems, item)
+	}
+	return tItems, nil
+}
+
+func (c GlobusClient) TransferFileList(sourceEndpoint string, sourcePath string, destEndpoint string, destPath string, fileList []string, isSymlink []bool, storeBasePath bool) (TransferResult, error) {
+	tItems, err := TransferFileListItems(sourcePath, destPath, fileList, isSymlink)
+	if err != nil {
+		return TransferResult{}, err
 	}
 
 	transfer := Transfer{
@@ -167,3 +242,27 @@ func (c GlobusClient) TransferFileList(sourceEndpoint string, sourcePath string,
 
 	return c.TransferPostTask(transfer)
 }
+
+// submits a delete task removing a list of paths on an endpoint.
+// Directories are only removed if recursive is set.
+func (c GlobusClient) TransferDeletePaths(endpoint string, paths []string, recursive bool) (TransferResult, error) {
+	var dItems []DeleteItem
+	for _, p := range paths {
+		dItems = append(dItems, DeleteItem{
+			DataType: "delete_item",
+			Path:     remotepath.Clean(p),
+		})
+	}
+
+	del := Delete{
+		CommonTransfer: CommonTransfer{
+			DataType:     "delete",
+			SubmissionId: "",
+		},
+		Endpoint:  endpoint,
+		Data:      dItems,
+		Recursive: boolPointer(recursive),
+	}
+
+	return c.TransferDeletePostTask(del)
+}
@@ -0,0 +1,206 @@
+package globus_test
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/SwissOpenEM/globus"
+	"github.com/SwissOpenEM/globus/globustest"
+)
+
+const (
+	srcEndpoint = "6f1b0c3e-0000-4000-8000-00000000000a"
+	dstEndpoint = "6f1b0c3e-0000-4000-8000-00000000000b"
+)
+
+func testTransfer() globus.Transfer {
+	transfer := globus.Transfer{
+		SourceEndpoint:      srcEndpoint,
+		DestinationEndpoint: dstEndpoint,
+		Data: []globus.TransferItem{
+			{DataType: "transfer_item", SourcePath: "/data/a.txt", DestinationPath: "/archive/a.txt"},
+		},
+	}
+	transfer.DataType = "transfer"
+	return transfer
+}
+
+func TestTransferFolderSync(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	srv.AddFile(srcEndpoint, "/data/a.txt", 10)
+	srv.AddFile(srcEndpoint, "/data/sub/b.txt", 20)
+	client := srv.Client()
+
+	result, err := client.TransferFolderSync(srcEndpoint, "/data", dstEndpoint, "/archive", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TaskId == "" || result.SubmissionId == "" {
+		t.Fatalf("result without task or submission id: %+v", result)
+	}
+
+	srv.Advance(srv.TaskDuration)
+	task, err := client.TransferGetTaskByID(result.TaskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != "SUCCEEDED" || task.Files != 2 {
+		t.Errorf("got status %s with %d files, want SUCCEEDED with 2 files", task.Status, task.Files)
+	}
+	for _, file := range []string{"/archive/a.txt", "/archive/sub/b.txt"} {
+		if !srv.Exists(dstEndpoint, file) {
+			t.Errorf("%s wasn't transferred", file)
+		}
+	}
+}
+
+func TestTransferPostTaskDuplicate(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+
+	transfer := testTransfer()
+	submissionId, err := client.TransferGetSubmissionId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer.SubmissionId = submissionId
+
+	first, err := client.TransferPostTask(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.TransferPostTask(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Code != "Duplicate" || second.TaskId != first.TaskId {
+		t.Errorf("resubmission returned %s with task %s, want Duplicate with task %s", second.Code, second.TaskId, first.TaskId)
+	}
+	if transfers, _ := srv.Submitted(); len(transfers) != 1 {
+		t.Errorf("%d tasks were created, want 1", len(transfers))
+	}
+}
+
+func TestTransferPostTaskRetry(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+
+	transfer := testTransfer()
+	submissionId, err := client.TransferGetSubmissionId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer.SubmissionId = submissionId
+
+	srv.InjectFault(globustest.Fault{Method: "POST", PathPrefix: "/transfer", StatusCode: 503})
+	_, err = client.TransferPostTask(transfer)
+	var apiErr *globus.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 503 {
+		t.Fatalf("got error %v, want a 503 API error", err)
+	}
+	if !globus.IsTemporaryError(err) {
+		t.Errorf("a 503 error isn't temporary")
+	}
+
+	result, err := client.TransferPostTask(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SubmissionId != submissionId {
+		t.Errorf("retry was submitted as %s, want %s", result.SubmissionId, submissionId)
+	}
+	if transfers, _ := srv.Submitted(); len(transfers) != 1 {
+		t.Errorf("%d tasks were created, want 1", len(transfers))
+	}
+}
+
+func TestTransferPostTaskConsentRequired(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+
+	scope := "urn:globus:auth:scope:transfer.api.globus.org:all[*https://auth.globus.org/scopes/" + srcEndpoint + "/data_access]"
+	srv.InjectFault(globustest.ConsentRequiredFault("/transfer", scope))
+
+	_, err := client.TransferPostTask(testTransfer())
+	var consentErr *globus.ConsentRequiredError
+	if !errors.As(err, &consentErr) {
+		t.Fatalf("got error %v, want a ConsentRequiredError", err)
+	}
+	if !slices.Equal(consentErr.RequiredScopes, []string{scope}) {
+		t.Errorf("got required scopes %v, want [%s]", consentErr.RequiredScopes, scope)
+	}
+	if globus.IsTemporaryError(err) {
+		t.Errorf("a consent required error is temporary")
+	}
+}
+
+func TestTransferPostTaskDryRun(t *testing.T) {
+	srv := globustest.NewServer()
+	defer srv.Close()
+	client := srv.Client().WithDryRun(true)
+
+	result, err := client.TransferPostTask(testTransfer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DataType != "dry_run" || result.TaskId != "" {
+		t.Errorf("got %s result with task '%s', want a dry_run result without task", result.DataType, result.TaskId)
+	}
+	var payload globus.Transfer
+	if err := json.Unmarshal(result.Payload, &payload); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if payload.SourceEndpoint != srcEndpoint || len(payload.Data) != 1 {
+		t.Errorf("payload doesn't match the transfer: %s", result.Payload)
+	}
+
+	invalid := testTransfer()
+	invalid.Data = nil
+	if _, err := client.TransferPostTask(invalid); err == nil {
+		t.Errorf("an invalid transfer was accepted in dry-run mode")
+	}
+
+	if transfers, _ := srv.Submitted(); len(transfers) != 0 {
+		t.Errorf("%d tasks were created in dry-run mode", len(transfers))
+	}
+}
+
+func TestTransferFileListItems(t *testing.T) {
+	items, err := globus.TransferFileListItems("~/src", "/dst", []string{"a.txt", "sub/", "~/src/b.txt", "/abs/c"}, []bool{false, false, false, true})
+	if err == nil {
+		t.Fatal("an absolute entry outside a home-relative source path was accepted")
+	}
+
+	items, err = globus.TransferFileListItems("~/src", "/dst", []string{"a.txt", "sub/", "~/src/b.txt"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []globus.TransferItem{
+		{DataType: "transfer_item", SourcePath: "~/src/a.txt", DestinationPath: "/dst/a.txt"},
+		{DataType: "transfer_item", SourcePath: "~/src/sub/", DestinationPath: "/dst/sub/"},
+		{DataType: "transfer_item", SourcePath: "~/src/b.txt", DestinationPath: "/dst/b.txt"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i, item := range items {
+		if item.DataType != want[i].DataType || item.SourcePath != want[i].SourcePath || item.DestinationPath != want[i].DestinationPath {
+			t.Errorf("item %d: got %+v, want %+v", i, item, want[i])
+		}
+	}
+	if items[1].Recursive == nil || !*items[1].Recursive {
+		t.Error("the directory entry isn't recursive")
+	}
+
+	for _, entry := range []string{"../x", "sub/../../x", "~/../src/x", "~/other/x"} {
+		if _, err := globus.TransferFileListItems("~/src", "/dst", []string{entry}, nil); err == nil {
+			t.Errorf("entry '%s' escaping the source path was accepted", entry)
+		}
+	}
+}
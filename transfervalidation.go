@@ -0,0 +1,88 @@
+package globus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// checks a transfer document for the mistakes that the Transfer API would reject it for
+func ValidateTransfer(transfer Transfer) error {
+	if transfer.DataType != "transfer" {
+		return fmt.Errorf("invalid DATA_TYPE '%s' for transfer", transfer.DataType)
+	}
+	if transfer.SourceEndpoint == "" {
+		return errors.New("source endpoint is not set")
+	}
+	if transfer.DestinationEndpoint == "" {
+		return errors.New("destination endpoint is not set")
+	}
+	if len(transfer.Data) == 0 {
+		return errors.New("transfer has no items")
+	}
+	if transfer.SyncLevel != nil && (*transfer.SyncLevel < 0 || *transfer.SyncLevel > 3) {
+		return fmt.Errorf("invalid sync level %d, must be between 0 and 3", *transfer.SyncLevel)
+	}
+	if transfer.FilterRules != nil {
+		for i, rule := range *transfer.FilterRules {
+			if rule.DataType != "filter_rule" {
+				return fmt.Errorf("filter rule %d: invalid DATA_TYPE '%s'", i, rule.DataType)
+			}
+			if rule.Method != "include" && rule.Method != "exclude" {
+				return fmt.Errorf("filter rule %d: invalid method '%s'", i, rule.Method)
+			}
+		}
+	}
+
+	for i, item := range transfer.Data {
+		if err := validateTransferItem(item); err != nil {
+			return fmt.Errorf("item %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func validateTransferItem(item TransferItem) error {
+	if item.SourcePath == "" || item.DestinationPath == "" {
+		return errors.New("source and destination paths must be set")
+	}
+
+	switch item.DataType {
+	case "transfer_item":
+		// the algorithm is optional (e.g. in globus-cli batch input), but useless without a checksum
+		if item.ChecksumAlgorithm != nil && item.ExternalChecksum == nil {
+			return fmt.Errorf("'%s': a checksum algorithm requires an external checksum", item.SourcePath)
+		}
+		if item.ExternalChecksum != nil && item.Recursive != nil && *item.Recursive {
+			return fmt.Errorf("'%s': recursive items can't have an external checksum", item.SourcePath)
+		}
+	case "transfer_symlink_item":
+		if item.Recursive != nil || item.ExternalChecksum != nil || item.ChecksumAlgorithm != nil {
+			return fmt.Errorf("'%s': symlink items can't have recursive or checksum options", item.SourcePath)
+		}
+	default:
+		return fmt.Errorf("invalid DATA_TYPE '%s'", item.DataType)
+	}
+	return nil
+}
+
+// checks a delete document for the mistakes that the Transfer API would reject it for
+func ValidateDelete(del Delete) error {
+	if del.DataType != "delete" {
+		return fmt.Errorf("invalid DATA_TYPE '%s' for delete", del.DataType)
+	}
+	if del.Endpoint == "" {
+		return errors.New("endpoint is not set")
+	}
+	if len(del.Data) == 0 {
+		return errors.New("delete has no items")
+	}
+	for i, item := range del.Data {
+		if item.DataType != "delete_item" {
+			return fmt.Errorf("item %d: invalid DATA_TYPE '%s'", i, item.DataType)
+		}
+		if item.Path == "" {
+			return fmt.Errorf("item %d: path must be set", i)
+		}
+	}
+	return nil
+}